@@ -0,0 +1,292 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"free5gc/lib/MongoDBLibrary"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+)
+
+const collName = "etaf.auditLog"
+
+type Action string
+
+const (
+	ActionLocationDisclosure Action = "LOCATION_DISCLOSURE"
+	ActionAlertIssue         Action = "ALERT_ISSUE"
+	ActionAlertCancel        Action = "ALERT_CANCEL"
+	ActionLocationPurge      Action = "LOCATION_PURGE"
+	ActionAuditRead          Action = "AUDIT_READ"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "SUCCESS"
+	OutcomeFailure Outcome = "FAILURE"
+)
+
+// Entry is one record of the audit trail. Entries are chained: Hash covers every
+// other field, PrevHash included, so altering or removing an entry breaks the chain.
+// Data is covered through DataHash, so it can be purged once its retention period
+// elapsed without breaking the chain.
+type Entry struct {
+	Sequence      int64     `json:"sequence" bson:"sequence"`
+	Time          time.Time `json:"time" bson:"time"`
+	Caller        string    `json:"caller" bson:"caller"` // verified token or client certificate subject, else address
+	Action        Action    `json:"action" bson:"action"`
+	UeId          string    `json:"ueId,omitempty" bson:"ueId,omitempty"`
+	AlertId       string    `json:"alertId,omitempty" bson:"alertId,omitempty"`
+	Purpose       string    `json:"purpose,omitempty" bson:"purpose,omitempty"`
+	Data          string    `json:"data,omitempty" bson:"data,omitempty"` // JSON of the data disclosed
+	DataHash      string    `json:"dataHash,omitempty" bson:"dataHash,omitempty"`
+	Outcome       Outcome   `json:"outcome" bson:"outcome"`
+	Cause         string    `json:"cause,omitempty" bson:"cause,omitempty"`
+	CorrelationId string    `json:"correlationId,omitempty" bson:"correlationId,omitempty"`
+	PrevHash      string    `json:"prevHash" bson:"prevHash"`
+	Hash          string    `json:"hash" bson:"hash"`
+}
+
+type Filter struct {
+	Caller  string
+	Action  Action
+	UeId    string
+	AlertId string
+	Outcome Outcome
+	From    *time.Time
+	To      *time.Time
+	Limit   int64
+}
+
+type Verification struct {
+	Valid        bool      `json:"valid"`
+	Entries      int64     `json:"entries"`
+	FirstInvalid int64     `json:"firstInvalid,omitempty"` // sequence of the first entry breaking the chain
+	LastSequence int64     `json:"lastSequence"`           // last entry verified
+	CheckedAt    time.Time `json:"checkedAt"`
+}
+
+var chainMutex sync.Mutex
+var lastSequence int64
+var lastHash string
+
+// Init resumes the hash chain from the last stored entry and opens the file sink
+func Init() error {
+	chainMutex.Lock()
+	defer chainMutex.Unlock()
+
+	var last Entry
+	err := collection().FindOne(context.Background(), bson.M{},
+		options.FindOne().SetSort(bson.M{"sequence": -1})).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return fmt.Errorf("Load last audit entry error: %+v", err)
+	} else if err == nil {
+		lastSequence = last.Sequence
+		lastHash = last.Hash
+	}
+
+	if auditConfig := factory.EtafConfig.Configuration.Audit; auditConfig != nil && auditConfig.FilePath != "" {
+		if err := openFileSink(auditConfig.FilePath, auditConfig.SigningKeyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record appends an entry to the audit trail. data is the payload disclosed to
+// the caller and is stored as JSON. The payload must not be disclosed when the
+// entry could not be recorded.
+func Record(entry Entry, data interface{}) error {
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			logger.AuditLog.Errorf("Marshal audit data error: %+v", err)
+			return fmt.Errorf("Marshal audit data error: %+v", err)
+		}
+		entry.Data = string(raw)
+		entry.DataHash = hashData(entry.Data)
+	}
+
+	chainMutex.Lock()
+	defer chainMutex.Unlock()
+
+	if err := appendEntry(&entry); err != nil {
+		return err
+	}
+
+	if err := writeFileSink(entry); err != nil {
+		logger.AuditLog.Errorf("Write audit entry[%d] to file error: %+v", entry.Sequence, err)
+		// the entry is chained as a success the caller never sees: record its
+		// failure, in MongoDB only since the file is failing
+		compensation := Entry{
+			Caller:        entry.Caller,
+			Action:        entry.Action,
+			UeId:          entry.UeId,
+			AlertId:       entry.AlertId,
+			Outcome:       OutcomeFailure,
+			Cause:         fmt.Sprintf("entry[%d] not written to the audit file", entry.Sequence),
+			CorrelationId: entry.CorrelationId,
+		}
+		if err := appendEntry(&compensation); err != nil {
+			logger.AuditLog.Errorf("Compensate audit entry[%d] error: %+v", entry.Sequence, err)
+		}
+		return err
+	}
+
+	logger.WithAlertID(logger.WithSupi(logger.WithCorrelationID(logger.AuditLog, entry.CorrelationId),
+		entry.UeId), entry.AlertId).Infof("Caller[%s] %s %s", entry.Caller, entry.Action, entry.Outcome)
+	return nil
+}
+
+// appendEntry chains entry after the last one and stores it; it must be called
+// with chainMutex held
+func appendEntry(entry *Entry) error {
+	// BSON dates have millisecond precision; truncate so the stored entry hashes the same
+	entry.Time = time.Now().UTC().Truncate(time.Millisecond)
+	entry.Sequence = lastSequence + 1
+	entry.PrevHash = lastHash
+	entry.Hash = hashEntry(*entry)
+
+	if _, err := collection().InsertOne(context.Background(), entry); err != nil {
+		logger.AuditLog.Errorf("Store audit entry[%d] error: %+v", entry.Sequence, err)
+		return fmt.Errorf("Store audit entry error: %+v", err)
+	}
+	lastSequence = entry.Sequence
+	lastHash = entry.Hash
+	return nil
+}
+
+// Query returns the entries matching filter in chain order
+func Query(filter Filter) ([]Entry, error) {
+	query := bson.M{}
+	if filter.Caller != "" {
+		query["caller"] = filter.Caller
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.UeId != "" {
+		query["ueId"] = filter.UeId
+	}
+	if filter.AlertId != "" {
+		query["alertId"] = filter.AlertId
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	if filter.From != nil || filter.To != nil {
+		timeRange := bson.M{}
+		if filter.From != nil {
+			timeRange["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			timeRange["$lte"] = *filter.To
+		}
+		query["time"] = timeRange
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"sequence": 1})
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+	return find(query, findOptions)
+}
+
+// Verify walks the whole chain and reports the first entry whose hash or link is broken
+func Verify() (*Verification, error) {
+	entries, err := find(bson.M{}, options.Find().SetSort(bson.M{"sequence": 1}))
+	if err != nil {
+		return nil, err
+	}
+	return verifyChain(entries), nil
+}
+
+// verifyChain checks the entries, in sequence order, from the first one
+func verifyChain(entries []Entry) *Verification {
+	verification := &Verification{
+		Valid:     true,
+		Entries:   int64(len(entries)),
+		CheckedAt: time.Now().UTC(),
+	}
+	prevHash := ""
+	for index, entry := range entries {
+		expectedSequence := int64(index) + 1
+		if entry.Sequence != expectedSequence || entry.PrevHash != prevHash || entry.Hash != hashEntry(entry) ||
+			(entry.Data != "" && entry.DataHash != "" && entry.DataHash != hashData(entry.Data)) {
+			verification.Valid = false
+			verification.FirstInvalid = expectedSequence
+			break
+		}
+		prevHash = entry.Hash
+		verification.LastSequence = entry.Sequence
+	}
+	return verification
+}
+
+// PurgeData drops the data disclosed by the entries recorded before purgeBefore,
+// keeping the entries themselves, and returns how many entries were purged.
+// Entries recorded before Data was hashed separately are kept whole.
+func PurgeData(purgeBefore time.Time) (int64, error) {
+	result, err := collection().UpdateMany(context.Background(), bson.M{
+		"time":     bson.M{"$lt": purgeBefore},
+		"data":     bson.M{"$exists": true},
+		"dataHash": bson.M{"$exists": true},
+	}, bson.M{"$unset": bson.M{"data": ""}})
+	if err != nil {
+		return 0, fmt.Errorf("Purge audit data error: %+v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+func hashEntry(entry Entry) string {
+	entry.Hash = ""
+	if entry.DataHash != "" {
+		entry.Data = ""
+	}
+	entry.Time = entry.Time.UTC()
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		logger.AuditLog.Errorf("Marshal audit entry error: %+v", err)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashData(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func find(query bson.M, findOptions *options.FindOptions) ([]Entry, error) {
+	ctx := context.Background()
+	cursor, err := collection().Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("Find audit entries error: %+v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []Entry
+	for cursor.Next(ctx) {
+		var entry Entry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("Decode audit entry error: %+v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, cursor.Err()
+}
+
+func collection() *mongo.Collection {
+	return MongoDBLibrary.Client.Database(factory.EtafConfig.Configuration.MongoDBName).Collection(collName)
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+// chain links the entries as Record does
+func chain(entries []Entry) []Entry {
+	prevHash := ""
+	for i := range entries {
+		entries[i].Sequence = int64(i) + 1
+		entries[i].Time = time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC)
+		if entries[i].Data != "" {
+			entries[i].DataHash = hashData(entries[i].Data)
+		}
+		entries[i].PrevHash = prevHash
+		entries[i].Hash = hashEntry(entries[i])
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func newChain() []Entry {
+	return chain([]Entry{
+		{Caller: "af-1", Action: ActionLocationDisclosure, UeId: "imsi-208930000000001",
+			Data: `{"tac":"000001"}`, Outcome: OutcomeSuccess},
+		{Caller: "af-1", Action: ActionAlertIssue, AlertId: "1", Outcome: OutcomeSuccess},
+		{Caller: "af-2", Action: ActionLocationDisclosure, UeId: "imsi-208930000000002",
+			Outcome: OutcomeFailure, Cause: "USER_NOT_FOUND"},
+	})
+}
+
+func TestVerifyChain(t *testing.T) {
+	testCases := []struct {
+		name         string
+		tamper       func(entries []Entry) []Entry
+		valid        bool
+		firstInvalid int64
+		lastSequence int64
+	}{
+		{
+			name:         "intact",
+			tamper:       func(entries []Entry) []Entry { return entries },
+			valid:        true,
+			lastSequence: 3,
+		},
+		{
+			name:   "empty",
+			tamper: func(entries []Entry) []Entry { return nil },
+			valid:  true,
+		},
+		{
+			name: "data purged",
+			tamper: func(entries []Entry) []Entry {
+				entries[0].Data = ""
+				return entries
+			},
+			valid:        true,
+			lastSequence: 3,
+		},
+		{
+			name: "data altered",
+			tamper: func(entries []Entry) []Entry {
+				entries[0].Data = `{"tac":"000002"}`
+				return entries
+			},
+			firstInvalid: 1,
+		},
+		{
+			name: "field altered",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].Caller = "af-2"
+				return entries
+			},
+			firstInvalid: 2,
+			lastSequence: 1,
+		},
+		{
+			name: "entry removed",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries[:1], entries[2:]...)
+			},
+			firstInvalid: 2,
+			lastSequence: 1,
+		},
+		{
+			name: "entry rehashed",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].Outcome = OutcomeFailure
+				entries[1].Hash = hashEntry(entries[1])
+				return entries
+			},
+			firstInvalid: 3,
+			lastSequence: 2,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			entries := testCase.tamper(newChain())
+			verification := verifyChain(entries)
+			if verification.Valid != testCase.valid || verification.FirstInvalid != testCase.firstInvalid ||
+				verification.LastSequence != testCase.lastSequence {
+				t.Errorf("verification %+v, expected valid %t, first invalid %d, last sequence %d",
+					verification, testCase.valid, testCase.firstInvalid, testCase.lastSequence)
+			}
+			if verification.Entries != int64(len(entries)) {
+				t.Errorf("%d entries verified, expected %d", verification.Entries, len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"free5gc/src/etaf/logger"
+)
+
+// fileLine is one line of the audit file. Signature is the hex HMAC-SHA256 of the
+// JSON encoded entry, empty when no signing key is configured. The data disclosed
+// is left out, the file cannot be purged; its DataHash still binds the entry to it.
+type fileLine struct {
+	Entry     Entry  `json:"entry"`
+	Signature string `json:"signature,omitempty"`
+}
+
+var auditFile *os.File
+var signingKey []byte
+
+// openFileSink must be called with chainMutex held
+func openFileSink(filePath, signingKeyPath string) error {
+	if signingKeyPath != "" {
+		key, err := ioutil.ReadFile(signingKeyPath)
+		if err != nil {
+			return fmt.Errorf("Read audit signing key error: %+v", err)
+		}
+		signingKey = []byte(strings.TrimSpace(string(key)))
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("Open audit file error: %+v", err)
+	}
+	auditFile = file
+	return nil
+}
+
+// writeFileSink must be called with chainMutex held
+func writeFileSink(entry Entry) error {
+	if auditFile == nil {
+		return nil
+	}
+
+	if entry.DataHash != "" {
+		entry.Data = ""
+	}
+	line := fileLine{Entry: entry}
+	if len(signingKey) != 0 {
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("Marshal audit entry error: %+v", err)
+		}
+		mac := hmac.New(sha256.New, signingKey)
+		mac.Write(raw)
+		line.Signature = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	raw, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("Marshal audit line error: %+v", err)
+	}
+	if _, err := auditFile.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("Write audit file error: %+v", err)
+	}
+	return nil
+}
+
+// Close closes the audit file
+func Close() {
+	chainMutex.Lock()
+	defer chainMutex.Unlock()
+
+	if auditFile == nil {
+		return
+	}
+	if err := auditFile.Close(); err != nil {
+		logger.AuditLog.Warnf("Close audit file error: %+v", err)
+	}
+	auditFile = nil
+}
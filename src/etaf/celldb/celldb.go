@@ -0,0 +1,228 @@
+package celldb
+
+import (
+	"fmt"
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Radio access technologies of a cell
+const (
+	RatNr    = "nr"
+	RatEutra = "eutra"
+)
+
+// Cell locates the antenna of an NR or E-UTRA cell. Azimuth is the direction of a
+// sector in degrees clockwise from north; an omnidirectional cell has none.
+type Cell struct {
+	Id      string        `json:"id" bson:"id"`
+	Rat     string        `json:"rat" bson:"rat"`
+	PlmnId  models.PlmnId `json:"plmnId" bson:"plmnId"`
+	CellId  string        `json:"cellId" bson:"cellId"` // NR cell identity or E-UTRA cell identity, hexadecimal
+	Lat     float64       `json:"lat" bson:"lat"`
+	Lon     float64       `json:"lon" bson:"lon"`
+	Azimuth *float64      `json:"azimuth,omitempty" bson:"azimuth,omitempty"`
+	Range   float32       `json:"range" bson:"range"` // meters
+}
+
+// CellKey identifies a cell in the database, e.g. nr-20893-000000010
+func CellKey(rat string, plmnId models.PlmnId, cellId string) string {
+	return fmt.Sprintf("%s-%s%s-%s", rat, plmnId.Mcc, plmnId.Mnc, strings.ToLower(cellId))
+}
+
+// Validate checks the cell and sets its Id
+func (cell *Cell) Validate() error {
+	if cell.Rat != RatNr && cell.Rat != RatEutra {
+		return fmt.Errorf("rat must be %s or %s", RatNr, RatEutra)
+	}
+	if cell.PlmnId.Mcc == "" || cell.PlmnId.Mnc == "" || cell.CellId == "" {
+		return fmt.Errorf("plmnId and cellId are required")
+	}
+	if cell.Lat < -90 || cell.Lat > 90 || cell.Lon < -180 || cell.Lon > 180 {
+		return fmt.Errorf("coordinates (%f, %f) out of range", cell.Lat, cell.Lon)
+	}
+	if cell.Azimuth != nil && (*cell.Azimuth < 0 || *cell.Azimuth >= 360) {
+		return fmt.Errorf("azimuth must be in [0, 360)")
+	}
+	if cell.Range <= 0 {
+		return fmt.Errorf("range must be positive")
+	}
+	cell.Id = CellKey(cell.Rat, cell.PlmnId, cell.CellId)
+	return nil
+}
+
+// Estimate approximates the location of a UE served by the cell: the middle of the
+// sector, or the site of an omnidirectional cell, with the uncertainty covering
+// the cell
+func (cell *Cell) Estimate() etaf_context.GeographicArea {
+	center := etaf_context.GeographicalCoordinates{Lat: cell.Lat, Lon: cell.Lon}
+	uncertainty := cell.Range
+	if cell.Azimuth != nil {
+		center = etaf_context.Offset(center, *cell.Azimuth, float64(cell.Range)/2)
+		uncertainty = cell.Range / 2
+	}
+	return etaf_context.GeographicArea{
+		Shape:       etaf_context.GADShapePointUncertaintyCircle,
+		Point:       &center,
+		Uncertainty: uncertainty,
+	}
+}
+
+// sectorWidth is the beamwidth assumed for sector cells, in degrees
+const sectorWidth = 120
+
+// Coverage approximates the area served by the cell: a disc around the site of
+// an omnidirectional cell, or a circular sector along the azimuth
+func (cell *Cell) Coverage() etaf_context.GeographicArea {
+	site := etaf_context.GeographicalCoordinates{Lat: cell.Lat, Lon: cell.Lon}
+	if cell.Azimuth == nil {
+		return etaf_context.GeographicArea{
+			Shape:       etaf_context.GADShapePointUncertaintyCircle,
+			Point:       &site,
+			Uncertainty: cell.Range,
+		}
+	}
+	pointList := []etaf_context.GeographicalCoordinates{site}
+	for azimuth := *cell.Azimuth - sectorWidth/2; azimuth <= *cell.Azimuth+sectorWidth/2; azimuth += 10 {
+		pointList = append(pointList, etaf_context.Offset(site, azimuth, float64(cell.Range)))
+	}
+	return etaf_context.GeographicArea{
+		Shape:     etaf_context.GADShapePolygon,
+		PointList: pointList,
+	}
+}
+
+// store persists the cell database
+type store interface {
+	load() ([]Cell, error)
+	put(cell Cell, cells []Cell) error
+	delete(id string, cells []Cell) error
+}
+
+var cells = make(map[string]Cell) // Id as key
+var cellsMutex sync.RWMutex
+var cellStore store
+
+// Init loads the cell database from the CSV file or the MongoDB collection
+// configured
+func Init() error {
+	config := factory.EtafConfig.Configuration.CellDatabase
+	if config != nil && config.Source == factory.CellDatabaseSourceCsv {
+		if config.CsvPath == "" {
+			return fmt.Errorf("cell database CSV path not configured")
+		}
+		cellStore = &csvStore{path: config.CsvPath}
+	} else {
+		cellStore = &mongoStore{}
+	}
+
+	loaded, err := cellStore.load()
+	if err != nil {
+		return err
+	}
+
+	cellsMutex.Lock()
+	defer cellsMutex.Unlock()
+	for _, cell := range loaded {
+		if err := cell.Validate(); err != nil {
+			logger.CellDbLog.Warnf("Cell[%s] ignored: %+v", cell.Id, err)
+			continue
+		}
+		cells[cell.Id] = cell
+	}
+	logger.CellDbLog.Infof("Cell database loaded: %d cells", len(cells))
+	return nil
+}
+
+// Find returns the cell serving a location, if it is in the database
+func Find(location models.UserLocation) (Cell, bool) {
+	var key string
+	switch {
+	case location.NrLocation != nil && location.NrLocation.Ncgi != nil && location.NrLocation.Ncgi.PlmnId != nil:
+		ncgi := location.NrLocation.Ncgi
+		key = CellKey(RatNr, *ncgi.PlmnId, ncgi.NrCellId)
+	case location.EutraLocation != nil && location.EutraLocation.Ecgi != nil &&
+		location.EutraLocation.Ecgi.PlmnId != nil:
+		ecgi := location.EutraLocation.Ecgi
+		key = CellKey(RatEutra, *ecgi.PlmnId, ecgi.EutraCellId)
+	default:
+		return Cell{}, false
+	}
+	return Get(key)
+}
+
+// Locate approximates a location by its serving cell
+func Locate(location models.UserLocation) (*etaf_context.GeographicArea, bool) {
+	cell, ok := Find(location)
+	if !ok {
+		return nil, false
+	}
+	estimate := cell.Estimate()
+	return &estimate, true
+}
+
+func Get(id string) (Cell, bool) {
+	cellsMutex.RLock()
+	defer cellsMutex.RUnlock()
+
+	cell, ok := cells[id]
+	return cell, ok
+}
+
+// List returns the cells ordered by Id
+func List() []Cell {
+	cellsMutex.RLock()
+	defer cellsMutex.RUnlock()
+
+	return listLocked()
+}
+
+// Put adds or replaces a validated cell; it returns true if the cell is new
+func Put(cell Cell) (bool, error) {
+	cellsMutex.Lock()
+	defer cellsMutex.Unlock()
+
+	_, exists := cells[cell.Id]
+	previous := cells[cell.Id]
+	cells[cell.Id] = cell
+	if err := cellStore.put(cell, listLocked()); err != nil {
+		if exists {
+			cells[cell.Id] = previous
+		} else {
+			delete(cells, cell.Id)
+		}
+		return false, err
+	}
+	return !exists, nil
+}
+
+// Delete returns false if there is no such cell
+func Delete(id string) (bool, error) {
+	cellsMutex.Lock()
+	defer cellsMutex.Unlock()
+
+	cell, ok := cells[id]
+	if !ok {
+		return false, nil
+	}
+	delete(cells, id)
+	if err := cellStore.delete(id, listLocked()); err != nil {
+		cells[id] = cell
+		return false, err
+	}
+	return true, nil
+}
+
+func listLocked() []Cell {
+	list := make([]Cell, 0, len(cells))
+	for _, cell := range cells {
+		list = append(list, cell)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list
+}
@@ -0,0 +1,174 @@
+package celldb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"free5gc/lib/MongoDBLibrary"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/factory"
+)
+
+const collName = "etaf.cellDatabase"
+
+// csvHeader lists the columns of a cell database CSV file; an empty azimuth marks
+// an omnidirectional cell
+var csvHeader = []string{"rat", "mcc", "mnc", "cellId", "lat", "lon", "azimuth", "range"}
+
+type mongoStore struct{}
+
+func (mongoStore) load() ([]Cell, error) {
+	ctx := context.Background()
+	cursor, err := collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("Find cells error: %+v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var loaded []Cell
+	for cursor.Next(ctx) {
+		var cell Cell
+		if err := cursor.Decode(&cell); err != nil {
+			return nil, fmt.Errorf("Decode cell error: %+v", err)
+		}
+		loaded = append(loaded, cell)
+	}
+	return loaded, cursor.Err()
+}
+
+func (mongoStore) put(cell Cell, cells []Cell) error {
+	_, err := collection().ReplaceOne(context.Background(), bson.M{"id": cell.Id}, cell,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("Store cell error: %+v", err)
+	}
+	return nil
+}
+
+func (mongoStore) delete(id string, cells []Cell) error {
+	if _, err := collection().DeleteOne(context.Background(), bson.M{"id": id}); err != nil {
+		return fmt.Errorf("Delete cell error: %+v", err)
+	}
+	return nil
+}
+
+func collection() *mongo.Collection {
+	return MongoDBLibrary.Client.Database(factory.EtafConfig.Configuration.MongoDBName).Collection(collName)
+}
+
+// csvStore rewrites the whole file on every change
+type csvStore struct {
+	path string
+}
+
+func (store *csvStore) load() ([]Cell, error) {
+	file, err := os.Open(store.path)
+	if err != nil {
+		return nil, fmt.Errorf("Open cell database error: %+v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = len(csvHeader)
+	reader.TrimLeadingSpace = true
+	var loaded []Cell
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return loaded, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("Read cell database error: %+v", err)
+		}
+		if line == 1 && strings.EqualFold(record[0], csvHeader[0]) {
+			continue
+		}
+		cell, err := parseCell(record)
+		if err != nil {
+			return nil, fmt.Errorf("Cell database line %d: %+v", line, err)
+		}
+		loaded = append(loaded, cell)
+	}
+}
+
+func (store *csvStore) put(cell Cell, cells []Cell) error {
+	return store.write(cells)
+}
+
+func (store *csvStore) delete(id string, cells []Cell) error {
+	return store.write(cells)
+}
+
+// write replaces the file through a rename, so a failed write leaves it intact
+func (store *csvStore) write(cells []Cell) error {
+	file, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".*")
+	if err != nil {
+		return fmt.Errorf("Write cell database error: %+v", err)
+	}
+	defer os.Remove(file.Name())
+
+	writer := csv.NewWriter(file)
+	records := [][]string{csvHeader}
+	for _, cell := range cells {
+		records = append(records, formatCell(cell))
+	}
+	if err := writer.WriteAll(records); err != nil {
+		file.Close()
+		return fmt.Errorf("Write cell database error: %+v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("Write cell database error: %+v", err)
+	}
+	if err := os.Rename(file.Name(), store.path); err != nil {
+		return fmt.Errorf("Write cell database error: %+v", err)
+	}
+	return nil
+}
+
+func parseCell(record []string) (Cell, error) {
+	cell := Cell{
+		Rat:    strings.ToLower(record[0]),
+		PlmnId: models.PlmnId{Mcc: record[1], Mnc: record[2]},
+		CellId: record[3],
+	}
+	var err error
+	if cell.Lat, err = strconv.ParseFloat(record[4], 64); err != nil {
+		return cell, fmt.Errorf("lat: %+v", err)
+	}
+	if cell.Lon, err = strconv.ParseFloat(record[5], 64); err != nil {
+		return cell, fmt.Errorf("lon: %+v", err)
+	}
+	if record[6] != "" {
+		azimuth, err := strconv.ParseFloat(record[6], 64)
+		if err != nil {
+			return cell, fmt.Errorf("azimuth: %+v", err)
+		}
+		cell.Azimuth = &azimuth
+	}
+	cellRange, err := strconv.ParseFloat(record[7], 32)
+	if err != nil {
+		return cell, fmt.Errorf("range: %+v", err)
+	}
+	cell.Range = float32(cellRange)
+	return cell, nil
+}
+
+func formatCell(cell Cell) []string {
+	azimuth := ""
+	if cell.Azimuth != nil {
+		azimuth = strconv.FormatFloat(*cell.Azimuth, 'f', -1, 64)
+	}
+	return []string{cell.Rat, cell.PlmnId.Mcc, cell.PlmnId.Mnc, cell.CellId,
+		strconv.FormatFloat(cell.Lat, 'f', -1, 64), strconv.FormatFloat(cell.Lon, 'f', -1, 64), azimuth,
+		strconv.FormatFloat(float64(cell.Range), 'f', -1, 32)}
+}
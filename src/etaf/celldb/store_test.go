@@ -0,0 +1,119 @@
+package celldb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCsvStoreLoad(t *testing.T) {
+	testCases := []struct {
+		name    string
+		csv     string
+		cells   int
+		azimuth []bool // whether each cell is a sector
+		err     bool
+	}{
+		{
+			name:    "with header",
+			csv:     "rat,mcc,mnc,cellId,lat,lon,azimuth,range\nnr,208,93,000000010,48.85,2.35,120,500\n",
+			cells:   1,
+			azimuth: []bool{true},
+		},
+		{
+			name:    "without header",
+			csv:     "NR,208,93,000000010,48.85,2.35,,500\neutra,208,93,0000001,48.86,2.36,90,1500\n",
+			cells:   2,
+			azimuth: []bool{false, true},
+		},
+		{
+			name:    "spaces after commas",
+			csv:     "nr, 208, 93, 000000010, 48.85, 2.35, , 500\n",
+			cells:   1,
+			azimuth: []bool{false},
+		},
+		{name: "empty", csv: ""},
+		{name: "missing column", csv: "nr,208,93,000000010,48.85,2.35,500\n", err: true},
+		{name: "bad latitude", csv: "nr,208,93,000000010,north,2.35,,500\n", err: true},
+		{name: "bad azimuth", csv: "nr,208,93,000000010,48.85,2.35,east,500\n", err: true},
+		{name: "bad range", csv: "nr,208,93,000000010,48.85,2.35,,far\n", err: true},
+	}
+
+	dir, err := ioutil.TempDir("", "celldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(dir, "cells.csv")
+			if err := ioutil.WriteFile(path, []byte(testCase.csv), 0600); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := (&csvStore{path: path}).load()
+			if testCase.err {
+				if err == nil {
+					t.Errorf("expected an error, loaded %+v", loaded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load error: %+v", err)
+			}
+			if len(loaded) != testCase.cells {
+				t.Fatalf("%d cells loaded, expected %d", len(loaded), testCase.cells)
+			}
+			for i, cell := range loaded {
+				if err := cell.Validate(); err != nil {
+					t.Errorf("cell %d invalid: %+v", i, err)
+				}
+				if (cell.Azimuth != nil) != testCase.azimuth[i] {
+					t.Errorf("cell %d azimuth %v", i, cell.Azimuth)
+				}
+			}
+		})
+	}
+}
+
+func TestCsvStoreRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "celldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	azimuth := 240.5
+	written := []Cell{
+		{Rat: RatNr, CellId: "000000010", Lat: 48.85, Lon: 2.35, Azimuth: &azimuth, Range: 750},
+		{Rat: RatEutra, CellId: "0000001", Lat: -33.9, Lon: 151.2, Range: 2000},
+	}
+	for i := range written {
+		written[i].PlmnId.Mcc, written[i].PlmnId.Mnc = "208", "93"
+		if err := written[i].Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := &csvStore{path: filepath.Join(dir, "cells.csv")}
+	if err := store.write(written); err != nil {
+		t.Fatalf("write error: %+v", err)
+	}
+	loaded, err := store.load()
+	if err != nil {
+		t.Fatalf("load error: %+v", err)
+	}
+	if len(loaded) != len(written) {
+		t.Fatalf("%d cells loaded, expected %d", len(loaded), len(written))
+	}
+	for i := range loaded {
+		if err := loaded[i].Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if loaded[i].Id != written[i].Id || loaded[i].Lat != written[i].Lat || loaded[i].Lon != written[i].Lon ||
+			loaded[i].Range != written[i].Range || (loaded[i].Azimuth == nil) != (written[i].Azimuth == nil) ||
+			(loaded[i].Azimuth != nil && *loaded[i].Azimuth != *written[i].Azimuth) {
+			t.Errorf("cell %d loaded %+v, written %+v", i, loaded[i], written[i])
+		}
+	}
+}
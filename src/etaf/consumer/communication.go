@@ -1,13 +1,14 @@
 package consumer
 
 import (
-	"free5gc/lib/openapi/models"
-	etaf_context "free5gc/src/etaf/context"
 	"context"
 	"fmt"
 	"free5gc/lib/openapi"
-	"free5gc/src/pcf/logger"
-	"free5gc/src/pcf/util"
+	"free5gc/lib/openapi/Namf_Communication"
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
 	"strings"
 )
 
@@ -108,11 +109,16 @@ func buildAmPolicyReqTriggers(triggers []models.RequestTrigger) (amPolicyReqTrig
 	return
 }
 
-func AmfStatusChangeSubscribe(amfInfo etaf_context.AMFStatusSubscriptionData) (
+func AmfStatusChangeSubscribe(ctx context.Context, amfInfo etaf_context.AMFStatusSubscriptionData) (
 	problemDetails *models.ProblemDetails, err error) {
-	logger.Consumerlog.Debugf("PCF Subscribe to AMF status[%+v]", amfInfo.AmfUri)
+	log := logger.ConsumerLog.WithContext(ctx)
+	log.Debugf("ETAF Subscribe to AMF status[%+v]", amfInfo.AmfUri)
 	etafSelf := etaf_context.ETAF_Self()
-	client := util.GetNamfClient(amfInfo.AmfUri)
+	configuration := Namf_Communication.NewConfiguration()
+	configuration.SetBasePath(amfInfo.AmfUri)
+	ctx, span := startSpan(ctx, "Namf_Communication AMFStatusChangeSubscribe", configuration)
+	defer span.End()
+	client := Namf_Communication.NewAPIClient(configuration)
 
 	subscriptionData := models.SubscriptionData{
 		AmfStatusUri: fmt.Sprintf("%s/netaf-callback/v1/locInfoNotify", etafSelf.GetIPv4Uri()),
@@ -120,10 +126,11 @@ func AmfStatusChangeSubscribe(amfInfo etaf_context.AMFStatusSubscriptionData) (
 	}
 
 	res, httpResp, localErr :=
-		client.SubscriptionsCollectionDocumentApi.AMFStatusChangeSubscribe(context.Background(), subscriptionData)
+		client.SubscriptionsCollectionDocumentApi.AMFStatusChangeSubscribe(ctx, subscriptionData)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		locationHeader := httpResp.Header.Get("Location")
-		logger.Consumerlog.Debugf("location header: %+v", locationHeader)
+		log.Debugf("location header: %+v", locationHeader)
 
 		subscriptionId := locationHeader[strings.LastIndex(locationHeader, "/")+1:]
 		amfStatusSubsData := etaf_context.AMFStatusSubscriptionData{
@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/Namf_EventExposure"
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
+)
+
+// AmfLocationReportSubscribe subscribes to the location reports of the UE or
+// group described by target, correlated with correlationId; reports are continuous
+// unless target sets other options. The reports the AMF returns immediately are
+// returned with the subscription ID.
+func AmfLocationReportSubscribe(ctx context.Context, amfUri, correlationId string,
+	target models.AmfEventSubscription) (subscriptionId string, reports []models.AmfEventReport,
+	problemDetails *models.ProblemDetails, err error) {
+	target.EventList = &[]models.AmfEvent{
+		{
+			Type:          models.AmfEventType_LOCATION_REPORT,
+			ImmediateFlag: true,
+		},
+	}
+	return amfEventSubscribe(ctx, amfUri, correlationId, target)
+}
+
+// AmfPresenceReportSubscribe subscribes to the reports of the presence of the UE
+// described by target in the area of presenceInfo, correlated with correlationId;
+// the area is identified in the reports by its presence reporting area ID
+func AmfPresenceReportSubscribe(ctx context.Context, amfUri, correlationId string,
+	target models.AmfEventSubscription, presenceInfo models.PresenceInfo) (subscriptionId string,
+	reports []models.AmfEventReport, problemDetails *models.ProblemDetails, err error) {
+	target.EventList = &[]models.AmfEvent{
+		{
+			Type:          models.AmfEventType_PRESENCE_IN_AOI_REPORT,
+			ImmediateFlag: true,
+			AreaList:      []models.AmfEventArea{{PresenceInfo: &presenceInfo}},
+		},
+	}
+	return amfEventSubscribe(ctx, amfUri, correlationId, target)
+}
+
+// AmfRegistrationStateSubscribe subscribes to the registration state reports of
+// any UE served by the AMF, correlated with correlationId
+func AmfRegistrationStateSubscribe(ctx context.Context, amfUri, correlationId string) (subscriptionId string,
+	problemDetails *models.ProblemDetails, err error) {
+	target := models.AmfEventSubscription{
+		AnyUE: true,
+		EventList: &[]models.AmfEvent{
+			{
+				Type: models.AmfEventType_REGISTRATION_STATE_REPORT,
+			},
+		},
+	}
+	subscriptionId, _, problemDetails, err = amfEventSubscribe(ctx, amfUri, correlationId, target)
+	return
+}
+
+func amfEventSubscribe(ctx context.Context, amfUri, correlationId string,
+	target models.AmfEventSubscription) (subscriptionId string, reports []models.AmfEventReport,
+	problemDetails *models.ProblemDetails, err error) {
+	configuration := Namf_EventExposure.NewConfiguration()
+	configuration.SetBasePath(amfUri)
+	ctx, span := startSpan(ctx, "Namf_EventExposure CreateSubscription", configuration)
+	defer span.End()
+	client := Namf_EventExposure.NewAPIClient(configuration)
+
+	etafSelf := etaf_context.ETAF_Self()
+	target.EventNotifyUri = fmt.Sprintf("%s/netaf-callback/v1/locInfoNotify", etafSelf.GetIPv4Uri())
+	target.NotifyCorrelationId = correlationId
+	target.NfId = etafSelf.NfId
+	if target.Options == nil {
+		target.Options = &models.AmfEventMode{
+			Trigger: models.AmfEventTrigger_CONTINUOUS,
+		}
+	}
+
+	created, httpResp, localErr := client.SubscriptionsCollectionDocumentApi.CreateSubscription(ctx,
+		models.AmfCreateEventSubscription{Subscription: &target})
+	util.SetSpanError(ctx, span, localErr)
+	if localErr == nil {
+		subscriptionId = created.SubscriptionId
+		reports = created.ReportList
+		etafSelf.SetNotifyCorrelation(correlationId, logger.CorrelationIDFromContext(ctx))
+	} else if httpResp != nil {
+		if httpResp.Status != localErr.Error() {
+			err = localErr
+			return
+		}
+		problem := localErr.(openapi.GenericOpenAPIError).Model().(models.ProblemDetails)
+		problemDetails = &problem
+	} else {
+		err = openapi.ReportError("%s: server no response", amfUri)
+	}
+	return
+}
+
+func AmfEventUnsubscribe(ctx context.Context, amfUri, subscriptionId string) (
+	problemDetails *models.ProblemDetails, err error) {
+	configuration := Namf_EventExposure.NewConfiguration()
+	configuration.SetBasePath(amfUri)
+	ctx, span := startSpan(ctx, "Namf_EventExposure DeleteSubscription", configuration)
+	defer span.End()
+	client := Namf_EventExposure.NewAPIClient(configuration)
+
+	httpResp, localErr := client.IndividualSubscriptionDocumentApi.DeleteSubscription(ctx, subscriptionId)
+	util.SetSpanError(ctx, span, localErr)
+	if localErr == nil {
+		return
+	} else if httpResp != nil {
+		if httpResp.Status != localErr.Error() {
+			err = localErr
+			return
+		}
+		problem := localErr.(openapi.GenericOpenAPIError).Model().(models.ProblemDetails)
+		problemDetails = &problem
+	} else {
+		err = openapi.ReportError("%s: server no response", amfUri)
+	}
+	return
+}
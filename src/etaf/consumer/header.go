@@ -0,0 +1,46 @@
+package consumer
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/trace"
+
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
+)
+
+// defaultHeaderSetter is implemented by the Configuration of every generated SBI client
+type defaultHeaderSetter interface {
+	AddDefaultHeader(key string, value string)
+}
+
+// headerCarrier lets the trace context propagator write into the default headers of an SBI client
+type headerCarrier struct {
+	configuration defaultHeaderSetter
+}
+
+func (carrier headerCarrier) Get(key string) string {
+	return ""
+}
+
+func (carrier headerCarrier) Set(key string, value string) {
+	carrier.configuration.AddDefaultHeader(key, value)
+}
+
+// startSpan starts a client span around an SBI call and propagates its W3C trace
+// context and the correlation ID carried by ctx to the request
+func startSpan(ctx context.Context, operation string, configuration defaultHeaderSetter) (
+	context.Context, trace.Span) {
+	ctx, span := util.Tracer().Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	global.TextMapPropagator().Inject(ctx, headerCarrier{configuration: configuration})
+	setCorrelationHeader(ctx, configuration)
+	return ctx, span
+}
+
+// setCorrelationHeader propagates the correlation ID carried by ctx to the SBI request
+func setCorrelationHeader(ctx context.Context, configuration defaultHeaderSetter) {
+	if correlationID := logger.CorrelationIDFromContext(ctx); correlationID != "" {
+		configuration.AddDefaultHeader(logger.CorrelationIDHeader, correlationID)
+	}
+}
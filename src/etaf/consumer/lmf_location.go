@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"free5gc/lib/openapi/Nnrf_NFDiscovery"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/lmf"
+	"free5gc/src/etaf/util"
+	"io/ioutil"
+	"net/http"
+)
+
+// httpHeaderSetter lets startSpan write the trace context into a plain request
+type httpHeaderSetter http.Header
+
+func (header httpHeaderSetter) AddDefaultHeader(key string, value string) {
+	http.Header(header).Set(key, value)
+}
+
+// SearchLmfUri selects an LMF offering Nlmf_Location
+func SearchLmfUri(ctx context.Context, nrfUri string) (string, error) {
+	resp, localErr := SendSearchNFInstances(ctx, nrfUri, models.NfType_LMF, models.NfType_ETAF,
+		&Nnrf_NFDiscovery.SearchNFInstancesParamOpts{})
+	if localErr != nil {
+		return "", localErr
+	}
+
+	for _, nfProfile := range resp.NfInstances {
+		if lmfUri := util.SearchNFServiceUri(nfProfile, models.ServiceName_NLMF_LOC,
+			models.NfServiceStatus_REGISTERED); lmfUri != "" {
+			return lmfUri, nil
+		}
+	}
+	return "", fmt.Errorf("ETAF can not select an LMF by NRF")
+}
+
+// DetermineLocation asks an LMF to position a UE. The request is made without a
+// generated client, so the LMF answers are decoded here.
+func DetermineLocation(ctx context.Context, lmfUri string, input lmf.InputData) (
+	locationData *lmf.LocationData, problemDetails *models.ProblemDetails, err error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	request, err := http.NewRequest(http.MethodPost, lmfUri+"/nlmf-loc/v1/determine-location",
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	ctx, span := startSpan(ctx, "Nlmf_Location DetermineLocation", httpHeaderSetter(request.Header))
+	defer span.End()
+
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if err != nil {
+		util.SetSpanError(ctx, span, err)
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		util.SetSpanError(ctx, span, err)
+		return nil, nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var problem models.ProblemDetails
+		if json.Unmarshal(responseBody, &problem) != nil || problem.Status == 0 {
+			problem = models.ProblemDetails{
+				Status: int32(response.StatusCode),
+				Cause:  "POSITIONING_FAILED",
+				Detail: fmt.Sprintf("LMF responded %s", response.Status),
+			}
+		}
+		util.SetSpanError(ctx, span, fmt.Errorf("%s", response.Status))
+		return nil, &problem, nil
+	}
+	locationData = new(lmf.LocationData)
+	if err = json.Unmarshal(responseBody, locationData); err == nil {
+		err = locationData.LocationEstimate.Validate()
+	}
+	if err != nil {
+		err = fmt.Errorf("Invalid LMF location data: %+v", err)
+		util.SetSpanError(ctx, span, err)
+		return nil, nil, err
+	}
+	return locationData, nil, nil
+}
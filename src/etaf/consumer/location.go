@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"context"
+	"free5gc/lib/openapi"
+	Namf_Location "free5gc/lib/openapi/Namf_Location"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/util"
+)
+
+// ProvideLocationInfo asks the serving AMF for the current location of a UE; the
+// AMF pages the UE when it is in CM-IDLE
+func ProvideLocationInfo(ctx context.Context, amfUri, supi string) (
+	locInfo *models.ProvideLocInfo, problemDetails *models.ProblemDetails, err error) {
+	configuration := Namf_Location.NewConfiguration()
+	configuration.SetBasePath(amfUri)
+	ctx, span := startSpan(ctx, "Namf_Location ProvideLocationInfo", configuration)
+	defer span.End()
+	client := Namf_Location.NewAPIClient(configuration)
+
+	requestLocInfo := models.RequestLocInfo{
+		Req5gsLoc:     true,
+		ReqCurrentLoc: true,
+		ReqRatType:    true,
+	}
+	res, httpResp, localErr := client.IndividualUEContextDocumentApi.ProvideLocationInfo(ctx, supi, requestLocInfo)
+	util.SetSpanError(ctx, span, localErr)
+	if localErr == nil {
+		locInfo = &res
+	} else if httpResp != nil {
+		if httpResp.Status != localErr.Error() {
+			err = localErr
+			return
+		}
+		problem := localErr.(openapi.GenericOpenAPIError).Model().(models.ProblemDetails)
+		problemDetails = &problem
+	} else {
+		err = openapi.ReportError("%s: server no response", amfUri)
+	}
+	return
+}
+
+// ProvidePositioningInfo asks the serving AMF to position a UE on behalf of an LCS
+// client of the given type and priority
+func ProvidePositioningInfo(ctx context.Context, amfUri, supi string, clientType models.ExternalClientType,
+	priority models.LcsPriority) (posInfo *models.ProvidePosInfo, problemDetails *models.ProblemDetails, err error) {
+	configuration := Namf_Location.NewConfiguration()
+	configuration.SetBasePath(amfUri)
+	ctx, span := startSpan(ctx, "Namf_Location ProvidePositioningInfo", configuration)
+	defer span.End()
+	client := Namf_Location.NewAPIClient(configuration)
+
+	requestPosInfo := models.RequestPosInfo{
+		LcsClientType: clientType,
+		LcsLocation:   models.LocationType_CURRENT_LOCATION,
+		Supi:          supi,
+		Priority:      priority,
+	}
+	res, httpResp, localErr := client.IndividualUEContextDocumentApi.ProvidePositioningInfo(ctx, supi, requestPosInfo)
+	util.SetSpanError(ctx, span, localErr)
+	if localErr == nil {
+		posInfo = &res
+	} else if httpResp != nil {
+		if httpResp.Status != localErr.Error() {
+			err = localErr
+			return
+		}
+		problem := localErr.(openapi.GenericOpenAPIError).Model().(models.ProblemDetails)
+		problemDetails = &problem
+	} else {
+		err = openapi.ReportError("%s: server no response", amfUri)
+	}
+	return
+}
@@ -11,25 +11,28 @@ import (
 	"net/http"
 )
 
-func SendSearchNFInstances(nrfUri string, targetNfType, requestNfType models.NfType,
+func SendSearchNFInstances(ctx context.Context, nrfUri string, targetNfType, requestNfType models.NfType,
 	param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts) (models.SearchResult, error) {
 
 	// Set client and set url
 	configuration := Nnrf_NFDiscovery.NewConfiguration()
 	configuration.SetBasePath(nrfUri)
+	ctx, span := startSpan(ctx, "Nnrf_NFDiscovery SearchNFInstances", configuration)
+	defer span.End()
 	client := Nnrf_NFDiscovery.NewAPIClient(configuration)
 
-	result, res, err := client.NFInstancesStoreApi.SearchNFInstances(context.TODO(), targetNfType, requestNfType, param)
+	result, res, err := client.NFInstancesStoreApi.SearchNFInstances(ctx, targetNfType, requestNfType, param)
 	if res != nil && res.StatusCode == http.StatusTemporaryRedirect {
 		err = fmt.Errorf("Temporary Redirect For Non NRF Consumer")
 	}
+	util.SetSpanError(ctx, span, err)
 	return result, err
 }
 
-func SearchUdmSdmInstance(ue *etaf_context.EtafUe, nrfUri string, targetNfType, requestNfType models.NfType,
-	param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts) error {
+func SearchUdmSdmInstance(ctx context.Context, ue *etaf_context.EtafUe, nrfUri string,
+	targetNfType, requestNfType models.NfType, param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts) error {
 
-	resp, localErr := SendSearchNFInstances(nrfUri, targetNfType, requestNfType, param)
+	resp, localErr := SendSearchNFInstances(ctx, nrfUri, targetNfType, requestNfType, param)
 	if localErr != nil {
 		return localErr
 	}
@@ -46,16 +49,33 @@ func SearchUdmSdmInstance(ue *etaf_context.EtafUe, nrfUri string, targetNfType,
 	ue.NudmSDMUri = sdmUri
 	if ue.NudmSDMUri == "" {
 		err := fmt.Errorf("ETAF can not select an UDM by NRF")
-		logger.ConsumerLog.Errorf(err.Error())
+		logger.WithSupi(logger.ConsumerLog.WithContext(ctx), ue.Supi).Errorf(err.Error())
 		return err
 	}
 	return nil
 }
 
-func SearchNssfNSSelectionInstance(ue *etaf_context.EtafUe, nrfUri string, targetNfType, requestNfType models.NfType,
-	param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts) error {
+// SearchUdmSdmUri selects a UDM for the requests that are not about a single UE
+func SearchUdmSdmUri(ctx context.Context, nrfUri string) (string, error) {
+	resp, localErr := SendSearchNFInstances(ctx, nrfUri, models.NfType_UDM, models.NfType_ETAF,
+		&Nnrf_NFDiscovery.SearchNFInstancesParamOpts{})
+	if localErr != nil {
+		return "", localErr
+	}
+
+	for _, nfProfile := range resp.NfInstances {
+		if sdmUri := util.SearchNFServiceUri(nfProfile, models.ServiceName_NUDM_SDM,
+			models.NfServiceStatus_REGISTERED); sdmUri != "" {
+			return sdmUri, nil
+		}
+	}
+	return "", fmt.Errorf("ETAF can not select an UDM by NRF")
+}
+
+func SearchNssfNSSelectionInstance(ctx context.Context, ue *etaf_context.EtafUe, nrfUri string,
+	targetNfType, requestNfType models.NfType, param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts) error {
 
-	resp, localErr := SendSearchNFInstances(nrfUri, targetNfType, requestNfType, param)
+	resp, localErr := SendSearchNFInstances(ctx, nrfUri, targetNfType, requestNfType, param)
 	if localErr != nil {
 		return localErr
 	}
@@ -76,10 +96,10 @@ func SearchNssfNSSelectionInstance(ue *etaf_context.EtafUe, nrfUri string, targe
 	return nil
 }
 
-func SearchAmfCommunicationInstance(ue *etaf_context.EtafUe, nrfUri string, targetNfType,
+func SearchAmfCommunicationInstance(ctx context.Context, ue *etaf_context.EtafUe, nrfUri string, targetNfType,
 	requestNfType models.NfType, param *Nnrf_NFDiscovery.SearchNFInstancesParamOpts) (err error) {
 
-	resp, localErr := SendSearchNFInstances(nrfUri, targetNfType, requestNfType, param)
+	resp, localErr := SendSearchNFInstances(ctx, nrfUri, targetNfType, requestNfType, param)
 	if localErr != nil {
 		err = localErr
 		return
@@ -101,13 +121,13 @@ func SearchAmfCommunicationInstance(ue *etaf_context.EtafUe, nrfUri string, targ
 
 }
 
-func SearchAvailableAMFs(nrfUri string, serviceName models.ServiceName) (
+func SearchAvailableAMFs(ctx context.Context, nrfUri string, serviceName models.ServiceName) (
 	amfInfos []etaf_context.AMFStatusSubscriptionData) {
 	localVarOptionals := Nnrf_NFDiscovery.SearchNFInstancesParamOpts{}
 
-	result, err := SendSearchNFInstances(nrfUri, models.NfType_AMF, models.NfType_ETAF, &localVarOptionals)
+	result, err := SendSearchNFInstances(ctx, nrfUri, models.NfType_AMF, models.NfType_ETAF, &localVarOptionals)
 	if err != nil {
-		logger.ConsumerLog.Errorf(err.Error())
+		logger.ConsumerLog.WithContext(ctx).Errorf(err.Error())
 		return
 	}
 
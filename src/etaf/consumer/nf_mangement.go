@@ -8,6 +8,7 @@ import (
 	"free5gc/lib/openapi/models"
 	etaf_context "free5gc/src/etaf/context"
 	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
 	"net/http"
 	"strings"
 	"time"
@@ -49,17 +50,20 @@ func BuildNFInstance(context *etaf_context.ETAFContext) (profile models.NfProfil
 	return profile, err
 }
 
-func SendRegisterNFInstance(nrfUri, nfInstanceId string, profile models.NfProfile) (
+func SendRegisterNFInstance(ctx context.Context, nrfUri, nfInstanceId string, profile models.NfProfile) (
 	resouceNrfUri string, retrieveNfInstanceId string, err error) {
 
 	// Set client and set url
 	configuration := Nnrf_NFManagement.NewConfiguration()
 	configuration.SetBasePath(nrfUri)
+	ctx, span := startSpan(ctx, "Nnrf_NFManagement RegisterNFInstance", configuration)
+	defer span.End()
 	client := Nnrf_NFManagement.NewAPIClient(configuration)
 
 	var res *http.Response
 	for {
-		_, res, err = client.NFInstanceIDDocumentApi.RegisterNFInstance(context.TODO(), nfInstanceId, profile)
+		_, res, err = client.NFInstanceIDDocumentApi.RegisterNFInstance(ctx, nfInstanceId, profile)
+		util.SetSpanError(ctx, span, err)
 		if err != nil || res == nil {
 			//TODO : add log
 			fmt.Println(fmt.Errorf("ETAF register to NRF Error[%s]", err.Error()))
@@ -84,19 +88,22 @@ func SendRegisterNFInstance(nrfUri, nfInstanceId string, profile models.NfProfil
 	return resouceNrfUri, retrieveNfInstanceId, err
 }
 
-func SendDeregisterNFInstance() (problemDetails *models.ProblemDetails, err error) {
+func SendDeregisterNFInstance(ctx context.Context) (problemDetails *models.ProblemDetails, err error) {
 
-	logger.ConsumerLog.Infof("[ETAF] Send Deregister NFInstance")
+	logger.ConsumerLog.WithContext(ctx).Infof("[ETAF] Send Deregister NFInstance")
 
 	etafSelf := etaf_context.ETAF_Self()
 	// Set client and set url
 	configuration := Nnrf_NFManagement.NewConfiguration()
 	configuration.SetBasePath(etafSelf.NrfUri)
+	ctx, span := startSpan(ctx, "Nnrf_NFManagement DeregisterNFInstance", configuration)
+	defer span.End()
 	client := Nnrf_NFManagement.NewAPIClient(configuration)
 
 	var res *http.Response
 
-	res, err = client.NFInstanceIDDocumentApi.DeregisterNFInstance(context.Background(), etafSelf.NfId)
+	res, err = client.NFInstanceIDDocumentApi.DeregisterNFInstance(ctx, etafSelf.NfId)
+	util.SetSpanError(ctx, span, err)
 	if err == nil {
 		return
 	} else if res != nil {
@@ -7,15 +7,19 @@ import (
 	"free5gc/lib/openapi/Nnssf_NSSelection"
 	"free5gc/lib/openapi/models"
 	etaf_context "free5gc/src/etaf/context"
-	"free5gc/src/nssf/logger"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
 
 	"github.com/antihax/optional"
 )
 
-func NSSelectionGetForRegistration(ue *etaf_context.EtafUe, requestedNssai []models.Snssai) (
+func NSSelectionGetForRegistration(ctx context.Context, ue *etaf_context.EtafUe,
+	requestedNssai []models.Snssai) (
 	*models.ProblemDetails, error) {
 	configuration := Nnssf_NSSelection.NewConfiguration()
 	configuration.SetBasePath(ue.NssfUri)
+	ctx, span := startSpan(ctx, "Nnssf_NSSelection NSSelectionGet", configuration)
+	defer span.End()
 	client := Nnssf_NSSelection.NewAPIClient(configuration)
 
 	etafSelf := etaf_context.ETAF_Self()
@@ -26,14 +30,15 @@ func NSSelectionGetForRegistration(ue *etaf_context.EtafUe, requestedNssai []mod
 
 	var paramOpt Nnssf_NSSelection.NSSelectionGetParamOpts
 	if e, err := json.Marshal(sliceInfoForRegistration); err != nil {
-		logger.Nsselection.Warnf("json marshal failed: %+v", err)
+		logger.WithSupi(logger.ConsumerLog.WithContext(ctx), ue.Supi).Warnf("json marshal failed: %+v", err)
 	} else {
 		paramOpt = Nnssf_NSSelection.NSSelectionGetParamOpts{
 			SliceInfoRequestForRegistration: optional.NewInterface(string(e)),
 		}
 	}
-	res, httpResp, localErr := client.NetworkSliceInformationDocumentApi.NSSelectionGet(context.Background(),
+	res, httpResp, localErr := client.NetworkSliceInformationDocumentApi.NSSelectionGet(ctx,
 		models.NfType_ETAF, etafSelf.NfId, &paramOpt)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		ue.NetworkSliceInfo = &res
 		for _, allowedNssai := range res.AllowedNssaiList {
@@ -54,10 +59,12 @@ func NSSelectionGetForRegistration(ue *etaf_context.EtafUe, requestedNssai []mod
 	return nil, nil
 }
 
-func NSSelectionGetForPduSession(ue *etaf_context.EtafUe, snssai models.Snssai) (
+func NSSelectionGetForPduSession(ctx context.Context, ue *etaf_context.EtafUe, snssai models.Snssai) (
 	*models.AuthorizedNetworkSliceInfo, *models.ProblemDetails, error) {
 	configuration := Nnssf_NSSelection.NewConfiguration()
 	configuration.SetBasePath(ue.NssfUri)
+	ctx, span := startSpan(ctx, "Nnssf_NSSelection NSSelectionGet", configuration)
+	defer span.End()
 	client := Nnssf_NSSelection.NewAPIClient(configuration)
 
 	etafSelf := etaf_context.ETAF_Self()
@@ -68,13 +75,14 @@ func NSSelectionGetForPduSession(ue *etaf_context.EtafUe, snssai models.Snssai)
 
 	e, err := json.Marshal(sliceInfoForPduSession)
 	if err != nil {
-		logger.Nsselection.Warnf("json marshal failed: %+v", err)
+		logger.WithSupi(logger.ConsumerLog.WithContext(ctx), ue.Supi).Warnf("json marshal failed: %+v", err)
 	}
 	paramOpt := Nnssf_NSSelection.NSSelectionGetParamOpts{
 		SliceInfoRequestForPduSession: optional.NewInterface(string(e)),
 	}
-	res, httpResp, localErr := client.NetworkSliceInformationDocumentApi.NSSelectionGet(context.Background(),
+	res, httpResp, localErr := client.NetworkSliceInformationDocumentApi.NSSelectionGet(ctx,
 		models.NfType_ETAF, etafSelf.NfId, &paramOpt)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		return &res, nil, nil
 	} else if httpResp != nil {
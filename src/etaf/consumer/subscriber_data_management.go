@@ -2,6 +2,7 @@ package consumer
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/antihax/optional"
 
@@ -9,12 +10,15 @@ import (
 	"free5gc/lib/openapi/Nudm_SubscriberDataManagement"
 	"free5gc/lib/openapi/models"
 	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/util"
 )
 
-func PutUpuAck(ue *etaf_context.EtafUe, upuMacIue string) error {
+func PutUpuAck(ctx context.Context, ue *etaf_context.EtafUe, upuMacIue string) error {
 
 	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
 	configuration.SetBasePath(ue.NudmSDMUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM PutUpuAck", configuration)
+	defer span.End()
 	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
 
 	ackInfo := models.AcknowledgeInfo{
@@ -23,14 +27,17 @@ func PutUpuAck(ue *etaf_context.EtafUe, upuMacIue string) error {
 	upuOpt := Nudm_SubscriberDataManagement.PutUpuAckParamOpts{
 		AcknowledgeInfo: optional.NewInterface(ackInfo),
 	}
-	_, err := client.ProvidingAcknowledgementOfUEParametersUpdateApi.PutUpuAck(context.Background(), ue.Supi, &upuOpt)
+	_, err := client.ProvidingAcknowledgementOfUEParametersUpdateApi.PutUpuAck(ctx, ue.Supi, &upuOpt)
+	util.SetSpanError(ctx, span, err)
 	return err
 }
 
-func SDMGetAmData(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
+func SDMGetAmData(ctx context.Context, ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
 
 	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
 	configuration.SetBasePath(ue.NudmSDMUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM GetAmData", configuration)
+	defer span.End()
 	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
 
 	getAmDataParamOpt := Nudm_SubscriberDataManagement.GetAmDataParamOpts{
@@ -38,10 +45,11 @@ func SDMGetAmData(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetail
 	}
 
 	data, httpResp, localErr := client.AccessAndMobilitySubscriptionDataRetrievalApi.GetAmData(
-		context.Background(), ue.Supi, &getAmDataParamOpt)
+		ctx, ue.Supi, &getAmDataParamOpt)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		ue.AccessAndMobilitySubscriptionData = &data
-		ue.Gpsi = data.Gpsis[0] // TODO: select GPSI
+		ue.SetGpsi(data.Gpsis[0]) // TODO: select GPSI
 	} else if httpResp != nil {
 		if httpResp.Status != localErr.Error() {
 			err = localErr
@@ -55,17 +63,21 @@ func SDMGetAmData(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetail
 	return
 }
 
-func SDMGetSmfSelectData(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
+func SDMGetSmfSelectData(ctx context.Context, ue *etaf_context.EtafUe) (
+	problemDetails *models.ProblemDetails, err error) {
 
 	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
 	configuration.SetBasePath(ue.NudmSDMUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM GetSmfSelectData", configuration)
+	defer span.End()
 	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
 
 	paramOpt := Nudm_SubscriberDataManagement.GetSmfSelectDataParamOpts{
 		PlmnId: optional.NewInterface(ue.PlmnId.Mcc + ue.PlmnId.Mnc),
 	}
 	data, httpResp, localErr :=
-		client.SMFSelectionSubscriptionDataRetrievalApi.GetSmfSelectData(context.Background(), ue.Supi, &paramOpt)
+		client.SMFSelectionSubscriptionDataRetrievalApi.GetSmfSelectData(ctx, ue.Supi, &paramOpt)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		ue.SmfSelectionData = &data
 	} else if httpResp != nil {
@@ -82,14 +94,18 @@ func SDMGetSmfSelectData(ue *etaf_context.EtafUe) (problemDetails *models.Proble
 	return
 }
 
-func SDMGetUeContextInSmfData(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
+func SDMGetUeContextInSmfData(ctx context.Context, ue *etaf_context.EtafUe) (
+	problemDetails *models.ProblemDetails, err error) {
 
 	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
 	configuration.SetBasePath(ue.NudmSDMUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM GetUeContextInSmfData", configuration)
+	defer span.End()
 	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
 
 	data, httpResp, localErr :=
-		client.UEContextInSMFDataRetrievalApi.GetUeContextInSmfData(context.Background(), ue.Supi, nil)
+		client.UEContextInSMFDataRetrievalApi.GetUeContextInSmfData(ctx, ue.Supi, nil)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		ue.UeContextInSmfData = &data
 	} else if httpResp != nil {
@@ -106,10 +122,12 @@ func SDMGetUeContextInSmfData(ue *etaf_context.EtafUe) (problemDetails *models.P
 	return
 }
 
-func SDMSubscribe(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
+func SDMSubscribe(ctx context.Context, ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
 
 	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
 	configuration.SetBasePath(ue.NudmSDMUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM Subscribe", configuration)
+	defer span.End()
 	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
 
 	etafSelf := etaf_context.ETAF_Self()
@@ -118,7 +136,8 @@ func SDMSubscribe(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetail
 		PlmnId:       &ue.PlmnId,
 	}
 
-	_, httpResp, localErr := client.SubscriptionCreationApi.Subscribe(context.Background(), ue.Supi, sdmSubscription)
+	_, httpResp, localErr := client.SubscriptionCreationApi.Subscribe(ctx, ue.Supi, sdmSubscription)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		return
 	} else if httpResp != nil {
@@ -134,16 +153,20 @@ func SDMSubscribe(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetail
 	return
 }
 
-func SDMGetSliceSelectionSubscriptionData(ue *etaf_context.EtafUe) (problemDetails *models.ProblemDetails, err error) {
+func SDMGetSliceSelectionSubscriptionData(ctx context.Context, ue *etaf_context.EtafUe) (
+	problemDetails *models.ProblemDetails, err error) {
 	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
 	configuration.SetBasePath(ue.NudmSDMUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM GetNssai", configuration)
+	defer span.End()
 	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
 
 	paramOpt := Nudm_SubscriberDataManagement.GetNssaiParamOpts{
 		PlmnId: optional.NewInterface(ue.PlmnId.Mcc + ue.PlmnId.Mnc),
 	}
 	nssai, httpResp, localErr :=
-		client.SliceSelectionSubscriptionDataRetrievalApi.GetNssai(context.Background(), ue.Supi, &paramOpt)
+		client.SliceSelectionSubscriptionDataRetrievalApi.GetNssai(ctx, ue.Supi, &paramOpt)
+	util.SetSpanError(ctx, span, localErr)
 	if localErr == nil {
 		for _, defaultSnssai := range nssai.DefaultSingleNssais {
 			subscribedSnssai := models.SubscribedSnssai{
@@ -177,3 +200,45 @@ func SDMGetSliceSelectionSubscriptionData(ue *etaf_context.EtafUe) (problemDetai
 	}
 	return problemDetails, err
 }
+
+// SDMGetGroupMembers returns the UEs of ues that are members of an internal group,
+// as listed by the internal groups of their access and mobility subscription data.
+// The UDM of this release cannot list the members of a group, so only the UEs
+// known to the ETAF are found; a UE unknown to the UDM is left out.
+func SDMGetGroupMembers(ctx context.Context, sdmUri, intGroupId string, ues []*etaf_context.EtafUe) (
+	members []*etaf_context.EtafUe, problemDetails *models.ProblemDetails, err error) {
+	configuration := Nudm_SubscriberDataManagement.NewConfiguration()
+	configuration.SetBasePath(sdmUri)
+	ctx, span := startSpan(ctx, "Nudm_SDM GetAmData", configuration)
+	defer span.End()
+	client := Nudm_SubscriberDataManagement.NewAPIClient(configuration)
+
+	for _, ue := range ues {
+		paramOpt := Nudm_SubscriberDataManagement.GetAmDataParamOpts{
+			PlmnId: optional.NewInterface(ue.PlmnId.Mcc + ue.PlmnId.Mnc),
+		}
+		data, httpResp, localErr := client.AccessAndMobilitySubscriptionDataRetrievalApi.GetAmData(
+			ctx, ue.Supi, &paramOpt)
+		if localErr == nil {
+			for _, groupId := range data.InternalGroupIds {
+				if groupId == intGroupId {
+					members = append(members, ue)
+					break
+				}
+			}
+		} else if httpResp != nil {
+			if httpResp.Status != localErr.Error() {
+				util.SetSpanError(ctx, span, localErr)
+				return nil, nil, localErr
+			}
+			if httpResp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			problem := localErr.(openapi.GenericOpenAPIError).Model().(models.ProblemDetails)
+			return nil, &problem, nil
+		} else {
+			return nil, nil, openapi.ReportError("server no response")
+		}
+	}
+	return members, nil, nil
+}
@@ -25,6 +25,7 @@ const (
 	DefaultT3502                      int   = 720  // 12 min
 	DefaultT3512                      int   = 3240 // 54 min
 	DefaultNon3gppDeregistrationTimer int   = 3240 // 54 min
+	DefaultTmsiGuardPeriod            int   = 60   // 1 min
 )
 
 // timers at ETAF side, defined in TS 24.501 table 10.2.2
@@ -0,0 +1,162 @@
+package context
+
+import (
+	"fmt"
+	"free5gc/lib/openapi/models"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Alert is a public warning broadcast to the UEs of its warning area, as a
+// message of the cell broadcast service
+type Alert struct {
+	Id                 string
+	MessageIdentifier  int32 // CBS message identifier, e.g. 4370 for a presidential alert
+	SerialNumber       int32
+	WarningArea        WarningArea
+	Message            string
+	RepetitionPeriod   int32 // seconds
+	NumberOfBroadcasts int32 // 0 broadcasts until cancelled
+	Caller             string
+	IssuedAt           time.Time
+
+	mutex       sync.Mutex
+	cancelledAt *time.Time
+	deliveries  map[string]*RanDelivery // RAN node ID as key
+}
+
+// Delivery status of an alert at a RAN
+const (
+	DeliveryPending       = "PENDING"        // Write-Replace Warning Request sent
+	DeliveryBroadcasting  = "BROADCASTING"   // Write-Replace Warning Response received
+	DeliveryFailed        = "FAILED"         // broadcast failed in some cells
+	DeliveryCancelPending = "CANCEL_PENDING" // PWS Cancel Request sent
+	DeliveryCancelled     = "CANCELLED"      // PWS Cancel Response received
+)
+
+// RanDelivery is the delivery of an alert to a RAN over NGAP
+type RanDelivery struct {
+	RanNodeId   string    `json:"ranNodeId"`
+	Status      string    `json:"status"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Restarts    int       `json:"restarts,omitempty"` // re-sent after a PWS restart or a new NG Setup
+	FailedCells []string  `json:"failedCells,omitempty"`
+}
+
+// WarningArea is the union of TAIs, cells and a geographic area
+type WarningArea struct {
+	TaiList  []models.Tai    `json:"taiList,omitempty" yaml:"taiList,omitempty" bson:"taiList,omitempty"`
+	NcgiList []models.Ncgi   `json:"ncgiList,omitempty" yaml:"ncgiList,omitempty" bson:"ncgiList,omitempty"`
+	EcgiList []models.Ecgi   `json:"ecgiList,omitempty" yaml:"ecgiList,omitempty" bson:"ecgiList,omitempty"`
+	Area     *GeographicArea `json:"area,omitempty" yaml:"area,omitempty" bson:"area,omitempty"`
+}
+
+func (area *WarningArea) IsEmpty() bool {
+	return len(area.TaiList) == 0 && len(area.NcgiList) == 0 && len(area.EcgiList) == 0 && area.Area == nil
+}
+
+// Cancel returns false if the alert was already cancelled
+func (alert *Alert) Cancel() bool {
+	alert.mutex.Lock()
+	defer alert.mutex.Unlock()
+
+	if alert.cancelledAt != nil {
+		return false
+	}
+	now := time.Now().UTC()
+	alert.cancelledAt = &now
+	return true
+}
+
+func (alert *Alert) CancelledAt() *time.Time {
+	alert.mutex.Lock()
+	defer alert.mutex.Unlock()
+
+	return alert.cancelledAt
+}
+
+func (alert *Alert) IsActive() bool {
+	return alert.CancelledAt() == nil
+}
+
+// UpdateDelivery changes the delivery of the alert to a RAN under the alert lock,
+// adding it if the RAN has none yet
+func (alert *Alert) UpdateDelivery(ranNodeId string, update func(delivery *RanDelivery)) {
+	alert.mutex.Lock()
+	defer alert.mutex.Unlock()
+
+	if alert.deliveries == nil {
+		alert.deliveries = make(map[string]*RanDelivery)
+	}
+	delivery, ok := alert.deliveries[ranNodeId]
+	if !ok {
+		delivery = &RanDelivery{RanNodeId: ranNodeId}
+		alert.deliveries[ranNodeId] = delivery
+	}
+	update(delivery)
+	delivery.UpdatedAt = time.Now().UTC()
+}
+
+// DeliveryReport returns copies of the deliveries of the alert ordered by RAN
+func (alert *Alert) DeliveryReport() []RanDelivery {
+	alert.mutex.Lock()
+	defer alert.mutex.Unlock()
+
+	report := make([]RanDelivery, 0, len(alert.deliveries))
+	for _, delivery := range alert.deliveries {
+		report = append(report, *delivery)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].RanNodeId < report[j].RanNodeId })
+	return report
+}
+
+func (context *ETAFContext) NewAlert() (*Alert, error) {
+	id, err := alertIDGenerator.Allocate()
+	if err != nil {
+		return nil, fmt.Errorf("Allocate alert ID error: %+v", err)
+	}
+	alert := &Alert{
+		Id:       strconv.FormatInt(id, 10),
+		IssuedAt: time.Now().UTC(),
+	}
+	return alert, nil
+}
+
+// AddAlert keeps the alert, cancelled or not, until the ETAF restarts
+func (context *ETAFContext) AddAlert(alert *Alert) {
+	context.AlertPool.Store(alert.Id, alert)
+}
+
+func (context *ETAFContext) AlertFindById(id string) (*Alert, bool) {
+	if value, ok := context.AlertPool.Load(id); ok {
+		return value.(*Alert), true
+	}
+	return nil, false
+}
+
+// AlertFindByMessage finds the last alert issued with the message identifier and
+// serial number, as they appear in the PWS procedures
+func (context *ETAFContext) AlertFindByMessage(messageIdentifier, serialNumber int32) (*Alert, bool) {
+	var found *Alert
+	context.AlertPool.Range(func(key, value interface{}) bool {
+		alert := value.(*Alert)
+		if alert.MessageIdentifier == messageIdentifier && alert.SerialNumber == serialNumber &&
+			(found == nil || alert.IssuedAt.After(found.IssuedAt)) {
+			found = alert
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// Alerts returns the alerts in the order they were issued
+func (context *ETAFContext) Alerts() (alerts []*Alert) {
+	context.AlertPool.Range(func(key, value interface{}) bool {
+		alerts = append(alerts, value.(*Alert))
+		return true
+	})
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].IssuedAt.Before(alerts[j].IssuedAt) })
+	return
+}
@@ -39,6 +39,11 @@ func InTaiList(servedTai models.Tai, taiList []models.Tai) bool {
 	return false
 }
 
+// SamePlmn reports whether two PLMN IDs are given and equal
+func SamePlmn(plmnId1, plmnId2 *models.PlmnId) bool {
+	return plmnId1 != nil && plmnId2 != nil && *plmnId1 == *plmnId2
+}
+
 func TacInAreas(targetTac string, areas []models.Area) bool {
 	for _, area := range areas {
 		for _, tac := range area.Tacs {
@@ -7,7 +7,6 @@ import (
 	"free5gc/src/etaf/logger"
 	"math"
 	"net"
-	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,29 +16,40 @@ var etafContext = ETAFContext{}
 var tmsiGenerator *idgenerator.IDGenerator = nil
 var etafUeNGAPIDGenerator *idgenerator.IDGenerator = nil
 var etafStatusSubscriptionIDGenerator *idgenerator.IDGenerator = nil
+var alertIDGenerator *idgenerator.IDGenerator = nil
 
 func init() {
 	ETAF_Self().LadnPool = make(map[string]*LADN)
 	ETAF_Self().EventSubscriptionIDGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
 	ETAF_Self().Name = "etaf"
 	ETAF_Self().UriScheme = models.UriScheme_HTTPS
-	ETAF_Self().RelativeCapacity = 0xff
+	// the ETAF serves no UE: advertised with no capacity, the RANs never select it
+	ETAF_Self().RelativeCapacity = 0
 	ETAF_Self().ServedGuamiList = make([]models.Guami, 0, MaxNumOfServedGuamiList)
 	ETAF_Self().PlmnSupportList = make([]PlmnSupportItem, 0, MaxNumOfPLMNs)
 	ETAF_Self().NfService = make(map[models.ServiceName]models.NfService)
 	ETAF_Self().NetworkName.Full = "free5GC"
+	ETAF_Self().TmsiGuardPeriod = DefaultTmsiGuardPeriod
 	tmsiGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
 	etafStatusSubscriptionIDGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
 	etafUeNGAPIDGenerator = idgenerator.NewGenerator(1, MaxValueOfEtafUeNgapId)
+	alertIDGenerator = idgenerator.NewGenerator(1, math.MaxInt32)
 	ETAF_Self().AMFStatusSubsData = make(map[string]AMFStatusSubscriptionData)
 }
 
 type ETAFContext struct {
 	EventSubscriptionIDGenerator    *idgenerator.IDGenerator
 	EventSubscriptions              sync.Map
-	UePool                          sync.Map         // map[supi]*EtafUe
+	UePool                          sync.Map         // map[supi]*EtafUe, PEI as key for the UEs without SUPI
+	PeiIndex                        sync.Map         // map[canonical PEI]*EtafUe
+	GutiIndex                       sync.Map         // map[guti]*EtafUe
+	GpsiIndex                       sync.Map         // map[gpsi]*EtafUe
+	PolicyAssociationIndex          sync.Map         // map[polAssoId]*EtafUe
 	RanUePool                       sync.Map         // map[EtafUeNgapID]*RanUe
 	EtafRanPool                     sync.Map         // map[net.Conn]*EtafRan
+	TrackingSessionPool             sync.Map         // map[sessionId]*TrackingSession
+	AlertPool                       sync.Map         // map[alertId]*Alert
+	NotifyCorrelations              sync.Map         // map[NotifyCorrelationId]correlation ID of the subscribing request
 	LadnPool                        map[string]*LADN // dnn as key
 	SupportTaiLists                 []models.Tai
 	ServedGuamiList                 []models.Guami
@@ -60,9 +70,13 @@ type ETAFContext struct {
 	SecurityAlgorithm               SecurityAlgorithm
 	NetworkName                     NetworkName
 	NgapIpList                      []string // NGAP Server IP
+	NgapPort                        int
 	T3502Value                      int      // unit is second
 	T3512Value                      int      // unit is second
 	Non3gppDeregistrationTimerValue int      // unit is second
+	TmsiGuardPeriod                 int      // unit is second
+	RegistrationAreaConfig          RegistrationAreaConfig
+	Non3gppTai                      Non3gppTaiConfig
 	AMFStatusSubsData map[string]AMFStatusSubscriptionData // subscriptionId as key
 }
 
@@ -100,43 +114,28 @@ func NewPlmnSupportItem() (item PlmnSupportItem) {
 	return
 }
 
-func (context *ETAFContext) TmsiAllocate() int32 {
-	tmsi, err := tmsiGenerator.Allocate()
-	if err != nil {
-		logger.ContextLog.Errorf("Allocate TMSI error: %+v", err)
-		return -1
-	}
-	return int32(tmsi)
-}
-
 func (context *ETAFContext) AllocateEtafUeNgapID() (int64, error) {
 	return etafUeNGAPIDGenerator.Allocate()
 }
 
-func (context *ETAFContext) AllocateGutiToUe(ue *EtafUe) {
-	servedGuami := context.ServedGuamiList[0]
-	ue.Tmsi = context.TmsiAllocate()
+// AllocateGutiToUe allocates a new GUTI to the UE, the TMSI of its previous GUTI
+// is quarantined
+func (context *ETAFContext) AllocateGutiToUe(ue *EtafUe) error {
+	servedGuami, err := context.SelectServedGuami(ue)
+	if err != nil {
+		return err
+	}
+	tmsi, err := context.TmsiAllocate()
+	if err != nil {
+		return err
+	}
+	context.FreeTmsi(ue.Tmsi)
+	ue.Tmsi = tmsi
 
 	plmnID := servedGuami.PlmnId.Mcc + servedGuami.PlmnId.Mnc
 	tmsiStr := fmt.Sprintf("%08x", ue.Tmsi)
-	ue.Guti = plmnID + servedGuami.AmfId + tmsiStr
-}
-
-func (context *ETAFContext) AllocateRegistrationArea(ue *EtafUe, anType models.AccessType) {
-
-	// clear the previous registration area if need
-	if len(ue.RegistrationArea[anType]) > 0 {
-		ue.RegistrationArea[anType] = nil
-	}
-
-	// allocate a new tai list as a registration area to ue
-	// TODO: algorithm to choose TAI list
-	for _, supportTai := range context.SupportTaiLists {
-		if reflect.DeepEqual(supportTai, ue.Tai) {
-			ue.RegistrationArea[anType] = append(ue.RegistrationArea[anType], supportTai)
-			break
-		}
-	}
+	ue.SetGuti(plmnID + servedGuami.AmfId + tmsiStr)
+	return nil
 }
 
 func (context *ETAFContext) NewETAFStatusSubscription(subscriptionData models.SubscriptionData) (subscriptionID string) {
@@ -155,8 +154,28 @@ func (context *ETAFContext) AddEtafUeToUePool(ue *EtafUe, supi string) {
 	if len(supi) == 0 {
 		logger.ContextLog.Errorf("Supi is nil")
 	}
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
 	ue.Supi = supi
 	context.UePool.Store(ue.Supi, ue)
+	context.indexUeIdentifiers(ue)
+	ue.pooled = true
+}
+
+// AddEtafUeToUePoolByPei pools a UE without SUPI, such as an emergency registered
+// UE without a valid SIM, under its canonical PEI
+func (context *ETAFContext) AddEtafUeToUePoolByPei(ue *EtafUe, pei string) error {
+	key, err := CanonicalPei(pei)
+	if err != nil {
+		return err
+	}
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
+	ue.Pei = pei
+	context.UePool.Store(key, ue)
+	context.indexUeIdentifiers(ue)
+	ue.pooled = true
+	return nil
 }
 
 func (context *ETAFContext) NewEtafUe(supi string) *EtafUe {
@@ -167,7 +186,9 @@ func (context *ETAFContext) NewEtafUe(supi string) *EtafUe {
 		context.AddEtafUeToUePool(&ue, supi)
 	}
 
-	context.AllocateGutiToUe(&ue)
+	if err := context.AllocateGutiToUe(&ue); err != nil {
+		logger.ContextLog.Errorf("Allocate GUTI error: %+v", err)
+	}
 
 	return &ue
 }
@@ -194,22 +215,21 @@ func (context *ETAFContext) EtafUeFindBySupi(supi string) (ue *EtafUe, ok bool)
 	return
 }
 
+// EtafUeFindByPei finds a UE by its PEI; an IMEI and the IMEISV of the same
+// equipment find the same UE
 func (context *ETAFContext) EtafUeFindByPei(pei string) (ue *EtafUe, ok bool) {
-	context.UePool.Range(func(key, value interface{}) bool {
-		candidate := value.(*EtafUe)
-		if ok = (candidate.Pei == pei); ok {
-			ue = candidate
-			return false
-		}
-		return true
-	})
-	return
+	return findIndexedUe(&context.PeiIndex, peiKey(pei))
+}
+
+func (context *ETAFContext) EtafUeFindByGpsi(gpsi string) (ue *EtafUe, ok bool) {
+	return findIndexedUe(&context.GpsiIndex, gpsi)
 }
 
 func (context *ETAFContext) NewEtafRan(conn net.Conn) *EtafRan {
 	ran := EtafRan{}
 	ran.SupportedTAList = make([]SupportedTAI, 0, MaxNumOfTAI*MaxNumOfBroadcastPLMNs)
 	ran.Conn = conn
+	ran.Log = logger.NgapLog.WithField(logger.FieldRanAddr, conn.RemoteAddr().String())
 	context.EtafRanPool.Store(conn, &ran)
 	return &ran
 }
@@ -268,27 +288,11 @@ func (context *ETAFContext) InSupportDnnList(targetDnn string) bool {
 }
 
 func (context *ETAFContext) EtafUeFindByGuti(guti string) (ue *EtafUe, ok bool) {
-	context.UePool.Range(func(key, value interface{}) bool {
-		candidate := value.(*EtafUe)
-		if ok = (candidate.Guti == guti); ok {
-			ue = candidate
-			return false
-		}
-		return true
-	})
-	return
+	return findIndexedUe(&context.GutiIndex, guti)
 }
 
 func (context *ETAFContext) EtafUeFindByPolicyAssociationID(polAssoId string) (ue *EtafUe, ok bool) {
-	context.UePool.Range(func(key, value interface{}) bool {
-		candidate := value.(*EtafUe)
-		if ok = (candidate.PolicyAssociationId == polAssoId); ok {
-			ue = candidate
-			return false
-		}
-		return true
-	})
-	return
+	return findIndexedUe(&context.PolicyAssociationIndex, polAssoId)
 }
 
 func (context *ETAFContext) RanUeFindByEtafUeNgapID(etafUeNgapID int64) *RanUe {
@@ -358,7 +362,7 @@ func (context *ETAFContext) Reset() {
 	context.SupportTaiLists = context.SupportTaiLists[:0]
 	context.PlmnSupportList = context.PlmnSupportList[:0]
 	context.ServedGuamiList = context.ServedGuamiList[:0]
-	context.RelativeCapacity = 0xff
+	context.RelativeCapacity = 0
 	context.NfId = ""
 	context.UriScheme = models.UriScheme_HTTPS
 	context.SBIPort = 0
@@ -7,6 +7,12 @@ import (
 	"free5gc/lib/openapi/models"
 	"free5gc/src/etaf/logger"
 	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -27,6 +33,13 @@ type EtafRan struct {
 
 	/* RAN UE List */
 	RanUeList []*RanUe // RanUeNgapId as key
+
+	// mutex guards SupportedTAList and RanUeList, which the NGAP handlers update
+	// while the SBI handlers read them through SupportedTais and RanUes
+	mutex sync.RWMutex
+
+	/* logger */
+	Log *logrus.Entry
 }
 
 type SupportedTAI struct {
@@ -55,13 +68,14 @@ func (ran *EtafRan) NewRanUe(ranUeNgapID int64) (*RanUe, error) {
 	ranUe.RanUeNgapId = ranUeNgapID
 	ranUe.Ran = ran
 
-	ran.RanUeList = append(ran.RanUeList, &ranUe)
+	ran.addRanUe(&ranUe)
 	self.RanUePool.Store(ranUe.EtafUeNgapId, &ranUe)
 	return &ranUe, nil
 }
 
 func (ran *EtafRan) RemoveAllUeInRan() {
-	for _, ranUe := range ran.RanUeList {
+	// RanUe.Remove removes the UE from the list
+	for _, ranUe := range ran.RanUes() {
 		if err := ranUe.Remove(); err != nil {
 			logger.ContextLog.Errorf("Remove RanUe error: %v", err)
 		}
@@ -69,6 +83,9 @@ func (ran *EtafRan) RemoveAllUeInRan() {
 }
 
 func (ran *EtafRan) RanUeFindByRanUeNgapID(ranUeNgapID int64) *RanUe {
+	ran.mutex.RLock()
+	defer ran.mutex.RUnlock()
+
 	for _, ranUe := range ran.RanUeList {
 		if ranUe.RanUeNgapId == ranUeNgapID {
 			return ranUe
@@ -77,6 +94,73 @@ func (ran *EtafRan) RanUeFindByRanUeNgapID(ranUeNgapID int64) *RanUe {
 	return nil
 }
 
+// RanUes returns a snapshot of the RAN UE List
+func (ran *EtafRan) RanUes() []*RanUe {
+	ran.mutex.RLock()
+	defer ran.mutex.RUnlock()
+
+	return append([]*RanUe(nil), ran.RanUeList...)
+}
+
+func (ran *EtafRan) addRanUe(ranUe *RanUe) {
+	ran.mutex.Lock()
+	defer ran.mutex.Unlock()
+
+	ran.RanUeList = append(ran.RanUeList, ranUe)
+}
+
+func (ran *EtafRan) removeRanUe(ranUe *RanUe) {
+	ran.mutex.Lock()
+	defer ran.mutex.Unlock()
+
+	for index, ranUe1 := range ran.RanUeList {
+		if ranUe1 == ranUe {
+			ran.RanUeList = append(ran.RanUeList[:index], ran.RanUeList[index+1:]...)
+			break
+		}
+	}
+}
+
+// SupportedTais returns a snapshot of the Supported TA List
+func (ran *EtafRan) SupportedTais() []SupportedTAI {
+	ran.mutex.RLock()
+	defer ran.mutex.RUnlock()
+
+	return append([]SupportedTAI(nil), ran.SupportedTAList...)
+}
+
+// SetSupportedTAList replaces the Supported TA List, as set up by NG Setup or
+// updated by RAN Configuration Update
+func (ran *EtafRan) SetSupportedTAList(supportedTAList []SupportedTAI) {
+	ran.mutex.Lock()
+	defer ran.mutex.Unlock()
+
+	ran.SupportedTAList = supportedTAList
+}
+
+// RanNodeId renders the global RAN node ID as "<type>-<mcc><mnc>-<id>", with type
+// gnb, ngenb or n3iwf
+func (ran *EtafRan) RanNodeId() string {
+	if ran.RanId == nil {
+		return ""
+	}
+	plmnId := ""
+	if ran.RanId.PlmnId != nil {
+		plmnId = ran.RanId.PlmnId.Mcc + ran.RanId.PlmnId.Mnc
+	}
+	switch ran.RanPresent {
+	case RanPresentGNbId:
+		if ran.RanId.GNbId != nil {
+			return "gnb-" + plmnId + "-" + ran.RanId.GNbId.GNBValue
+		}
+	case RanPresentNgeNbId:
+		return "ngenb-" + plmnId + "-" + ran.RanId.NgeNbId
+	case RanPresentN3IwfId:
+		return "n3iwf-" + plmnId + "-" + ran.RanId.N3IwfId
+	}
+	return ""
+}
+
 func (ran *EtafRan) SetRanId(ranNodeId *ngapType.GlobalRANNodeID) {
 	ranId := ngapConvert.RanIdToModels(*ranNodeId)
 	ran.RanPresent = ranNodeId.Present
@@ -86,4 +170,68 @@ func (ran *EtafRan) SetRanId(ranNodeId *ngapType.GlobalRANNodeID) {
 	} else {
 		ran.AnType = models.AccessType__3_GPP_ACCESS
 	}
+	if ran.Log != nil {
+		ran.Log = ran.Log.WithField(logger.FieldRanId, ran.RanNodeId())
+	}
+}
+
+// SupportsTai reports whether the TAI is in the Supported TA List of the RAN
+func (ran *EtafRan) SupportsTai(tai models.Tai) bool {
+	ran.mutex.RLock()
+	defer ran.mutex.RUnlock()
+
+	for _, supportedTai := range ran.SupportedTAList {
+		if reflect.DeepEqual(supportedTai.Tai, tai) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServesNrCell reports whether the NR cell is a cell of the gNB, the gNB ID being
+// the leftmost bits of the 36 bit NR cell identity
+func (ran *EtafRan) ServesNrCell(ncgi models.Ncgi) bool {
+	if ran.RanPresent != RanPresentGNbId || ran.RanId == nil || ran.RanId.GNbId == nil {
+		return false
+	}
+	return SamePlmn(ran.RanId.PlmnId, ncgi.PlmnId) &&
+		cellOfNode(ncgi.NrCellId, 36, ran.RanId.GNbId.GNBValue, int(ran.RanId.GNbId.BitLength))
+}
+
+// ServesEutraCell reports whether the E-UTRA cell is a cell of the ng-eNB, the
+// eNB ID being the leftmost bits of the 28 bit E-UTRA cell identity
+func (ran *EtafRan) ServesEutraCell(ecgi models.Ecgi) bool {
+	if ran.RanPresent != RanPresentNgeNbId || ran.RanId == nil {
+		return false
+	}
+	// NgeNbId is rendered as <type>-<eNB ID in hexadecimal>
+	var bitLength int
+	nodeId := ran.RanId.NgeNbId
+	switch {
+	case strings.HasPrefix(nodeId, "MacroNGeNB-"):
+		bitLength = 20
+	case strings.HasPrefix(nodeId, "SMacroNGeNB-"):
+		bitLength = 18
+	case strings.HasPrefix(nodeId, "LMacroNGeNB-"):
+		bitLength = 21
+	default:
+		return false
+	}
+	nodeId = nodeId[strings.Index(nodeId, "-")+1:]
+	return SamePlmn(ran.RanId.PlmnId, ecgi.PlmnId) && cellOfNode(ecgi.EutraCellId, 28, nodeId, bitLength)
+}
+
+func cellOfNode(cellId string, cellIdLength int, nodeId string, nodeIdLength int) bool {
+	if nodeIdLength <= 0 || nodeIdLength > cellIdLength {
+		return false
+	}
+	cell, err := strconv.ParseUint(cellId, 16, 64)
+	if err != nil {
+		return false
+	}
+	node, err := strconv.ParseUint(nodeId, 16, 64)
+	if err != nil {
+		return false
+	}
+	return cell>>uint(cellIdLength-nodeIdLength) == node
 }
@@ -53,6 +53,10 @@ type EtafUe struct {
 	DeregistrationTargetAccessType     uint8 // only used when deregistration procedure is initialized by the network
 	RegistrationAcceptForNon3GPPAccess []byte
 	RetransmissionOfInitialNASMsg      bool
+	/* Emergency services */
+	EmergencyRegistered bool       // emergency registration, from the registration type or reported by the AMF
+	EmergencySessionId  string     // tracking session following the UE while it is in emergency services
+	EmergencyMutex      sync.Mutex // serialises the start and end of the emergency tracking session
 	/* Ue Identity*/
 	PlmnId              models.PlmnId
 	Suci                string
@@ -64,15 +68,23 @@ type EtafUe struct {
 	Guti                string
 	GroupID             string
 	EBI                 int32
+	// The UE is indexed by its identifiers only while it is pooled
+	indexMutex sync.Mutex
+	pooled     bool
 	/* Ue Identity*/
 	// EventSubscriptionsInfo map[string]*EtafUeEventSubscription
 	/* User Location*/
+	// LocationMutex serialises the location updates of the UE, from the UE context
+	// until they are queued for the streams and tracking sessions
+	LocationMutex            sync.Mutex
 	RatType                  models.RatType
 	Location                 models.UserLocation
 	Tai                      models.Tai
 	LocationChanged          bool
 	LastVisitedRegisteredTai models.Tai
 	TimeZone                 string
+	LocationHistory          *LocationHistory
+	LocationReporting        LocationReporting
 	/* context about udm */
 	UdmId                             string
 	NudmUECMUri                       string
@@ -257,6 +269,7 @@ func (ue *EtafUe) init() {
 	ue.OnGoing[models.AccessType__3_GPP_ACCESS] = new(OnGoing)
 	ue.OnGoing[models.AccessType__3_GPP_ACCESS].Procedure = OnGoingProcedureNothing
 	ue.ReleaseCause = make(map[models.AccessType]*CauseAll)
+	ue.LocationHistory = new(LocationHistory)
 }
 
 func (ue *EtafUe) CmConnect(anType models.AccessType) bool {
@@ -276,10 +289,26 @@ func (ue *EtafUe) Remove() {
 			logger.ContextLog.Errorf("Remove RanUe error: %v", err)
 		}
 	}
-	tmsiGenerator.FreeID(int64(ue.Tmsi))
-	if len(ue.Supi) > 0 {
-		ETAF_Self().UePool.Delete(ue.Supi)
+	ETAF_Self().FreeTmsi(ue.Tmsi)
+
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
+	if id := ue.UeId(); len(id) > 0 {
+		ETAF_Self().UePool.Delete(id)
+	}
+	if ue.pooled {
+		ETAF_Self().unindexUeIdentifiers(ue)
+		ue.pooled = false
+	}
+}
+
+// UeId returns the SUPI of the UE, or the canonical PEI of a UE without SUPI,
+// as it is pooled
+func (ue *EtafUe) UeId() string {
+	if ue.Supi != "" || ue.Pei == "" {
+		return ue.Supi
 	}
+	return peiKey(ue.Pei)
 }
 
 func (ue *EtafUe) DetachRanUe(anType models.AccessType) {
@@ -374,6 +403,13 @@ func (ue *EtafUe) SecurityContextIsValid() bool {
 }
 
 func (ue *EtafUe) ClearRegistrationRequestData(accessType models.AccessType) {
+	// an emergency registration lasts until the UE registers again initially
+	switch ue.RegistrationType5GS {
+	case nasMessage.RegistrationType5GSEmergencyRegistration:
+		ue.EmergencyRegistered = true
+	case nasMessage.RegistrationType5GSInitialRegistration:
+		ue.EmergencyRegistered = false
+	}
 	ue.RegistrationRequest = nil
 	ue.RegistrationType5GS = 0
 	ue.IdentityTypeUsedForRegistration = 0
@@ -386,7 +422,7 @@ func (ue *EtafUe) ClearRegistrationRequestData(accessType models.AccessType) {
 
 func (ue *EtafUe) RemoveAmPolicyAssociation() {
 	ue.AmPolicyAssociation = nil
-	ue.PolicyAssociationId = ""
+	ue.SetPolicyAssociationId("")
 }
 
 func (ue *EtafUe) CopyDataFromUeContextModel(ueContext models.UeContext) {
@@ -396,7 +432,7 @@ func (ue *EtafUe) CopyDataFromUeContextModel(ueContext models.UeContext) {
 	}
 
 	if ueContext.Pei != "" {
-		ue.Pei = ueContext.Pei
+		ue.SetPei(ueContext.Pei)
 	}
 
 	if ueContext.UdmGroupId != "" {
@@ -0,0 +1,138 @@
+package context
+
+import (
+	"fmt"
+	"math"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371000
+
+// GAD shapes of TS 23.032 as named by TS 29.572
+const (
+	GADShapePoint                   = "POINT"
+	GADShapePointUncertaintyCircle  = "POINT_UNCERTAINTY_CIRCLE"
+	GADShapePointUncertaintyEllipse = "POINT_UNCERTAINTY_ELLIPSE"
+	GADShapePolygon                 = "POLYGON"
+)
+
+type GeographicalCoordinates struct {
+	Lon float64 `json:"lon" yaml:"lon" bson:"lon"`
+	Lat float64 `json:"lat" yaml:"lat" bson:"lat"`
+}
+
+type UncertaintyEllipse struct {
+	SemiMajor        float32 `json:"semiMajor" yaml:"semiMajor" bson:"semiMajor"` // meters
+	SemiMinor        float32 `json:"semiMinor" yaml:"semiMinor" bson:"semiMinor"` // meters
+	OrientationMajor int32   `json:"orientationMajor" yaml:"orientationMajor" bson:"orientationMajor"`
+}
+
+// GeographicArea is a location estimate or an area; the fields set depend on Shape
+type GeographicArea struct {
+	Shape              string                    `json:"shape" yaml:"shape" bson:"shape"`
+	Point              *GeographicalCoordinates  `json:"point,omitempty" yaml:"point,omitempty" bson:"point,omitempty"`
+	Uncertainty        float32                   `json:"uncertainty,omitempty" yaml:"uncertainty,omitempty" bson:"uncertainty,omitempty"` // meters
+	UncertaintyEllipse *UncertaintyEllipse       `json:"uncertaintyEllipse,omitempty" yaml:"uncertaintyEllipse,omitempty" bson:"uncertaintyEllipse,omitempty"`
+	Confidence         int32                     `json:"confidence,omitempty" yaml:"confidence,omitempty" bson:"confidence,omitempty"` // percent
+	PointList          []GeographicalCoordinates `json:"pointList,omitempty" yaml:"pointList,omitempty" bson:"pointList,omitempty"`    // vertices of a polygon
+}
+
+// Validate checks that the fields of the shape are present and the coordinates
+// are in range
+func (area *GeographicArea) Validate() error {
+	switch area.Shape {
+	case GADShapePoint, GADShapePointUncertaintyCircle, GADShapePointUncertaintyEllipse:
+		if area.Point == nil {
+			return fmt.Errorf("%s without point", area.Shape)
+		}
+		if area.Shape == GADShapePointUncertaintyEllipse && area.UncertaintyEllipse == nil {
+			return fmt.Errorf("%s without uncertaintyEllipse", area.Shape)
+		}
+		return area.Point.validate()
+	case GADShapePolygon:
+		if len(area.PointList) < 3 || len(area.PointList) > 15 {
+			return fmt.Errorf("%s with %d points, 3 to 15 expected", area.Shape, len(area.PointList))
+		}
+		for _, point := range area.PointList {
+			if err := point.validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported shape %q", area.Shape)
+	}
+}
+
+func (coordinates GeographicalCoordinates) validate() error {
+	if coordinates.Lat < -90 || coordinates.Lat > 90 || coordinates.Lon < -180 || coordinates.Lon > 180 {
+		return fmt.Errorf("coordinates (%f, %f) out of range", coordinates.Lat, coordinates.Lon)
+	}
+	return nil
+}
+
+// Center returns the point of a point shape or the centroid of the vertices of a
+// polygon
+func (area *GeographicArea) Center() (GeographicalCoordinates, bool) {
+	if area.Point != nil {
+		return *area.Point, true
+	}
+	if len(area.PointList) == 0 {
+		return GeographicalCoordinates{}, false
+	}
+	var center GeographicalCoordinates
+	for _, point := range area.PointList {
+		center.Lat += point.Lat
+		center.Lon += point.Lon
+	}
+	center.Lat /= float64(len(area.PointList))
+	center.Lon /= float64(len(area.PointList))
+	return center, true
+}
+
+// Contains reports whether point lies in the area: within the uncertainty of a
+// point shape, taking the semi-major axis of an ellipse, or inside a polygon
+func (area *GeographicArea) Contains(point GeographicalCoordinates) bool {
+	switch area.Shape {
+	case GADShapePointUncertaintyCircle:
+		return area.Point != nil && Distance(*area.Point, point) <= float64(area.Uncertainty)
+	case GADShapePointUncertaintyEllipse:
+		return area.Point != nil && area.UncertaintyEllipse != nil &&
+			Distance(*area.Point, point) <= float64(area.UncertaintyEllipse.SemiMajor)
+	case GADShapePolygon:
+		// even-odd rule, treating the coordinates as planar
+		inside := false
+		for i, j := 0, len(area.PointList)-1; i < len(area.PointList); j, i = i, i+1 {
+			a, b := area.PointList[i], area.PointList[j]
+			if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+				point.Lon < (b.Lon-a.Lon)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+				inside = !inside
+			}
+		}
+		return inside
+	default:
+		return false
+	}
+}
+
+// Distance returns the great-circle distance between two points in meters
+func Distance(from, to GeographicalCoordinates) float64 {
+	lat1, lat2 := from.Lat*math.Pi/180, to.Lat*math.Pi/180
+	deltaLat := lat2 - lat1
+	deltaLon := (to.Lon - from.Lon) * math.Pi / 180
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Offset returns the point at distance meters from point in the direction of
+// azimuth, in degrees clockwise from north
+func Offset(point GeographicalCoordinates, azimuth, distance float64) GeographicalCoordinates {
+	bearing := azimuth * math.Pi / 180
+	angular := distance / earthRadius
+	lat1, lon1 := point.Lat*math.Pi/180, point.Lon*math.Pi/180
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(bearing))
+	lon2 := lon1 + math.Atan2(math.Sin(bearing)*math.Sin(angular)*math.Cos(lat1),
+		math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+	return GeographicalCoordinates{Lat: lat2 * 180 / math.Pi, Lon: lon2 * 180 / math.Pi}
+}
@@ -0,0 +1,99 @@
+package context
+
+import (
+	"container/list"
+	"fmt"
+	"free5gc/lib/openapi/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// guamiCursor rotates the GUTI allocations over the candidate GUAMIs
+var guamiCursor uint32
+
+// tmsiQuarantine holds the freed TMSIs until their guard period elapsed, so a
+// GUTI is not reassigned while the RANs and peer NFs may still refer to its
+// previous UE
+var tmsiQuarantine = struct {
+	sync.Mutex
+	list.List // of quarantinedTmsi, by release time
+}{}
+
+type quarantinedTmsi struct {
+	tmsi      int32
+	releaseAt time.Time
+}
+
+// SelectServedGuami selects the GUAMI of a new GUTI of the UE: the served GUAMIs
+// of the UE's PLMN, or all the served GUAMIs if none is of its PLMN, are used in
+// turn
+func (context *ETAFContext) SelectServedGuami(ue *EtafUe) (*models.Guami, error) {
+	if len(context.ServedGuamiList) == 0 {
+		return nil, fmt.Errorf("No served GUAMI")
+	}
+
+	candidates := make([]int, 0, len(context.ServedGuamiList))
+	if ue.PlmnId.Mcc != "" {
+		for i, guami := range context.ServedGuamiList {
+			if guami.PlmnId != nil && *guami.PlmnId == ue.PlmnId {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range context.ServedGuamiList {
+			candidates = append(candidates, i)
+		}
+	}
+
+	cursor := atomic.AddUint32(&guamiCursor, 1)
+	guami := context.ServedGuamiList[candidates[int(cursor%uint32(len(candidates)))]]
+	if guami.PlmnId == nil {
+		return nil, fmt.Errorf("Served GUAMI[%s] has no PLMN ID", guami.AmfId)
+	}
+	return &guami, nil
+}
+
+func (context *ETAFContext) TmsiAllocate() (int32, error) {
+	releaseQuarantinedTmsis(time.Now())
+	tmsi, err := tmsiGenerator.Allocate()
+	if err != nil {
+		return 0, fmt.Errorf("Allocate TMSI error: %+v", err)
+	}
+	return int32(tmsi), nil
+}
+
+// FreeTmsi quarantines a TMSI no longer assigned, it is reused once the TMSI
+// guard period elapsed
+func (context *ETAFContext) FreeTmsi(tmsi int32) {
+	if tmsi <= 0 {
+		return
+	}
+	now := time.Now()
+	releaseQuarantinedTmsis(now)
+
+	guardPeriod := time.Duration(context.TmsiGuardPeriod) * time.Second
+	if guardPeriod <= 0 {
+		tmsiGenerator.FreeID(int64(tmsi))
+		return
+	}
+	tmsiQuarantine.Lock()
+	defer tmsiQuarantine.Unlock()
+	tmsiQuarantine.PushBack(quarantinedTmsi{tmsi: tmsi, releaseAt: now.Add(guardPeriod)})
+}
+
+// releaseQuarantinedTmsis returns the TMSIs whose guard period elapsed to the
+// allocator
+func releaseQuarantinedTmsis(now time.Time) {
+	tmsiQuarantine.Lock()
+	defer tmsiQuarantine.Unlock()
+	for element := tmsiQuarantine.Front(); element != nil; element = tmsiQuarantine.Front() {
+		quarantined := element.Value.(quarantinedTmsi)
+		if quarantined.releaseAt.After(now) {
+			break
+		}
+		tmsiQuarantine.Remove(element)
+		tmsiGenerator.FreeID(int64(quarantined.tmsi))
+	}
+}
@@ -0,0 +1,119 @@
+package context
+
+import (
+	"free5gc/lib/openapi/models"
+	"sync"
+	"time"
+
+	"github.com/mohae/deepcopy"
+)
+
+// maxLocationPoints bounds the history kept per UE
+const maxLocationPoints = 1024
+
+// LocationPoint is one location of a UE, with the estimate of a positioning if
+// there was one. Location and Estimate are nil once the point has been coarsened
+// by the retention policy, leaving only the TAI.
+type LocationPoint struct {
+	Time       time.Time            `json:"time"`
+	AccessType models.AccessType    `json:"accessType"`
+	Tai        models.Tai           `json:"tai"`
+	Location   *models.UserLocation `json:"location,omitempty"`
+	Estimate   *GeographicArea      `json:"estimate,omitempty"`
+	Coarsened  bool                 `json:"coarsened,omitempty"`
+}
+
+type LocationHistory struct {
+	mutex  sync.RWMutex
+	points []LocationPoint // oldest first
+}
+
+func (history *LocationHistory) Add(point LocationPoint) {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	history.points = append(history.points, point)
+	if len(history.points) > maxLocationPoints {
+		history.points = history.points[len(history.points)-maxLocationPoints:]
+	}
+}
+
+// Points returns a copy of the points recorded at or after since
+func (history *LocationHistory) Points(since time.Time) []LocationPoint {
+	history.mutex.RLock()
+	defer history.mutex.RUnlock()
+
+	var points []LocationPoint
+	for _, point := range history.points {
+		if !point.Time.Before(since) {
+			points = append(points, deepcopy.Copy(point).(LocationPoint))
+		}
+	}
+	return points
+}
+
+// ApplyRetention drops the points older than purgeBefore and strips the cell level
+// location of the points older than coarsenBefore. A zero time disables that step.
+// It returns the number of points purged and coarsened.
+func (history *LocationHistory) ApplyRetention(coarsenBefore, purgeBefore time.Time) (int, int) {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	purged, coarsened := 0, 0
+	if !purgeBefore.IsZero() {
+		for purged < len(history.points) && history.points[purged].Time.Before(purgeBefore) {
+			purged++
+		}
+		history.points = history.points[purged:]
+	}
+	if !coarsenBefore.IsZero() {
+		for i := range history.points {
+			point := &history.points[i]
+			if !point.Time.Before(coarsenBefore) {
+				break
+			}
+			if !point.Coarsened {
+				point.Location = nil
+				point.Estimate = nil
+				point.Coarsened = true
+				coarsened++
+			}
+		}
+	}
+	return purged, coarsened
+}
+
+// Purge drops every point and returns how many were dropped
+func (history *LocationHistory) Purge() int {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	purged := len(history.points)
+	history.points = nil
+	return purged
+}
+
+// RecordLocation appends the current location of the UE to its history
+func (ue *EtafUe) RecordLocation(accessType models.AccessType, timestamp time.Time) {
+	location := deepcopy.Copy(ue.Location).(models.UserLocation)
+	ue.LocationHistory.Add(LocationPoint{
+		Time:       timestamp,
+		AccessType: accessType,
+		Tai:        ue.Tai,
+		Location:   &location,
+	})
+}
+
+// RecordLocationEstimate appends the estimate of a positioning of the UE to its
+// history, next to the current location
+func (ue *EtafUe) RecordLocationEstimate(accessType models.AccessType, estimate GeographicArea,
+	timestamp time.Time) {
+	location := deepcopy.Copy(ue.Location).(models.UserLocation)
+	ue.LocationHistory.Add(LocationPoint{
+		Time:       timestamp,
+		AccessType: accessType,
+		Tai:        ue.Tai,
+		Location:   &location,
+		Estimate:   &estimate,
+	})
+}
@@ -0,0 +1,160 @@
+package context
+
+import (
+	"fmt"
+	"free5gc/lib/openapi/models"
+	"sort"
+	"sync"
+)
+
+// Location reporting requested from the serving AMF of a UE, which controls the
+// location reporting of the NG-RAN (TS 23.502 4.10, TS 38.413 8.12.1)
+const (
+	LocationReportingDirect            = "DIRECT"
+	LocationReportingChangeOfServeCell = "CHANGE_OF_SERVE_CELL"
+	LocationReportingAreaOfInterest    = "AREA_OF_INTEREST"
+)
+
+// Presence of a UE in an area of interest, as last reported by the AMF
+const (
+	UePresenceIn      = "IN"
+	UePresenceOut     = "OUT"
+	UePresenceUnknown = "UNKNOWN"
+)
+
+// AreaOfInterest is an area the AMF reports the presence of a UE in
+type AreaOfInterest struct {
+	ReferenceId int64         `json:"referenceId"`
+	TaiList     []models.Tai  `json:"taiList,omitempty"`
+	NcgiList    []models.Ncgi `json:"ncgiList,omitempty"`
+	EcgiList    []models.Ecgi `json:"ecgiList,omitempty"`
+	Presence    string        `json:"presence,omitempty"`
+}
+
+// LocationReporting is the location reporting requested for a UE, each kept by
+// an AMF event subscription
+type LocationReporting struct {
+	mutex             sync.Mutex
+	changeOfServeCell *AmfSubscription
+	areasOfInterest   map[int64]*AreaOfInterest // reference ID as key
+	areaSubscriptions map[int64]AmfSubscription // reference ID as key
+}
+
+// AddAreaOfInterest allocates a reference ID to the area; the area is reported
+// on once SetAreaSubscription gave its AMF subscription
+func (reporting *LocationReporting) AddAreaOfInterest(area AreaOfInterest) (int64, error) {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	if reporting.areasOfInterest == nil {
+		reporting.areasOfInterest = make(map[int64]*AreaOfInterest)
+	}
+	// reference IDs range from 1 to maxnoofAoI, as the Location Reporting
+	// Reference IDs of the NG-RAN
+	for id := int64(1); id <= int64(MaxNumOfAOI); id++ {
+		if _, ok := reporting.areasOfInterest[id]; !ok {
+			area.ReferenceId = id
+			area.Presence = UePresenceUnknown
+			reporting.areasOfInterest[id] = &area
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no Location Reporting Reference ID left")
+}
+
+// SetAreaSubscription records the AMF subscription reporting on the area of
+// interest referenceId; it returns false if the area was removed meanwhile
+func (reporting *LocationReporting) SetAreaSubscription(referenceId int64, subscription AmfSubscription) bool {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	if _, ok := reporting.areasOfInterest[referenceId]; !ok {
+		return false
+	}
+	if reporting.areaSubscriptions == nil {
+		reporting.areaSubscriptions = make(map[int64]AmfSubscription)
+	}
+	reporting.areaSubscriptions[referenceId] = subscription
+	return true
+}
+
+// RemoveAreaOfInterest returns the AMF subscription of the area to be removed, and
+// false if there is no area with the reference ID
+func (reporting *LocationReporting) RemoveAreaOfInterest(referenceId int64) (*AmfSubscription, bool) {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	if _, ok := reporting.areasOfInterest[referenceId]; !ok {
+		return nil, false
+	}
+	delete(reporting.areasOfInterest, referenceId)
+	subscription, ok := reporting.areaSubscriptions[referenceId]
+	delete(reporting.areaSubscriptions, referenceId)
+	if !ok {
+		return nil, true
+	}
+	return &subscription, true
+}
+
+// SetPresence records the presence of the UE in an area of interest; it returns
+// false if the presence did not change
+func (reporting *LocationReporting) SetPresence(referenceId int64, presence string) bool {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	area, ok := reporting.areasOfInterest[referenceId]
+	if !ok || area.Presence == presence {
+		return false
+	}
+	area.Presence = presence
+	return true
+}
+
+// AreasOfInterest returns copies of the areas of interest ordered by reference ID
+func (reporting *LocationReporting) AreasOfInterest() []AreaOfInterest {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	areas := make([]AreaOfInterest, 0, len(reporting.areasOfInterest))
+	for _, area := range reporting.areasOfInterest {
+		areas = append(areas, *area)
+	}
+	sort.Slice(areas, func(i, j int) bool { return areas[i].ReferenceId < areas[j].ReferenceId })
+	return areas
+}
+
+// SetChangeOfServeCell records the AMF subscription reporting every change of
+// serving cell, or none; it returns the subscription it replaces
+func (reporting *LocationReporting) SetChangeOfServeCell(subscription *AmfSubscription) *AmfSubscription {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	previous := reporting.changeOfServeCell
+	reporting.changeOfServeCell = subscription
+	return previous
+}
+
+func (reporting *LocationReporting) ChangeOfServeCell() bool {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	return reporting.changeOfServeCell != nil
+}
+
+// Cancel clears the reporting and returns the AMF subscriptions to be removed
+func (reporting *LocationReporting) Cancel() []AmfSubscription {
+	reporting.mutex.Lock()
+	defer reporting.mutex.Unlock()
+
+	var subscriptions []AmfSubscription
+	if reporting.changeOfServeCell != nil {
+		subscriptions = append(subscriptions, *reporting.changeOfServeCell)
+	}
+	for _, subscription := range reporting.areaSubscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	reporting.changeOfServeCell = nil
+	reporting.areasOfInterest = nil
+	reporting.areaSubscriptions = nil
+	return subscriptions
+}
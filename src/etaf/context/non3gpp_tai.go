@@ -0,0 +1,43 @@
+package context
+
+import (
+	"free5gc/lib/openapi/models"
+	"strings"
+
+	"github.com/mohae/deepcopy"
+)
+
+// Non3gppTaiConfig sets the TAI of the non-3GPP accesses, which the N3IWF does not
+// report in the user location (TS 23.501 5.3.2.3): the TAI of the N3IWF, or the
+// default one
+type Non3gppTaiConfig struct {
+	Default *models.Tai
+	N3iwfs  []N3iwfTai
+}
+
+type N3iwfTai struct {
+	N3IwfId string // in hexadecimal, as in the Global RAN Node ID of NGAP
+	Tai     models.Tai
+}
+
+// N3gppTai returns a copy of the TAI of the UEs accessing through an N3IWF: the
+// TAI configured for the N3IWF, else the default TAI, else the TAI the N3IWF
+// supports when it supports a single one
+func (context *ETAFContext) N3gppTai(ran *EtafRan) (models.Tai, bool) {
+	if ran != nil && ran.RanPresent == RanPresentN3IwfId && ran.RanId != nil {
+		for _, n3iwfTai := range context.Non3gppTai.N3iwfs {
+			if strings.EqualFold(n3iwfTai.N3IwfId, ran.RanId.N3IwfId) {
+				return deepcopy.Copy(n3iwfTai.Tai).(models.Tai), true
+			}
+		}
+	}
+	if context.Non3gppTai.Default != nil {
+		return deepcopy.Copy(*context.Non3gppTai.Default).(models.Tai), true
+	}
+	if ran != nil {
+		if supportedTais := ran.SupportedTais(); len(supportedTais) == 1 {
+			return deepcopy.Copy(supportedTais[0].Tai).(models.Tai), true
+		}
+	}
+	return models.Tai{}, false
+}
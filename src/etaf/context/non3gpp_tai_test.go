@@ -0,0 +1,51 @@
+package context
+
+import (
+	"testing"
+
+	"free5gc/lib/openapi/models"
+)
+
+func TestN3gppTai(t *testing.T) {
+	self := ETAF_Self()
+	previous := self.Non3gppTai
+	defer func() { self.Non3gppTai = previous }()
+
+	self.Non3gppTai = Non3gppTaiConfig{
+		Default: &models.Tai{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, Tac: "000001"},
+		N3iwfs: []N3iwfTai{{
+			N3IwfId: "0000ab",
+			Tai:     models.Tai{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, Tac: "000002"},
+		}},
+	}
+	n3iwf := &EtafRan{RanPresent: RanPresentN3IwfId, RanId: &models.GlobalRanNodeId{N3IwfId: "0000AB"}}
+	otherN3iwf := &EtafRan{RanPresent: RanPresentN3IwfId, RanId: &models.GlobalRanNodeId{N3IwfId: "0000cd"}}
+
+	testCases := []struct {
+		name string
+		ran  *EtafRan
+		tac  string
+	}{
+		{name: "configured N3IWF", ran: n3iwf, tac: "000002"},
+		{name: "other N3IWF", ran: otherN3iwf, tac: "000001"},
+		{name: "unknown RAN", ran: nil, tac: "000001"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tai, ok := self.N3gppTai(testCase.ran)
+			if !ok || tai.Tac != testCase.tac {
+				t.Fatalf("TAI %+v, expected TAC %s", tai, testCase.tac)
+			}
+			// the TAI returned must not alias the configuration
+			tai.PlmnId.Mnc = "01"
+			if self.Non3gppTai.Default.PlmnId.Mnc != "93" || self.Non3gppTai.N3iwfs[0].Tai.PlmnId.Mnc != "93" {
+				t.Errorf("configured TAI modified through the TAI returned")
+			}
+		})
+	}
+
+	self.Non3gppTai = Non3gppTaiConfig{}
+	if tai, ok := self.N3gppTai(otherN3iwf); ok {
+		t.Errorf("TAI %+v found without configuration", tai)
+	}
+}
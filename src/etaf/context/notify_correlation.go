@@ -0,0 +1,28 @@
+package context
+
+// The AMF does not echo the correlation ID header of a subscription request on
+// its notifications, so the correlation ID of the request is kept under the
+// NotifyCorrelationId of the subscription, for the callbacks to log under it
+
+// SetNotifyCorrelation records the correlation ID of the request that created
+// the subscriptions notifying with notifyCorrelationId
+func (context *ETAFContext) SetNotifyCorrelation(notifyCorrelationId, correlationID string) {
+	if notifyCorrelationId == "" || correlationID == "" {
+		return
+	}
+	context.NotifyCorrelations.Store(notifyCorrelationId, correlationID)
+}
+
+// NotifyCorrelation returns the correlation ID recorded for notifyCorrelationId,
+// empty if there is none
+func (context *ETAFContext) NotifyCorrelation(notifyCorrelationId string) string {
+	if correlationID, ok := context.NotifyCorrelations.Load(notifyCorrelationId); ok {
+		return correlationID.(string)
+	}
+	return ""
+}
+
+// ForgetNotifyCorrelation drops the correlation ID of subscriptions removed
+func (context *ETAFContext) ForgetNotifyCorrelation(notifyCorrelationId string) {
+	context.NotifyCorrelations.Delete(notifyCorrelationId)
+}
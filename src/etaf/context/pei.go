@@ -0,0 +1,77 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PEI formats of the IMEI and IMEISV (TS 29.571 5.3.2)
+const (
+	PeiPrefixImei   = "imei-"
+	PeiPrefixImeisv = "imeisv-"
+)
+
+// IsImeiPei reports whether id is an IMEI or IMEISV PEI
+func IsImeiPei(id string) bool {
+	return strings.HasPrefix(id, PeiPrefixImei) || strings.HasPrefix(id, PeiPrefixImeisv)
+}
+
+// CanonicalPei returns the IMEI form of an IMEI or IMEISV PEI, so both identify
+// the same equipment: an IMEISV is reduced to its TAC and serial number followed
+// by their Luhn check digit (TS 23.003 6.2). The check digit of an IMEI is
+// verified. Other PEIs, such as MAC addresses, are returned unchanged.
+func CanonicalPei(pei string) (string, error) {
+	switch {
+	case strings.HasPrefix(pei, PeiPrefixImei):
+		digits := strings.TrimPrefix(pei, PeiPrefixImei)
+		if len(digits) != 15 || !isDigits(digits) {
+			return "", fmt.Errorf("IMEI[%s] must have 15 digits", pei)
+		}
+		if luhnCheckDigit(digits[:14]) != digits[14] {
+			return "", fmt.Errorf("IMEI[%s] has a wrong check digit", pei)
+		}
+		return pei, nil
+	case strings.HasPrefix(pei, PeiPrefixImeisv):
+		digits := strings.TrimPrefix(pei, PeiPrefixImeisv)
+		if len(digits) != 16 || !isDigits(digits) {
+			return "", fmt.Errorf("IMEISV[%s] must have 16 digits", pei)
+		}
+		return PeiPrefixImei + digits[:14] + string(luhnCheckDigit(digits[:14])), nil
+	}
+	return pei, nil
+}
+
+// luhnCheckDigit computes the check digit of the digits, doubling every second
+// digit from the rightmost one
+func luhnCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// peiKey is the key of the PEI index: the canonical PEI, or the PEI as given when
+// it is malformed
+func peiKey(pei string) string {
+	if key, err := CanonicalPei(pei); err == nil {
+		return key
+	}
+	return pei
+}
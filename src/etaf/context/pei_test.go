@@ -0,0 +1,54 @@
+package context
+
+import "testing"
+
+func TestLuhnCheckDigit(t *testing.T) {
+	testCases := []struct {
+		digits string
+		check  byte
+	}{
+		{"49015420323751", '8'},
+		{"35209900176148", '1'},
+		{"00000000000000", '0'},
+		{"7992739871", '3'},
+	}
+	for _, testCase := range testCases {
+		if check := luhnCheckDigit(testCase.digits); check != testCase.check {
+			t.Errorf("luhnCheckDigit(%s) = %c, expected %c", testCase.digits, check, testCase.check)
+		}
+	}
+}
+
+func TestCanonicalPei(t *testing.T) {
+	testCases := []struct {
+		name      string
+		pei       string
+		canonical string
+		err       bool
+	}{
+		{name: "imei", pei: "imei-490154203237518", canonical: "imei-490154203237518"},
+		{name: "imei wrong check digit", pei: "imei-490154203237519", err: true},
+		{name: "imei too short", pei: "imei-49015420323751", err: true},
+		{name: "imei not digits", pei: "imei-49015420323751a", err: true},
+		{name: "imeisv", pei: "imeisv-4901542032375101", canonical: "imei-490154203237518"},
+		{name: "imeisv too long", pei: "imeisv-49015420323751012", err: true},
+		{name: "imeisv not digits", pei: "imeisv-49015420323751x1", err: true},
+		{name: "mac address", pei: "mac-00-11-22-33-44-55", canonical: "mac-00-11-22-33-44-55"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			canonical, err := CanonicalPei(testCase.pei)
+			if testCase.err {
+				if err == nil {
+					t.Errorf("CanonicalPei(%s) = %s, expected an error", testCase.pei, canonical)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("CanonicalPei(%s) error: %+v", testCase.pei, err)
+			} else if canonical != testCase.canonical {
+				t.Errorf("CanonicalPei(%s) = %s, expected %s", testCase.pei, canonical, testCase.canonical)
+			}
+		})
+	}
+}
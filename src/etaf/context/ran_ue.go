@@ -7,6 +7,7 @@ import (
 	"free5gc/lib/ngap/ngapType"
 	"free5gc/lib/openapi/models"
 	"free5gc/src/etaf/logger"
+	"reflect"
 	"time"
 
 	"github.com/mohae/deepcopy"
@@ -76,12 +77,7 @@ func (ranUe *RanUe) Remove() error {
 		ranUe.DetachEtafUe()
 	}
 
-	for index, ranUe1 := range ran.RanUeList {
-		if ranUe1 == ranUe {
-			ran.RanUeList = append(ran.RanUeList[:index], ran.RanUeList[index+1:]...)
-			break
-		}
-	}
+	ran.removeRanUe(ranUe)
 	self := ETAF_Self()
 	self.RanUePool.Delete(ranUe.EtafUeNgapId)
 	return nil
@@ -104,15 +100,10 @@ func (ranUe *RanUe) SwitchToRan(newRan *EtafRan, ranUeNgapId int64) error {
 	oldRan := ranUe.Ran
 
 	// remove ranUe from oldRan
-	for index, ranUe1 := range oldRan.RanUeList {
-		if ranUe1 == ranUe {
-			oldRan.RanUeList = append(oldRan.RanUeList[:index], oldRan.RanUeList[index+1:]...)
-			break
-		}
-	}
+	oldRan.removeRanUe(ranUe)
 
 	// add ranUe to newRan
-	newRan.RanUeList = append(newRan.RanUeList, ranUe)
+	newRan.addRanUe(ranUe)
 
 	// switch to newRan
 	ranUe.Ran = newRan
@@ -163,11 +154,13 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 				locationInfoEUTRA.TimeStamp.Value)
 		}
 		if ranUe.EtafUe != nil {
-			if ranUe.EtafUe.Tai != ranUe.Tai {
+			if !reflect.DeepEqual(ranUe.EtafUe.Tai, ranUe.Tai) {
 				ranUe.EtafUe.LocationChanged = true
 			}
 			ranUe.EtafUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
 			ranUe.EtafUe.Tai = deepcopy.Copy(*ranUe.EtafUe.Location.EutraLocation.Tai).(models.Tai)
+			ranUe.EtafUe.UpdateRegistrationArea(ranUe.Ran.AnType)
+			ranUe.EtafUe.RecordLocation(ranUe.Ran.AnType, curTime)
 		}
 	case ngapType.UserLocationInformationPresentUserLocationInformationNR:
 		locationInfoNR := userLocationInformation.UserLocationInformationNR
@@ -200,11 +193,13 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 			ranUe.Location.NrLocation.AgeOfLocationInformation = ngapConvert.TimeStampToInt32(locationInfoNR.TimeStamp.Value)
 		}
 		if ranUe.EtafUe != nil {
-			if ranUe.EtafUe.Tai != ranUe.Tai {
+			if !reflect.DeepEqual(ranUe.EtafUe.Tai, ranUe.Tai) {
 				ranUe.EtafUe.LocationChanged = true
 			}
 			ranUe.EtafUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
 			ranUe.EtafUe.Tai = deepcopy.Copy(*ranUe.EtafUe.Location.NrLocation.Tai).(models.Tai)
+			ranUe.EtafUe.UpdateRegistrationArea(ranUe.Ran.AnType)
+			ranUe.EtafUe.RecordLocation(ranUe.Ran.AnType, curTime)
 		}
 	case ngapType.UserLocationInformationPresentUserLocationInformationN3IWF:
 		locationInfoN3IWF := userLocationInformation.UserLocationInformationN3IWF
@@ -221,16 +216,24 @@ func (ranUe *RanUe) UpdateLocation(userLocationInformation *ngapType.UserLocatio
 		ranUe.Location.N3gaLocation.UeIpv6Addr = ipv6Addr
 		ranUe.Location.N3gaLocation.PortNumber = ngapConvert.PortNumberToInt(port)
 		// N3GPP TAI is operator-specific
-		// TODO: define N3GPP TAI
-		ranUe.Location.N3gaLocation.N3gppTai = &models.Tai{
-			PlmnId: etafSelf.SupportTaiLists[0].PlmnId,
-			Tac:    etafSelf.SupportTaiLists[0].Tac,
+		if n3gppTai, ok := etafSelf.N3gppTai(ranUe.Ran); ok {
+			ranUe.Location.N3gaLocation.N3gppTai = &n3gppTai
+			ranUe.Tai = deepcopy.Copy(n3gppTai).(models.Tai)
+		} else {
+			logger.ContextLog.Warnf("RanUe[RanUeNgapID: %d] No non-3GPP TAI configured for Ran[Name: %s]",
+				ranUe.RanUeNgapId, ranUe.Ran.Name)
+			ranUe.Location.N3gaLocation.N3gppTai = nil
+			ranUe.Tai = models.Tai{}
 		}
-		ranUe.Tai = deepcopy.Copy(*ranUe.Location.N3gaLocation.N3gppTai).(models.Tai)
 
 		if ranUe.EtafUe != nil {
+			if !reflect.DeepEqual(ranUe.EtafUe.Tai, ranUe.Tai) {
+				ranUe.EtafUe.LocationChanged = true
+			}
 			ranUe.EtafUe.Location = deepcopy.Copy(ranUe.Location).(models.UserLocation)
-			ranUe.EtafUe.Tai = *ranUe.Location.N3gaLocation.N3gppTai
+			ranUe.EtafUe.Tai = deepcopy.Copy(ranUe.Tai).(models.Tai)
+			ranUe.EtafUe.UpdateRegistrationArea(ranUe.Ran.AnType)
+			ranUe.EtafUe.RecordLocation(ranUe.Ran.AnType, curTime)
 		}
 	case ngapType.UserLocationInformationPresentNothing:
 	}
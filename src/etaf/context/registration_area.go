@@ -0,0 +1,146 @@
+package context
+
+import (
+	"free5gc/lib/openapi/models"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// RegistrationAreaStrategy selects how the TAI list of a registration area is
+// chosen around the current TAI of the UE
+type RegistrationAreaStrategy string
+
+const (
+	// RegistrationAreaCurrentTai allocates the current TAI only
+	RegistrationAreaCurrentTai RegistrationAreaStrategy = "currentTai"
+	// RegistrationAreaNeighbours adds the configured neighbours of the current TAI
+	RegistrationAreaNeighbours RegistrationAreaStrategy = "neighbours"
+	// RegistrationAreaMobility adds the TAIs the UE was recently located in, the
+	// most visited first
+	RegistrationAreaMobility RegistrationAreaStrategy = "mobility"
+)
+
+const DefaultMobilityWindow int = 3600 // 1 hour
+
+type RegistrationAreaConfig struct {
+	Strategy   RegistrationAreaStrategy
+	Neighbours []TaiNeighbours
+	// Seconds of location history considered by the mobility strategy
+	MobilityWindow int
+	// Maximum number of TAIs of a registration area, at most MaxNumOfTAI
+	MaxListSize int
+}
+
+type TaiNeighbours struct {
+	Tai        models.Tai
+	Neighbours []models.Tai
+}
+
+// AllocateRegistrationArea allocates a new TAI list as the registration area of
+// the UE for the access type: its current TAI followed by the TAIs chosen by the
+// configured strategy, among the supported TAIs and up to the maximum list size
+func (context *ETAFContext) AllocateRegistrationArea(ue *EtafUe, anType models.AccessType) {
+	// clear the previous registration area if need
+	if len(ue.RegistrationArea[anType]) > 0 {
+		ue.RegistrationArea[anType] = nil
+	}
+	if !InTaiList(ue.Tai, context.SupportTaiLists) {
+		return
+	}
+
+	config := context.RegistrationAreaConfig
+	maxListSize := config.MaxListSize
+	if maxListSize <= 0 || maxListSize > MaxNumOfTAI {
+		maxListSize = MaxNumOfTAI
+	}
+
+	candidates := []models.Tai{ue.Tai}
+	switch config.Strategy {
+	case RegistrationAreaNeighbours:
+		for _, taiNeighbours := range config.Neighbours {
+			if reflect.DeepEqual(taiNeighbours.Tai, ue.Tai) {
+				candidates = append(candidates, taiNeighbours.Neighbours...)
+			}
+		}
+	case RegistrationAreaMobility:
+		window := config.MobilityWindow
+		if window <= 0 {
+			window = DefaultMobilityWindow
+		}
+		candidates = append(candidates,
+			ue.visitedTais(anType, time.Now().Add(-time.Duration(window)*time.Second))...)
+	}
+
+	for _, tai := range candidates {
+		if len(ue.RegistrationArea[anType]) == maxListSize {
+			break
+		}
+		if InTaiList(tai, context.SupportTaiLists) && !InTaiList(tai, ue.RegistrationArea[anType]) {
+			ue.RegistrationArea[anType] = append(ue.RegistrationArea[anType], tai)
+		}
+	}
+}
+
+// visitedTais returns the TAIs of the locations of the UE recorded since, the
+// most visited first and the most recently visited among equals
+func (ue *EtafUe) visitedTais(anType models.AccessType, since time.Time) []models.Tai {
+	type visit struct {
+		tai      models.Tai
+		count    int
+		lastSeen time.Time
+	}
+	var visits []*visit
+	for _, point := range ue.LocationHistory.Points(since) {
+		if point.AccessType != anType || point.Tai.Tac == "" {
+			continue
+		}
+		found := false
+		for _, visited := range visits {
+			if reflect.DeepEqual(visited.tai, point.Tai) {
+				visited.count++
+				visited.lastSeen = point.Time
+				found = true
+				break
+			}
+		}
+		if !found {
+			visits = append(visits, &visit{tai: point.Tai, count: 1, lastSeen: point.Time})
+		}
+	}
+	sort.SliceStable(visits, func(i, j int) bool {
+		if visits[i].count != visits[j].count {
+			return visits[i].count > visits[j].count
+		}
+		return visits[i].lastSeen.After(visits[j].lastSeen)
+	})
+
+	tais := make([]models.Tai, 0, len(visits))
+	for _, visited := range visits {
+		tais = append(tais, visited.tai)
+	}
+	return tais
+}
+
+// UpdateRegistrationArea allocates a new registration area to the UE once its
+// current TAI left the registration area of the access type, as the mobility
+// registration update of the UE would. It reports whether it did.
+func (ue *EtafUe) UpdateRegistrationArea(anType models.AccessType) bool {
+	if ue.Tai.Tac == "" || InTaiList(ue.Tai, ue.RegistrationArea[anType]) {
+		return false
+	}
+	ETAF_Self().AllocateRegistrationArea(ue, anType)
+	return true
+}
+
+// PagingArea returns the TAIs a UE in CM-IDLE may be in, and would be paged in:
+// its registration area, or its last TAI when it has none
+func (ue *EtafUe) PagingArea(anType models.AccessType) []models.Tai {
+	if area := ue.RegistrationArea[anType]; len(area) > 0 {
+		return append([]models.Tai(nil), area...)
+	}
+	if ue.Tai.Tac == "" {
+		return nil
+	}
+	return []models.Tai{ue.Tai}
+}
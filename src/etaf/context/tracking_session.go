@@ -0,0 +1,225 @@
+package context
+
+import (
+	"free5gc/lib/openapi/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrackingSession follows the location of a UE, or of the members of a group,
+// on behalf of a client and notifies the client at NotificationUri
+type TrackingSession struct {
+	Id              string
+	Supi            string // empty for the sessions of a group
+	GroupId         string // internal group identifier, if the group was given by identifier
+	Members         []GroupMember
+	Caller          string // identity of the client that created the session
+	Purpose         string
+	NotificationUri string
+	Filter          string // all, tai, cell or registrationArea, as for the location streams
+	CreatedAt       time.Time
+	Expiry          *time.Time
+	ExpiryTimer     *time.Timer
+	// High priority sessions, such as those following UEs in emergency services,
+	// are delivered ahead of the other notifications to the same URI
+	Priority bool
+	// Interval of the periodic reports of the last known location, on top of the
+	// location changes; 0 for none
+	ReportInterval time.Duration
+
+	mutex            sync.Mutex
+	deleted          bool
+	lastNotified     map[string]models.UserLocation // SUPI as key
+	delivery         DeliveryStatus
+	amfSubscriptions []AmfSubscription
+	reportTimer      *time.Timer
+}
+
+// GroupMember is a UE followed by a group session; members given by GPSI have
+// no SUPI until they are found in the UE pool
+type GroupMember struct {
+	Supi string
+	Gpsi string
+}
+
+type AmfSubscription struct {
+	AmfUri         string
+	SubscriptionId string
+}
+
+// DeliveryStatus counts the notifications of a tracking session
+type DeliveryStatus struct {
+	Queued         int        `json:"queued"`
+	Delivered      uint64     `json:"delivered"`
+	Deduplicated   uint64     `json:"deduplicated"` // identical to the previous location
+	Retries        uint64     `json:"retries"`
+	DeadLettered   uint64     `json:"deadLettered"` // given up after the last retry
+	LastAttempt    *time.Time `json:"lastAttempt,omitempty"`
+	LastDelivered  *time.Time `json:"lastDelivered,omitempty"`
+	LastHttpStatus int        `json:"lastHttpStatus,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+// IsGroup reports whether the session follows a group rather than a single UE
+func (session *TrackingSession) IsGroup() bool {
+	return session.Supi == ""
+}
+
+// Follows reports whether the session follows the UE identified by supi or gpsi
+func (session *TrackingSession) Follows(supi, gpsi string) bool {
+	if !session.IsGroup() {
+		return session.Supi == supi
+	}
+	for _, member := range session.Members {
+		if (member.Supi != "" && member.Supi == supi) || (member.Gpsi != "" && member.Gpsi == gpsi) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordNotified records location as the location notified last for the UE. It
+// returns false if it already was, in which case the notification should be
+// dropped; else it returns the location notified before, if any, for
+// RestoreNotified to undo the record when the notification cannot be queued.
+func (session *TrackingSession) RecordNotified(supi string,
+	location models.UserLocation) (previous *models.UserLocation, recorded bool) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	last, ok := session.lastNotified[supi]
+	if ok && CompareUserLocation(last, location) {
+		session.delivery.Deduplicated++
+		return nil, false
+	}
+	session.lastNotified[supi] = location
+	if ok {
+		previous = &last
+	}
+	return previous, true
+}
+
+// RestoreNotified undoes the record of location by RecordNotified, unless another
+// location was recorded since
+func (session *TrackingSession) RestoreNotified(supi string, location models.UserLocation,
+	previous *models.UserLocation) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if last, ok := session.lastNotified[supi]; !ok || !CompareUserLocation(last, location) {
+		return
+	}
+	if previous != nil {
+		session.lastNotified[supi] = *previous
+	} else {
+		delete(session.lastNotified, supi)
+	}
+}
+
+// SetAmfSubscriptions records the AMF event subscriptions made for the session. It
+// returns false if the session was deleted meanwhile; the subscriptions are then
+// left to the caller to remove.
+func (session *TrackingSession) SetAmfSubscriptions(subscriptions []AmfSubscription) bool {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if session.deleted {
+		return false
+	}
+	session.amfSubscriptions = subscriptions
+	return true
+}
+
+func (session *TrackingSession) AmfSubscriptions() []AmfSubscription {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.amfSubscriptions
+}
+
+// SetReportTimer records the timer of the next periodic report. It returns false
+// if the session was deleted meanwhile; the timer is then stopped.
+func (session *TrackingSession) SetReportTimer(timer *time.Timer) bool {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if session.deleted {
+		timer.Stop()
+		return false
+	}
+	session.reportTimer = timer
+	return true
+}
+
+// UpdateDelivery changes the delivery status under the session lock
+func (session *TrackingSession) UpdateDelivery(update func(status *DeliveryStatus)) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	update(&session.delivery)
+}
+
+func (session *TrackingSession) Delivery() DeliveryStatus {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.delivery
+}
+
+// NewTrackingSession allocates a session, for a group if supi is empty; it
+// receives notifications once added with AddTrackingSession. Session IDs are
+// never reused, so the records of a session, such as its dead letters, are never
+// mistaken for those of a later one.
+func (context *ETAFContext) NewTrackingSession(supi string) *TrackingSession {
+	return &TrackingSession{
+		Id:           uuid.New().String(),
+		Supi:         supi,
+		CreatedAt:    time.Now().UTC(),
+		lastNotified: make(map[string]models.UserLocation),
+	}
+}
+
+func (context *ETAFContext) AddTrackingSession(session *TrackingSession) {
+	context.TrackingSessionPool.Store(session.Id, session)
+}
+
+func (context *ETAFContext) TrackingSessionFindById(id string) (*TrackingSession, bool) {
+	if value, ok := context.TrackingSessionPool.Load(id); ok {
+		return value.(*TrackingSession), true
+	}
+	return nil, false
+}
+
+// TrackingSessionsByUe returns the tracking sessions following a UE, alone or
+// as a group member
+func (context *ETAFContext) TrackingSessionsByUe(supi, gpsi string) (sessions []*TrackingSession) {
+	context.TrackingSessionPool.Range(func(key, value interface{}) bool {
+		if session := value.(*TrackingSession); session.Follows(supi, gpsi) {
+			sessions = append(sessions, session)
+		}
+		return true
+	})
+	return
+}
+
+// DeleteTrackingSession returns false if the session was already deleted
+func (context *ETAFContext) DeleteTrackingSession(session *TrackingSession) bool {
+	session.mutex.Lock()
+	if session.deleted {
+		session.mutex.Unlock()
+		return false
+	}
+	session.deleted = true
+	if session.reportTimer != nil {
+		session.reportTimer.Stop()
+	}
+	session.mutex.Unlock()
+
+	if session.ExpiryTimer != nil {
+		session.ExpiryTimer.Stop()
+	}
+	context.TrackingSessionPool.Delete(session.Id)
+	return true
+}
@@ -0,0 +1,49 @@
+package context
+
+import (
+	"testing"
+
+	"free5gc/lib/openapi/models"
+)
+
+func nrLocation(cellId string) models.UserLocation {
+	return models.UserLocation{NrLocation: &models.NrLocation{
+		Tai:  &models.Tai{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, Tac: "000001"},
+		Ncgi: &models.Ncgi{PlmnId: &models.PlmnId{Mcc: "208", Mnc: "93"}, NrCellId: cellId},
+	}}
+}
+
+func TestRecordNotified(t *testing.T) {
+	const supi = "imsi-208930000000001"
+	session := ETAF_Self().NewTrackingSession(supi)
+
+	first, second := nrLocation("000000010"), nrLocation("000000020")
+	if previous, recorded := session.RecordNotified(supi, first); !recorded || previous != nil {
+		t.Errorf("first location: recorded %t, previous %+v", recorded, previous)
+	}
+	if _, recorded := session.RecordNotified(supi, first); recorded {
+		t.Errorf("same location recorded again")
+	}
+	previous, recorded := session.RecordNotified(supi, second)
+	if !recorded || previous == nil || !CompareUserLocation(*previous, first) {
+		t.Errorf("new location: recorded %t, previous %+v", recorded, previous)
+	}
+
+	// the notification of second could not be queued
+	session.RestoreNotified(supi, second, previous)
+	if _, recorded := session.RecordNotified(supi, first); recorded {
+		t.Errorf("restored location recorded again")
+	}
+	if _, recorded := session.RecordNotified(supi, second); !recorded {
+		t.Errorf("location not queued still deduplicated")
+	}
+	if delivery := session.Delivery(); delivery.Deduplicated != 2 {
+		t.Errorf("deduplicated %d, expected 2", delivery.Deduplicated)
+	}
+
+	// a restore does not undo a location recorded since
+	session.RestoreNotified(supi, first, nil)
+	if _, recorded := session.RecordNotified(supi, second); recorded {
+		t.Errorf("location recorded since was undone")
+	}
+}
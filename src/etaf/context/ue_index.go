@@ -0,0 +1,130 @@
+package context
+
+import "sync"
+
+// The secondary indexes of the UE pool map the GUTI, PEI, GPSI and policy
+// association ID of the pooled UEs to them. They are kept up to date as the UEs
+// are pooled, their identifiers assigned and the UEs removed; a UE not pooled,
+// such as one created without SUPI, is never indexed.
+
+// UeIdentifierWatcher is told when a pooled UE gains or loses id, its UE ID or
+// GPSI; ueId is the UE ID of the UE
+type UeIdentifierWatcher func(ueId, id string, added bool)
+
+var ueIdentifierWatchers []UeIdentifierWatcher
+var ueIdentifierWatchersMutex sync.RWMutex
+
+// WatchUeIdentifiers lets a package depending on this one keep its own index of
+// the identifiers of the pooled UEs
+func WatchUeIdentifiers(watcher UeIdentifierWatcher) {
+	ueIdentifierWatchersMutex.Lock()
+	defer ueIdentifierWatchersMutex.Unlock()
+
+	ueIdentifierWatchers = append(ueIdentifierWatchers, watcher)
+}
+
+func notifyUeIdentifier(ueId, id string, added bool) {
+	if id == "" {
+		return
+	}
+	ueIdentifierWatchersMutex.RLock()
+	defer ueIdentifierWatchersMutex.RUnlock()
+
+	for _, watcher := range ueIdentifierWatchers {
+		watcher(ueId, id, added)
+	}
+}
+
+func indexUe(index *sync.Map, key string, ue *EtafUe) {
+	if key != "" {
+		index.Store(key, ue)
+	}
+}
+
+// unindexUe drops the entry of key unless it was taken over by another UE
+func unindexUe(index *sync.Map, key string, ue *EtafUe) {
+	if key == "" {
+		return
+	}
+	if value, ok := index.Load(key); ok && value.(*EtafUe) == ue {
+		index.Delete(key)
+	}
+}
+
+func findIndexedUe(index *sync.Map, key string) (*EtafUe, bool) {
+	if value, ok := index.Load(key); ok {
+		return value.(*EtafUe), true
+	}
+	return nil, false
+}
+
+// indexUeIdentifiers adds the identifiers of a UE to the indexes
+func (context *ETAFContext) indexUeIdentifiers(ue *EtafUe) {
+	indexUe(&context.GutiIndex, ue.Guti, ue)
+	if ue.Pei != "" {
+		indexUe(&context.PeiIndex, peiKey(ue.Pei), ue)
+	}
+	indexUe(&context.GpsiIndex, ue.Gpsi, ue)
+	indexUe(&context.PolicyAssociationIndex, ue.PolicyAssociationId, ue)
+	notifyUeIdentifier(ue.UeId(), ue.UeId(), true)
+	notifyUeIdentifier(ue.UeId(), ue.Gpsi, true)
+}
+
+// unindexUeIdentifiers removes the identifiers of a UE from the indexes
+func (context *ETAFContext) unindexUeIdentifiers(ue *EtafUe) {
+	unindexUe(&context.GutiIndex, ue.Guti, ue)
+	if ue.Pei != "" {
+		unindexUe(&context.PeiIndex, peiKey(ue.Pei), ue)
+	}
+	unindexUe(&context.GpsiIndex, ue.Gpsi, ue)
+	unindexUe(&context.PolicyAssociationIndex, ue.PolicyAssociationId, ue)
+	notifyUeIdentifier(ue.UeId(), ue.UeId(), false)
+	notifyUeIdentifier(ue.UeId(), ue.Gpsi, false)
+}
+
+// SetGuti records the GUTI allocated to the UE and indexes the UE by it
+func (ue *EtafUe) SetGuti(guti string) {
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
+	ue.reindexLocked(&ETAF_Self().GutiIndex, ue.Guti, guti)
+	ue.Guti = guti
+}
+
+// SetPei records the PEI of the UE and indexes the UE by it
+func (ue *EtafUe) SetPei(pei string) {
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
+	ue.reindexLocked(&ETAF_Self().PeiIndex, peiKey(ue.Pei), peiKey(pei))
+	ue.Pei = pei
+}
+
+// SetGpsi records the GPSI of the UE and indexes the UE by it
+func (ue *EtafUe) SetGpsi(gpsi string) {
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
+	ue.reindexLocked(&ETAF_Self().GpsiIndex, ue.Gpsi, gpsi)
+	if ue.pooled && ue.Gpsi != gpsi {
+		notifyUeIdentifier(ue.UeId(), ue.Gpsi, false)
+		notifyUeIdentifier(ue.UeId(), gpsi, true)
+	}
+	ue.Gpsi = gpsi
+}
+
+// SetPolicyAssociationId records the AM policy association of the UE and indexes
+// the UE by it
+func (ue *EtafUe) SetPolicyAssociationId(polAssoId string) {
+	ue.indexMutex.Lock()
+	defer ue.indexMutex.Unlock()
+	ue.reindexLocked(&ETAF_Self().PolicyAssociationIndex, ue.PolicyAssociationId, polAssoId)
+	ue.PolicyAssociationId = polAssoId
+}
+
+// reindexLocked moves the UE from oldKey to newKey of index. A UE not pooled
+// yet is left out; it is indexed once pooled.
+func (ue *EtafUe) reindexLocked(index *sync.Map, oldKey, newKey string) {
+	if !ue.pooled {
+		return
+	}
+	unindexUe(index, oldKey, ue)
+	indexUe(index, newKey, ue)
+}
@@ -0,0 +1,164 @@
+package context
+
+import (
+	"fmt"
+	"testing"
+)
+
+// populateUePool pools n UEs with a GUTI, PEI, GPSI and policy association each,
+// and returns them with the function removing them again
+func populateUePool(n int) ([]*EtafUe, func()) {
+	self := ETAF_Self()
+	ues := make([]*EtafUe, n)
+	for i := range ues {
+		ue := &EtafUe{}
+		ue.init()
+		ue.Guti = fmt.Sprintf("20893cafe%08x", i)
+		imei := fmt.Sprintf("35%012d", i)
+		ue.Pei = PeiPrefixImei + imei + string(luhnCheckDigit(imei))
+		ue.Gpsi = fmt.Sprintf("msisdn-0900%06d", i)
+		ue.PolicyAssociationId = fmt.Sprintf("imsi-20893%010d-%d", i, i)
+		self.AddEtafUeToUePool(ue, fmt.Sprintf("imsi-20893%010d", i))
+		ues[i] = ue
+	}
+	return ues, func() {
+		for _, ue := range ues {
+			self.UePool.Delete(ue.Supi)
+			self.unindexUeIdentifiers(ue)
+		}
+	}
+}
+
+// scanUePool finds a UE the way the lookups did before the indexes: by ranging
+// over the whole pool
+func scanUePool(match func(ue *EtafUe) bool) (ue *EtafUe, ok bool) {
+	ETAF_Self().UePool.Range(func(key, value interface{}) bool {
+		candidate := value.(*EtafUe)
+		if ok = match(candidate); ok {
+			ue = candidate
+			return false
+		}
+		return true
+	})
+	return
+}
+
+func BenchmarkEtafUeFind(b *testing.B) {
+	self := ETAF_Self()
+	for _, size := range []int{100, 1000, 10000, 100000} {
+		ues, cleanup := populateUePool(size)
+
+		lookups := []struct {
+			name  string
+			index func(ue *EtafUe) (*EtafUe, bool)
+			scan  func(ue *EtafUe) (*EtafUe, bool)
+		}{
+			{
+				name:  "Guti",
+				index: func(ue *EtafUe) (*EtafUe, bool) { return self.EtafUeFindByGuti(ue.Guti) },
+				scan: func(ue *EtafUe) (*EtafUe, bool) {
+					return scanUePool(func(candidate *EtafUe) bool { return candidate.Guti == ue.Guti })
+				},
+			},
+			{
+				name:  "Pei",
+				index: func(ue *EtafUe) (*EtafUe, bool) { return self.EtafUeFindByPei(ue.Pei) },
+				scan: func(ue *EtafUe) (*EtafUe, bool) {
+					return scanUePool(func(candidate *EtafUe) bool { return candidate.Pei == ue.Pei })
+				},
+			},
+			{
+				name:  "Gpsi",
+				index: func(ue *EtafUe) (*EtafUe, bool) { return self.EtafUeFindByGpsi(ue.Gpsi) },
+				scan: func(ue *EtafUe) (*EtafUe, bool) {
+					return scanUePool(func(candidate *EtafUe) bool { return candidate.Gpsi == ue.Gpsi })
+				},
+			},
+			{
+				name: "PolicyAssociationID",
+				index: func(ue *EtafUe) (*EtafUe, bool) {
+					return self.EtafUeFindByPolicyAssociationID(ue.PolicyAssociationId)
+				},
+				scan: func(ue *EtafUe) (*EtafUe, bool) {
+					return scanUePool(func(candidate *EtafUe) bool {
+						return candidate.PolicyAssociationId == ue.PolicyAssociationId
+					})
+				},
+			},
+		}
+
+		for _, lookup := range lookups {
+			for _, method := range []struct {
+				name string
+				find func(ue *EtafUe) (*EtafUe, bool)
+			}{{"Index", lookup.index}, {"Scan", lookup.scan}} {
+				find := method.find
+				b.Run(fmt.Sprintf("%s/%s/%d", lookup.name, method.name, size), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						ue := ues[i%len(ues)]
+						if found, ok := find(ue); !ok || found != ue {
+							b.Fatalf("UE[%s] not found", ue.Supi)
+						}
+					}
+				})
+			}
+		}
+
+		cleanup()
+	}
+}
+
+func TestEtafUeIndexes(t *testing.T) {
+	self := ETAF_Self()
+	ues, cleanup := populateUePool(2)
+	defer cleanup()
+	ue := ues[0]
+
+	ue.SetGuti("20893cafe0000ffff")
+	if _, ok := self.EtafUeFindByGuti(fmt.Sprintf("20893cafe%08x", 0)); ok {
+		t.Errorf("UE found by its previous GUTI")
+	}
+	if found, ok := self.EtafUeFindByGuti(ue.Guti); !ok || found != ue {
+		t.Errorf("UE not found by its reallocated GUTI")
+	}
+
+	imeisv := "imeisv-" + ue.Pei[len(PeiPrefixImei):len(ue.Pei)-1] + "01"
+	if found, ok := self.EtafUeFindByPei(imeisv); !ok || found != ue {
+		t.Errorf("UE not found by its IMEISV")
+	}
+
+	gpsi, polAssoId := ue.Gpsi, ue.PolicyAssociationId
+	ue.Remove()
+	if _, ok := self.EtafUeFindByGpsi(gpsi); ok {
+		t.Errorf("removed UE found by its GPSI")
+	}
+	if _, ok := self.EtafUeFindByPolicyAssociationID(polAssoId); ok {
+		t.Errorf("removed UE found by its policy association")
+	}
+	if found, ok := self.EtafUeFindByGpsi(ues[1].Gpsi); !ok || found != ues[1] {
+		t.Errorf("UE not found by its GPSI")
+	}
+}
+
+func TestEtafUeIndexesUnpooled(t *testing.T) {
+	self := ETAF_Self()
+	ue := self.NewEtafUe("")
+	ue.SetGuti("20893cafe0000fffe")
+	ue.SetGpsi("msisdn-0900999999")
+	ue.SetPolicyAssociationId("imsi-208930000999999-1")
+	if _, ok := self.EtafUeFindByGuti(ue.Guti); ok {
+		t.Errorf("UE not pooled found by its GUTI")
+	}
+	if _, ok := self.EtafUeFindByGpsi(ue.Gpsi); ok {
+		t.Errorf("UE not pooled found by its GPSI")
+	}
+
+	self.AddEtafUeToUePool(ue, "imsi-208930000999999")
+	defer ue.Remove()
+	if found, ok := self.EtafUeFindByGuti(ue.Guti); !ok || found != ue {
+		t.Errorf("pooled UE not found by its GUTI")
+	}
+	if found, ok := self.EtafUeFindByPolicyAssociationID(ue.PolicyAssociationId); !ok || found != ue {
+		t.Errorf("pooled UE not found by its policy association")
+	}
+}
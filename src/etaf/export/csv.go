@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var trackHeader = []string{"ueId", "time", "lat", "lon", "uncertainty", "source", "mcc", "mnc", "tac", "cellId",
+	"emergency"}
+
+func tracksCSV(tracks []Track) ([]byte, error) {
+	records := [][]string{trackHeader}
+	for _, track := range tracks {
+		for _, fix := range track.Fixes {
+			records = append(records, []string{
+				track.UeId,
+				fix.Time.Format(time.RFC3339),
+				strconv.FormatFloat(fix.Point.Lat, 'f', -1, 64),
+				strconv.FormatFloat(fix.Point.Lon, 'f', -1, 64),
+				formatUncertainty(fix.Uncertainty),
+				fix.Source,
+				plmnMcc(fix),
+				plmnMnc(fix),
+				fix.Tai.Tac,
+				fix.CellId,
+				strconv.FormatBool(track.Emergency),
+			})
+		}
+	}
+	return writeCSV(records)
+}
+
+// areasCSV writes one row per area with its center, the properties last
+func areasCSV(properties map[string]string, areas []Area) ([]byte, error) {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := [][]string{append([]string{"name", "shape", "lat", "lon", "uncertainty"}, keys...)}
+	for _, area := range areas {
+		center, ok := area.Area.Center()
+		if !ok {
+			continue
+		}
+		uncertainty := area.Area.Uncertainty
+		if area.Area.UncertaintyEllipse != nil {
+			uncertainty = area.Area.UncertaintyEllipse.SemiMajor
+		}
+		record := []string{
+			area.Name,
+			area.Area.Shape,
+			strconv.FormatFloat(center.Lat, 'f', -1, 64),
+			strconv.FormatFloat(center.Lon, 'f', -1, 64),
+			formatUncertainty(uncertainty),
+		}
+		for _, key := range keys {
+			record = append(record, properties[key])
+		}
+		records = append(records, record)
+	}
+	return writeCSV(records)
+}
+
+func formatUncertainty(uncertainty float32) string {
+	if uncertainty <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(uncertainty), 'f', -1, 32)
+}
+
+func writeCSV(records [][]string) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := csv.NewWriter(&buffer).WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
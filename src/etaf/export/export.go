@@ -0,0 +1,151 @@
+package export
+
+import (
+	"fmt"
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"math"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Export formats, as given by the format query parameter
+const (
+	FormatGeoJSON = "geojson"
+	FormatKML     = "kml"
+	FormatCSV     = "csv"
+)
+
+var contentTypes = map[string]string{
+	FormatGeoJSON: "application/geo+json",
+	FormatKML:     "application/vnd.google-earth.kml+xml",
+	FormatCSV:     "text/csv",
+}
+
+// circleVertices approximates circles and ellipses by polygons
+const circleVertices = 36
+
+// Fix is one location of a UE with coordinates
+type Fix struct {
+	Time        time.Time
+	Tai         models.Tai
+	CellId      string // NR or E-UTRA cell identity, empty for N3IWF locations
+	Point       etaf_context.GeographicalCoordinates
+	Uncertainty float32 // meters, 0 when unknown
+	Source      string  // how the point was estimated, by the LMF or from the serving cell
+}
+
+// Track is the location history of a UE, oldest fix first
+type Track struct {
+	UeId      string
+	Emergency bool // the UE is in emergency services
+	Fixes     []Fix
+}
+
+// Area is a named geographic area, such as a cell of a warning area
+type Area struct {
+	Name string
+	Area etaf_context.GeographicArea
+}
+
+// NegotiateFormat selects the format from the format query parameter or, when
+// absent, from the Accept header, GeoJSON being the default
+func NegotiateFormat(format, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		if _, ok := contentTypes[format]; !ok {
+			return "", fmt.Errorf("format must be %s, %s or %s", FormatGeoJSON, FormatKML, FormatCSV)
+		}
+		return format, nil
+	}
+	if accept == "" {
+		return FormatGeoJSON, nil
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "*/*", "application/*", "application/json", contentTypes[FormatGeoJSON]:
+			return FormatGeoJSON, nil
+		case contentTypes[FormatKML], "application/xml", "text/xml":
+			return FormatKML, nil
+		case contentTypes[FormatCSV], "text/*":
+			return FormatCSV, nil
+		}
+	}
+	return "", fmt.Errorf("none of the media types accepted is supported")
+}
+
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// RenderTracks renders the tracks as a GeoJSON FeatureCollection of points and
+// line strings, as KML placemarks and line strings, or as CSV with one row per fix
+func RenderTracks(format string, tracks []Track) ([]byte, error) {
+	switch format {
+	case FormatGeoJSON:
+		return tracksGeoJSON(tracks)
+	case FormatKML:
+		return tracksKML(tracks)
+	case FormatCSV:
+		return tracksCSV(tracks)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// RenderAreas renders the areas as polygons, or as points for the point shapes;
+// properties are attached to every feature, and to every row in CSV
+func RenderAreas(format, name string, properties map[string]string, areas []Area) ([]byte, error) {
+	switch format {
+	case FormatGeoJSON:
+		return areasGeoJSON(properties, areas)
+	case FormatKML:
+		return areasKML(name, properties, areas)
+	case FormatCSV:
+		return areasCSV(properties, areas)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// outline returns the closed ring of vertices of an area, or nil for a point
+func outline(area etaf_context.GeographicArea) []etaf_context.GeographicalCoordinates {
+	var ring []etaf_context.GeographicalCoordinates
+	if area.Shape != etaf_context.GADShapePolygon && area.Point == nil {
+		return nil
+	}
+	switch area.Shape {
+	case etaf_context.GADShapePolygon:
+		ring = append(ring, area.PointList...)
+	case etaf_context.GADShapePointUncertaintyCircle:
+		for i := 0; i < circleVertices; i++ {
+			azimuth := float64(i) * 360 / circleVertices
+			ring = append(ring, etaf_context.Offset(*area.Point, azimuth, float64(area.Uncertainty)))
+		}
+	case etaf_context.GADShapePointUncertaintyEllipse:
+		if area.UncertaintyEllipse == nil {
+			return nil
+		}
+		semiMajor := float64(area.UncertaintyEllipse.SemiMajor)
+		semiMinor := float64(area.UncertaintyEllipse.SemiMinor)
+		for i := 0; i < circleVertices; i++ {
+			azimuth := float64(i) * 360 / circleVertices
+			// angle from the major axis, oriented clockwise from north
+			theta := (azimuth - float64(area.UncertaintyEllipse.OrientationMajor)) * math.Pi / 180
+			radius := semiMajor * semiMinor /
+				math.Hypot(semiMinor*math.Cos(theta), semiMajor*math.Sin(theta))
+			ring = append(ring, etaf_context.Offset(*area.Point, azimuth, radius))
+		}
+	default:
+		return nil
+	}
+	if len(ring) == 0 {
+		return nil
+	}
+	return append(ring, ring[0])
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"encoding/json"
+	etaf_context "free5gc/src/etaf/context"
+	"time"
+)
+
+// GeoJSON objects of RFC 7946; positions are longitude first
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string                 `json:"type"`
+	Geometry   geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func position(point etaf_context.GeographicalCoordinates) []float64 {
+	return []float64{point.Lon, point.Lat}
+}
+
+func tracksGeoJSON(tracks []Track) ([]byte, error) {
+	collection := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, track := range tracks {
+		var line [][]float64
+		for _, fix := range track.Fixes {
+			properties := map[string]interface{}{
+				"ueId":      track.UeId,
+				"emergency": track.Emergency,
+				"time":      fix.Time.Format(time.RFC3339),
+				"mcc":       plmnMcc(fix),
+				"mnc":       plmnMnc(fix),
+				"tac":       fix.Tai.Tac,
+				"source":    fix.Source,
+			}
+			if fix.CellId != "" {
+				properties["cellId"] = fix.CellId
+			}
+			if fix.Uncertainty > 0 {
+				properties["uncertainty"] = fix.Uncertainty
+			}
+			collection.Features = append(collection.Features, feature{
+				Type:       "Feature",
+				Geometry:   geometry{Type: "Point", Coordinates: position(fix.Point)},
+				Properties: properties,
+			})
+			line = append(line, position(fix.Point))
+		}
+		if len(line) > 1 {
+			collection.Features = append(collection.Features, feature{
+				Type:     "Feature",
+				Geometry: geometry{Type: "LineString", Coordinates: line},
+				Properties: map[string]interface{}{
+					"ueId":      track.UeId,
+					"emergency": track.Emergency,
+					"start":     track.Fixes[0].Time.Format(time.RFC3339),
+					"end":       track.Fixes[len(track.Fixes)-1].Time.Format(time.RFC3339),
+				},
+			})
+		}
+	}
+	return json.Marshal(collection)
+}
+
+func areasGeoJSON(properties map[string]string, areas []Area) ([]byte, error) {
+	collection := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, area := range areas {
+		featureProperties := map[string]interface{}{"name": area.Name, "shape": area.Area.Shape}
+		for key, value := range properties {
+			featureProperties[key] = value
+		}
+		var areaGeometry geometry
+		if ring := outline(area.Area); ring != nil {
+			var coordinates [][]float64
+			for _, point := range ring {
+				coordinates = append(coordinates, position(point))
+			}
+			areaGeometry = geometry{Type: "Polygon", Coordinates: [][][]float64{coordinates}}
+		} else if center, ok := area.Area.Center(); ok {
+			areaGeometry = geometry{Type: "Point", Coordinates: position(center)}
+		} else {
+			continue
+		}
+		collection.Features = append(collection.Features, feature{
+			Type:       "Feature",
+			Geometry:   areaGeometry,
+			Properties: featureProperties,
+		})
+	}
+	return json.Marshal(collection)
+}
+
+func plmnMcc(fix Fix) string {
+	if fix.Tai.PlmnId == nil {
+		return ""
+	}
+	return fix.Tai.PlmnId.Mcc
+}
+
+func plmnMnc(fix Fix) string {
+	if fix.Tai.PlmnId == nil {
+		return ""
+	}
+	return fix.Tai.PlmnId.Mnc
+}
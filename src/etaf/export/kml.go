@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	etaf_context "free5gc/src/etaf/context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kmlDocument struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Name     string      `xml:"Document>name,omitempty"`
+	Folders  []kmlFolder `xml:"Document>Folder,omitempty"`
+	Elements []placemark `xml:"Document>Placemark,omitempty"`
+}
+
+type kmlFolder struct {
+	Name       string      `xml:"name"`
+	Placemarks []placemark `xml:"Placemark"`
+}
+
+type placemark struct {
+	Name         string      `xml:"name,omitempty"`
+	TimeStamp    string      `xml:"TimeStamp>when,omitempty"`
+	ExtendedData []kmlData   `xml:"ExtendedData>Data,omitempty"`
+	Point        *kmlPoint   `xml:"Point,omitempty"`
+	LineString   *kmlPoint   `xml:"LineString,omitempty"`
+	Polygon      *kmlPolygon `xml:"Polygon,omitempty"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Coordinates string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+// kmlCoordinates formats points as KML tuples, longitude first
+func kmlCoordinates(points ...etaf_context.GeographicalCoordinates) string {
+	tuples := make([]string, 0, len(points))
+	for _, point := range points {
+		tuples = append(tuples, strconv.FormatFloat(point.Lon, 'f', -1, 64)+","+
+			strconv.FormatFloat(point.Lat, 'f', -1, 64))
+	}
+	return strings.Join(tuples, " ")
+}
+
+func tracksKML(tracks []Track) ([]byte, error) {
+	document := kmlDocument{Xmlns: kmlNamespace, Name: "tracks"}
+	for _, track := range tracks {
+		folder := kmlFolder{Name: track.UeId}
+		var line []etaf_context.GeographicalCoordinates
+		for _, fix := range track.Fixes {
+			data := []kmlData{
+				{Name: "mcc", Value: plmnMcc(fix)},
+				{Name: "mnc", Value: plmnMnc(fix)},
+				{Name: "tac", Value: fix.Tai.Tac},
+				{Name: "source", Value: fix.Source},
+				{Name: "emergency", Value: strconv.FormatBool(track.Emergency)},
+			}
+			if fix.CellId != "" {
+				data = append(data, kmlData{Name: "cellId", Value: fix.CellId})
+			}
+			if fix.Uncertainty > 0 {
+				data = append(data, kmlData{Name: "uncertainty",
+					Value: strconv.FormatFloat(float64(fix.Uncertainty), 'f', -1, 32)})
+			}
+			folder.Placemarks = append(folder.Placemarks, placemark{
+				Name:         fix.Time.Format(time.RFC3339),
+				TimeStamp:    fix.Time.Format(time.RFC3339),
+				ExtendedData: data,
+				Point:        &kmlPoint{Coordinates: kmlCoordinates(fix.Point)},
+			})
+			line = append(line, fix.Point)
+		}
+		if len(line) > 1 {
+			folder.Placemarks = append(folder.Placemarks, placemark{
+				Name:       track.UeId,
+				LineString: &kmlPoint{Coordinates: kmlCoordinates(line...)},
+			})
+		}
+		document.Folders = append(document.Folders, folder)
+	}
+	return marshalKML(document)
+}
+
+func areasKML(name string, properties map[string]string, areas []Area) ([]byte, error) {
+	document := kmlDocument{Xmlns: kmlNamespace, Name: name}
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, area := range areas {
+		element := placemark{Name: area.Name, ExtendedData: []kmlData{{Name: "shape", Value: area.Area.Shape}}}
+		for _, key := range keys {
+			element.ExtendedData = append(element.ExtendedData, kmlData{Name: key, Value: properties[key]})
+		}
+		if ring := outline(area.Area); ring != nil {
+			element.Polygon = &kmlPolygon{Coordinates: kmlCoordinates(ring...)}
+		} else if center, ok := area.Area.Center(); ok {
+			element.Point = &kmlPoint{Coordinates: kmlCoordinates(center)}
+		} else {
+			continue
+		}
+		document.Elements = append(document.Elements, element)
+	}
+	return marshalKML(document)
+}
+
+func marshalKML(document kmlDocument) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buffer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -31,6 +31,8 @@ type Configuration struct {
 
 	NgapIpList []string `yaml:"ngapIpList,omitempty"`
 
+	NgapPort int `yaml:"ngapPort,omitempty"`
+
 	Sbi *Sbi `yaml:"sbi,omitempty"`
 
 	ServiceNameList []string `yaml:"serviceNameList,omitempty"`
@@ -54,6 +56,36 @@ type Configuration struct {
 	T3512 int `yaml:"t3512,omitempty"`
 
 	Non3gppDeregistrationTimer int `yaml:"mon3gppDeregistrationTimer,omitempty"`
+
+	// Seconds a freed TMSI is quarantined before it is reallocated; 0 or less
+	// reallocates it at once. Defaults to 60 when not set.
+	TmsiGuardPeriod *int `yaml:"tmsiGuardPeriod,omitempty"`
+
+	RegistrationArea *RegistrationArea `yaml:"registrationArea,omitempty"`
+
+	Non3gppTai *Non3gppTai `yaml:"non3gppTai,omitempty"`
+
+	LogFormat string `yaml:"logFormat,omitempty"` // text (default) or json
+
+	Tracing *Tracing `yaml:"tracing,omitempty"`
+
+	Authentication *Authentication `yaml:"authentication,omitempty"`
+
+	Audit *Audit `yaml:"audit,omitempty"`
+
+	Privacy *Privacy `yaml:"privacy,omitempty"`
+
+	Geofences []Geofence `yaml:"geofences,omitempty"`
+
+	// Hosts the notification URIs of the tracking sessions may point to: host,
+	// host:port or *.domain; no tracking session is accepted when empty
+	NotificationHosts []string `yaml:"notificationHosts,omitempty"`
+
+	Lmf *Lmf `yaml:"lmf,omitempty"`
+
+	CellDatabase *CellDatabase `yaml:"cellDatabase,omitempty"`
+
+	Emergency *Emergency `yaml:"emergency,omitempty"`
 }
 
 type Sbi struct {
@@ -64,6 +96,131 @@ type Sbi struct {
 	Port        int    `yaml:"port,omitempty"`
 }
 
+type Tracing struct {
+	Enable      bool   `yaml:"enable"`
+	Exporter    string `yaml:"exporter,omitempty"`    // otlp (default) or file
+	OtlpAddress string `yaml:"otlpAddress,omitempty"` // host:port of the OTLP collector
+	FilePath    string `yaml:"filePath,omitempty"`    // spans are appended to this file by the file exporter
+}
+
+// Authentication sets how the callers named in the audit trail and privacy
+// controls are authenticated; unauthenticated callers are named by their address
+type Authentication struct {
+	TokenVerificationKeyPath string   `yaml:"tokenVerificationKeyPath,omitempty"` // PEM public key or certificate of the access token issuer (NRF)
+	TokenIssuer              string   `yaml:"tokenIssuer,omitempty"`              // iss the access tokens must carry, required with a verification key
+	TokenAudience            string   `yaml:"tokenAudience,omitempty"`            // aud the access tokens must include, required with a verification key
+	ClientCaPath             string   `yaml:"clientCaPath,omitempty"`             // PEM CA certificates of the TLS client certificates
+	Operators                []string `yaml:"operators,omitempty"`                // authenticated callers allowed to read the audit trail and change the ETAF through OAM
+}
+
+type Audit struct {
+	FilePath       string `yaml:"filePath,omitempty"`       // audit entries are also appended to this file when set
+	SigningKeyPath string `yaml:"signingKeyPath,omitempty"` // HMAC key signing each line of the audit file
+}
+
+type Privacy struct {
+	PseudonymKeyPath      string          `yaml:"pseudonymKeyPath,omitempty"` // HMAC key deriving the pseudonyms
+	PseudonymiseByDefault bool            `yaml:"pseudonymiseByDefault,omitempty"`
+	Clients               []PrivacyClient `yaml:"clients,omitempty"`
+	Retention             *Retention      `yaml:"retention,omitempty"`
+}
+
+type PrivacyClient struct {
+	Caller       string `yaml:"caller"` // authenticated identity as recorded in the audit log
+	Pseudonymise bool   `yaml:"pseudonymise"`
+}
+
+type Retention struct {
+	CoarsenAfter  int `yaml:"coarsenAfter,omitempty"`  // seconds after which location points keep only their TAI
+	PurgeAfter    int `yaml:"purgeAfter,omitempty"`    // seconds after which location points are dropped
+	CheckInterval int `yaml:"checkInterval,omitempty"` // seconds, default 60
+}
+
+// Geofence names an area made of TAIs, cells and a geographic area for the area
+// queries; UEs are placed in the geographic area by their LMF fix or serving cell
+type Geofence struct {
+	Id       string                  `yaml:"id"`
+	TaiList  []models.Tai            `yaml:"taiList,omitempty"`
+	NcgiList []models.Ncgi           `yaml:"ncgiList,omitempty"`
+	EcgiList []models.Ecgi           `yaml:"ecgiList,omitempty"`
+	Area     *context.GeographicArea `yaml:"area,omitempty"`
+}
+
+type Lmf struct {
+	Uri                string      `yaml:"uri,omitempty"`                // LMF to use instead of discovering one through the NRF
+	HorizontalAccuracy float32     `yaml:"horizontalAccuracy,omitempty"` // meters, default QoS of the positioning requests
+	ResponseTime       string      `yaml:"responseTime,omitempty"`       // NO_DELAY, LOW_DELAY (default) or DELAY_TOLERANT
+	StandIn            *LmfStandIn `yaml:"standIn,omitempty"`
+}
+
+// LmfStandIn serves Nlmf_Location from the ETAF itself, positioning UEs at the
+// configured cell sites, for testing without an LMF
+type LmfStandIn struct {
+	Enable bool          `yaml:"enable"`
+	Cells  []StandInCell `yaml:"cells,omitempty"`
+}
+
+// StandInCell locates one NR or E-UTRA cell
+type StandInCell struct {
+	Ncgi   *models.Ncgi `yaml:"ncgi,omitempty"`
+	Ecgi   *models.Ecgi `yaml:"ecgi,omitempty"`
+	Lat    float64      `yaml:"lat"`
+	Lon    float64      `yaml:"lon"`
+	Radius float32      `yaml:"radius"` // meters
+}
+
+const (
+	CellDatabaseSourceMongoDB = "mongodb"
+	CellDatabaseSourceCsv     = "csv"
+)
+
+type CellDatabase struct {
+	Source  string `yaml:"source,omitempty"`  // mongodb (default) or csv
+	CsvPath string `yaml:"csvPath,omitempty"` // cells file of the csv source, rewritten on OAM changes
+}
+
+// Emergency recognises the UEs in emergency services by their emergency
+// registration, or by PDU sessions to one of the DNNs or slices listed. Such UEs
+// are followed by a high priority tracking session notifying NotificationUri.
+type Emergency struct {
+	Dnns            []string        `yaml:"dnns,omitempty"`            // e.g. sos
+	Snssais         []models.Snssai `yaml:"snssais,omitempty"`         // slices dedicated to emergency services
+	NotificationUri string          `yaml:"notificationUri,omitempty"` // no tracking session is created without it
+	ReportInterval  int             `yaml:"reportInterval,omitempty"`  // seconds between periodic reports, default 5
+}
+
+const (
+	RegistrationAreaStrategyCurrentTai = "currentTai"
+	RegistrationAreaStrategyNeighbours = "neighbours"
+	RegistrationAreaStrategyMobility   = "mobility"
+)
+
+// RegistrationArea sets how the TAI list of a registration area is chosen around
+// the current TAI of the UE
+type RegistrationArea struct {
+	Strategy       string          `yaml:"strategy,omitempty"` // currentTai (default), neighbours or mobility
+	Neighbours     []TaiNeighbours `yaml:"neighbours,omitempty"`
+	MobilityWindow int             `yaml:"mobilityWindow,omitempty"` // seconds of location history considered by the mobility strategy, default 3600
+	MaxListSize    int             `yaml:"maxListSize,omitempty"`    // at most 16 TAIs
+}
+
+type TaiNeighbours struct {
+	Tai        models.Tai   `yaml:"tai"`
+	Neighbours []models.Tai `yaml:"neighbours"`
+}
+
+// Non3gppTai sets the TAI of the non-3GPP accesses, which the N3IWF does not
+// report in the user location: the TAI of the N3IWF, or the default one
+type Non3gppTai struct {
+	Default *models.Tai `yaml:"default,omitempty"`
+	N3iwfs  []N3iwfTai  `yaml:"n3iwfs,omitempty"`
+}
+
+type N3iwfTai struct {
+	N3IwfId string     `yaml:"n3iwfId"` // in hexadecimal, as in the Global RAN Node ID of NGAP
+	Tai     models.Tai `yaml:"tai"`
+}
+
 type Security struct {
 	IntegrityOrder []string `yaml:"integrityOrder,omitempty"`
 	CipheringOrder []string `yaml:"cipheringOrder,omitempty"`
@@ -31,6 +31,23 @@ func InitConfigFactory(f string) {
 
 	err = yaml.Unmarshal([]byte(content), &EtafConfig)
 	checkErr(err)
+	checkErr(EtafConfig.validate())
 
 	logger.InitLog.Infof("Successfully initialize configuration %s", f)
 }
+
+// validate rejects the settings that would otherwise be ignored silently
+func (config *Config) validate() error {
+	if config.Configuration == nil {
+		return nil
+	}
+	if registrationArea := config.Configuration.RegistrationArea; registrationArea != nil {
+		switch registrationArea.Strategy {
+		case "", RegistrationAreaStrategyCurrentTai, RegistrationAreaStrategyNeighbours,
+			RegistrationAreaStrategyMobility:
+		default:
+			return fmt.Errorf("unknown registration area strategy %s", registrationArea.Strategy)
+		}
+	}
+	return nil
+}
@@ -1,16 +1,61 @@
 package httpcallback
 
 import (
-//	"free5gc/lib/http_wrapper"
-//	"free5gc/lib/openapi"
-//	"free5gc/lib/openapi/models"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/models"
 	"free5gc/src/etaf/logger"
-//	"free5gc/src/pcf/producer"
-//	"net/http"
-//
+	"free5gc/src/etaf/producer"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 )
 
 func HTTPLocInfoNotify(c *gin.Context) {
-	logger.CommLog.Info("Location Info Notification received")
+	var notification models.AmfEventNotification
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		logger.CallbackLog.Errorf("Get Request Body error: %+v", err)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&notification, requestBody, "application/json")
+	if err != nil {
+		problemDetail := "[Request Body] " + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.CallbackLog.Errorln(problemDetail)
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	logger.CallbackLog.WithContext(c.Request.Context()).Info("Location Info Notification received")
+
+	req := http_wrapper.NewRequest(c.Request, notification)
+
+	rsp := producer.HandleLocationInfoNotify(req)
+
+	responseBody, err := openapi.Serialize(rsp.Body, "application/json")
+	if err != nil {
+		logger.CallbackLog.Errorln(err)
+		problemDetails := models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, problemDetails)
+	} else {
+		c.Data(rsp.Status, "application/json", responseBody)
+	}
 }
@@ -3,6 +3,7 @@ package httpcallback
 import (
 	"free5gc/lib/logger_util"
 	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
 	"net/http"
     "strings"
 	"github.com/sirupsen/logrus"
@@ -42,17 +43,18 @@ func AddService(engine *gin.Engine) *gin.RouterGroup {
 	group := engine.Group("/netaf-callback/v1")
 
 	for _, route := range routes {
+		handlerFunc := util.TracingHandler("netaf-callback", group.BasePath()+route.Pattern, route.HandlerFunc)
 		switch route.Method {
 		case "GET":
-			group.GET(route.Pattern, route.HandlerFunc)
+			group.GET(route.Pattern, handlerFunc)
 		case "POST":
-			group.POST(route.Pattern, route.HandlerFunc)
+			group.POST(route.Pattern, handlerFunc)
 		case "PUT":
-			group.PUT(route.Pattern, route.HandlerFunc)
+			group.PUT(route.Pattern, handlerFunc)
 		case "PATCH":
-			group.PATCH(route.Pattern, route.HandlerFunc)
+			group.PATCH(route.Pattern, handlerFunc)
 		case "DELETE":
-			group.DELETE(route.Pattern, route.HandlerFunc)
+			group.DELETE(route.Pattern, handlerFunc)
 		}
 	}
 	return group
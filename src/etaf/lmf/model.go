@@ -0,0 +1,49 @@
+package lmf
+
+import (
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"time"
+)
+
+// Response times of a positioning request
+const (
+	ResponseTimeNoDelay       = "NO_DELAY"
+	ResponseTimeLowDelay      = "LOW_DELAY"
+	ResponseTimeDelayTolerant = "DELAY_TOLERANT"
+)
+
+const (
+	AccuracyFulfilled    = "REQUESTED_ACCURACY_FULFILLED"
+	AccuracyNotFulfilled = "REQUESTED_ACCURACY_NOT_FULFILLED"
+)
+
+// InputData is the DetermineLocation request of Nlmf_Location (TS 29.572)
+type InputData struct {
+	ExternalClientType string       `json:"externalClientType"`
+	CorrelationID      string       `json:"correlationID,omitempty"`
+	AmfId              string       `json:"amfId,omitempty"`
+	LocationQoS        *LocationQoS `json:"locationQoS,omitempty"`
+	SupportedGADShapes []string     `json:"supportedGADShapes,omitempty"`
+	Supi               string       `json:"supi,omitempty"`
+	Gpsi               string       `json:"gpsi,omitempty"`
+	Ecgi               *models.Ecgi `json:"ecgi,omitempty"`
+	Ncgi               *models.Ncgi `json:"ncgi,omitempty"`
+	Priority           string       `json:"priority,omitempty"`
+}
+
+type LocationQoS struct {
+	HAccuracy    float32 `json:"hAccuracy,omitempty"` // meters
+	VAccuracy    float32 `json:"vAccuracy,omitempty"` // meters
+	ResponseTime string  `json:"responseTime,omitempty"`
+}
+
+// LocationData is the DetermineLocation response of Nlmf_Location (TS 29.572)
+type LocationData struct {
+	LocationEstimate            etaf_context.GeographicArea `json:"locationEstimate"`
+	AccuracyFulfilmentIndicator string                      `json:"accuracyFulfilmentIndicator,omitempty"`
+	AgeOfLocationEstimate       int32                       `json:"ageOfLocationEstimate,omitempty"` // minutes
+	TimestampOfLocationEstimate *time.Time                  `json:"timestampOfLocationEstimate,omitempty"`
+	Ecgi                        *models.Ecgi                `json:"ecgi,omitempty"`
+	Ncgi                        *models.Ncgi                `json:"ncgi,omitempty"`
+}
@@ -0,0 +1,45 @@
+package lmf
+
+import (
+	"free5gc/src/etaf/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route is the information for every URI.
+type Route struct {
+	// Name is the name of this Route.
+	Name string
+	// Method is the string for the HTTP method. ex) GET, POST etc..
+	Method string
+	// Pattern is the pattern of the URI.
+	Pattern string
+	// HandlerFunc is the handler function of this route.
+	HandlerFunc gin.HandlerFunc
+}
+
+// Routes is the list of the generated Route.
+type Routes []Route
+
+// AddService serves the stand-in LMF
+func AddService(engine *gin.Engine) *gin.RouterGroup {
+	group := engine.Group("/nlmf-loc/v1")
+
+	for _, route := range routes {
+		handlerFunc := util.TracingHandler("nlmf-loc", group.BasePath()+route.Pattern, route.HandlerFunc)
+		switch route.Method {
+		case "POST":
+			group.POST(route.Pattern, handlerFunc)
+		}
+	}
+	return group
+}
+
+var routes = Routes{
+	{
+		"DetermineLocation",
+		"POST",
+		"/determine-location",
+		HTTPDetermineLocation,
+	},
+}
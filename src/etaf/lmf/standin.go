@@ -0,0 +1,126 @@
+package lmf
+
+import (
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// polygonVertices is the number of vertices approximating the cell circle when
+// the client does not accept POINT_UNCERTAINTY_CIRCLE
+const polygonVertices = 8
+
+const metersPerDegree = 111320
+
+// DetermineLocation positions a UE at the site of its serving cell, with the cell
+// radius as uncertainty. It is the stand-in for an LMF; the accuracy requested is
+// fulfilled only by cells smaller than it.
+func DetermineLocation(input InputData) (*LocationData, *models.ProblemDetails) {
+	if input.Ncgi == nil && input.Ecgi == nil {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "ncgi or ecgi is required",
+		}
+	}
+	cell, ok := findCell(input.Ncgi, input.Ecgi)
+	if !ok {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "POSITIONING_FAILED",
+			Detail: "the serving cell is unknown",
+		}
+	}
+
+	var estimate etaf_context.GeographicArea
+	center := etaf_context.GeographicalCoordinates{Lat: cell.Lat, Lon: cell.Lon}
+	switch {
+	case acceptsShape(input.SupportedGADShapes, etaf_context.GADShapePointUncertaintyCircle):
+		estimate = etaf_context.GeographicArea{
+			Shape:       etaf_context.GADShapePointUncertaintyCircle,
+			Point:       &center,
+			Uncertainty: cell.Radius,
+		}
+	case acceptsShape(input.SupportedGADShapes, etaf_context.GADShapePolygon):
+		estimate = etaf_context.GeographicArea{
+			Shape:     etaf_context.GADShapePolygon,
+			PointList: circlePolygon(center, cell.Radius),
+		}
+	case acceptsShape(input.SupportedGADShapes, etaf_context.GADShapePoint):
+		estimate = etaf_context.GeographicArea{
+			Shape: etaf_context.GADShapePoint,
+			Point: &center,
+		}
+	default:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "UNSUPPORTED_GAD_SHAPES",
+			Detail: "none of the supported GAD shapes can be provided",
+		}
+	}
+
+	now := time.Now().UTC()
+	locationData := &LocationData{
+		LocationEstimate:            estimate,
+		AccuracyFulfilmentIndicator: AccuracyFulfilled,
+		TimestampOfLocationEstimate: &now,
+		Ecgi:                        input.Ecgi,
+		Ncgi:                        input.Ncgi,
+	}
+	if input.LocationQoS != nil && input.LocationQoS.HAccuracy > 0 && cell.Radius > input.LocationQoS.HAccuracy {
+		locationData.AccuracyFulfilmentIndicator = AccuracyNotFulfilled
+	}
+	return locationData, nil
+}
+
+func findCell(ncgi *models.Ncgi, ecgi *models.Ecgi) (*factory.StandInCell, bool) {
+	lmfConfig := factory.EtafConfig.Configuration.Lmf
+	if lmfConfig == nil || lmfConfig.StandIn == nil {
+		return nil, false
+	}
+	for i := range lmfConfig.StandIn.Cells {
+		cell := &lmfConfig.StandIn.Cells[i]
+		if ncgi != nil && cell.Ncgi != nil && etaf_context.SamePlmn(cell.Ncgi.PlmnId, ncgi.PlmnId) &&
+			strings.EqualFold(cell.Ncgi.NrCellId, ncgi.NrCellId) {
+			return cell, true
+		}
+		if ecgi != nil && cell.Ecgi != nil && etaf_context.SamePlmn(cell.Ecgi.PlmnId, ecgi.PlmnId) &&
+			strings.EqualFold(cell.Ecgi.EutraCellId, ecgi.EutraCellId) {
+			return cell, true
+		}
+	}
+	return nil, false
+}
+
+// acceptsShape reports whether shape is among the shapes supported by the client;
+// every shape is supported when none is listed
+func acceptsShape(supportedShapes []string, shape string) bool {
+	if len(supportedShapes) == 0 {
+		return true
+	}
+	for _, supportedShape := range supportedShapes {
+		if supportedShape == shape {
+			return true
+		}
+	}
+	return false
+}
+
+// circlePolygon approximates a circle of radius meters around center
+func circlePolygon(center etaf_context.GeographicalCoordinates, radius float32) []etaf_context.GeographicalCoordinates {
+	latRadius := float64(radius) / metersPerDegree
+	lonRadius := latRadius / math.Cos(center.Lat*math.Pi/180)
+	points := make([]etaf_context.GeographicalCoordinates, 0, polygonVertices)
+	for i := 0; i < polygonVertices; i++ {
+		angle := 2 * math.Pi * float64(i) / polygonVertices
+		points = append(points, etaf_context.GeographicalCoordinates{
+			Lat: center.Lat + latRadius*math.Sin(angle),
+			Lon: center.Lon + lonRadius*math.Cos(angle),
+		})
+	}
+	return points
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// CorrelationIDHeader carries the correlation ID of a tracking flow on inbound
+// requests, outgoing SBI calls and the callbacks they trigger
+const CorrelationIDHeader = "X-Correlation-Id"
+
+// Field keys attached to log entries
+const (
+	FieldCorrelationID = "correlationId"
+	FieldSupi          = "supi"
+	FieldAlertID       = "alertId"
+	FieldRanAddr       = "ranAddr"
+	FieldRanId         = "ranId"
+)
+
+type correlationIDKey struct{}
+
+func NewCorrelationID() string {
+	return uuid.New().String()
+}
+
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	if correlationID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if correlationID, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		return correlationID
+	}
+	return ""
+}
+
+func WithCorrelationID(entry *logrus.Entry, correlationID string) *logrus.Entry {
+	if correlationID == "" {
+		return entry
+	}
+	return entry.WithField(FieldCorrelationID, correlationID)
+}
+
+func WithSupi(entry *logrus.Entry, supi string) *logrus.Entry {
+	if supi == "" {
+		return entry
+	}
+	return entry.WithField(FieldSupi, supi)
+}
+
+func WithAlertID(entry *logrus.Entry, alertID string) *logrus.Entry {
+	if alertID == "" {
+		return entry
+	}
+	return entry.WithField(FieldAlertID, alertID)
+}
+
+// correlationHook copies the correlation ID of the entry's context into its fields,
+// so every entry logged with WithContext(ctx) can be joined across a tracking flow
+type correlationHook struct{}
+
+func (correlationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (correlationHook) Fire(entry *logrus.Entry) error {
+	if correlationID := CorrelationIDFromContext(entry.Context); correlationID != "" {
+		entry.Data[FieldCorrelationID] = correlationID
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	formatter "github.com/antonfisher/nested-logrus-formatter"
@@ -12,6 +14,11 @@ import (
 )
 
 var log *logrus.Logger
+
+// categoryLoggers holds one logger per log category so that their levels can be
+// controlled separately; all of them share the formatter and hooks of log
+var categoryLoggers = make(map[string]*logrus.Logger)
+
 var AppLog *logrus.Entry
 var InitLog *logrus.Entry
 var ContextLog *logrus.Entry
@@ -29,6 +36,10 @@ var NasLog *logrus.Entry
 var ConsumerLog *logrus.Entry
 var EeLog *logrus.Entry
 var GinLog *logrus.Entry
+var AuditLog *logrus.Entry
+var PrivacyLog *logrus.Entry
+var LmfLog *logrus.Entry
+var CellDbLog *logrus.Entry
 
 func init() {
 	log = logrus.New()
@@ -42,6 +53,9 @@ func init() {
 		FieldsOrder:     []string{"component", "category"},
 	}
 
+	// must fire before the file hooks so they see the correlation ID
+	log.Hooks.Add(correlationHook{})
+
 	free5gcLogHook, err := logger_util.NewFileHook(logger_conf.Free5gcLogFile, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
 	if err == nil {
 		log.Hooks.Add(free5gcLogHook)
@@ -52,29 +66,104 @@ func init() {
 		log.Hooks.Add(selfLogHook)
 	}
 
-	AppLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "App"})
-	InitLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Init"})
-	ContextLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Context"})
-	NgapLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "NGAP"})
-	HandlerLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Handler"})
-	HttpLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "HTTP"})
-	GmmLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Gmm"})
-	MtLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "MT"})
-	ProducerLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Producer"})
-	LocationLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "LocInfo"})
-	CommLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Comm"})
-	CallbackLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Callback"})
-	UtilLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Util"})
-	NasLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "NAS"})
-	ConsumerLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "Consumer"})
-	EeLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "EventExposure"})
-	GinLog = log.WithFields(logrus.Fields{"component": "ETAF", "category": "GIN"})
+	AppLog = newCategoryLog("App")
+	InitLog = newCategoryLog("Init")
+	ContextLog = newCategoryLog("Context")
+	NgapLog = newCategoryLog("NGAP")
+	HandlerLog = newCategoryLog("Handler")
+	HttpLog = newCategoryLog("HTTP")
+	GmmLog = newCategoryLog("Gmm")
+	MtLog = newCategoryLog("MT")
+	ProducerLog = newCategoryLog("Producer")
+	LocationLog = newCategoryLog("LocInfo")
+	CommLog = newCategoryLog("Comm")
+	CallbackLog = newCategoryLog("Callback")
+	UtilLog = newCategoryLog("Util")
+	NasLog = newCategoryLog("NAS")
+	ConsumerLog = newCategoryLog("Consumer")
+	EeLog = newCategoryLog("EventExposure")
+	GinLog = newCategoryLog("GIN")
+	AuditLog = newCategoryLog("Audit")
+	PrivacyLog = newCategoryLog("Privacy")
+	LmfLog = newCategoryLog("LMF")
+	CellDbLog = newCategoryLog("CellDB")
+}
+
+func newCategoryLog(category string) *logrus.Entry {
+	categoryLogger := logrus.New()
+	categoryLogger.Formatter = log.Formatter
+	categoryLogger.Hooks = log.Hooks
+	categoryLogger.SetReportCaller(log.ReportCaller)
+	categoryLogger.SetLevel(log.GetLevel())
+	categoryLoggers[category] = categoryLogger
+	return categoryLogger.WithFields(logrus.Fields{"component": "ETAF", "category": category})
 }
 
 func SetLogLevel(level logrus.Level) {
 	log.SetLevel(level)
+	for _, categoryLogger := range categoryLoggers {
+		categoryLogger.SetLevel(level)
+	}
 }
 
 func SetReportCaller(bool bool) {
 	log.SetReportCaller(bool)
+	for _, categoryLogger := range categoryLoggers {
+		categoryLogger.SetReportCaller(bool)
+	}
+}
+
+func ReportCaller() bool {
+	return log.ReportCaller
+}
+
+// findCategoryLogger matches a category case-insensitively, either by its name
+// ("NGAP") or by the name of its entry ("NgapLog")
+func findCategoryLogger(category string) (string, *logrus.Logger, error) {
+	for _, candidate := range []string{category, strings.TrimSuffix(category, "Log")} {
+		for name, categoryLogger := range categoryLoggers {
+			if strings.EqualFold(name, candidate) {
+				return name, categoryLogger, nil
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("Unknown log category[%s]", category)
+}
+
+// SetCategoryLogLevel sets the level of a single category and returns its name
+func SetCategoryLogLevel(category string, level logrus.Level) (string, error) {
+	name, categoryLogger, err := findCategoryLogger(category)
+	if err != nil {
+		return "", err
+	}
+	categoryLogger.SetLevel(level)
+	return name, nil
+}
+
+// CategoryLogLevel returns the name and level of a single category
+func CategoryLogLevel(category string) (string, logrus.Level, error) {
+	name, categoryLogger, err := findCategoryLogger(category)
+	if err != nil {
+		return "", log.GetLevel(), err
+	}
+	return name, categoryLogger.GetLevel(), nil
+}
+
+// CategoryLogLevels returns the current level of every category
+func CategoryLogLevels() map[string]logrus.Level {
+	levels := make(map[string]logrus.Level, len(categoryLoggers))
+	for name, categoryLogger := range categoryLoggers {
+		levels[name] = categoryLogger.GetLevel()
+	}
+	return levels
+}
+
+// SetJSONFormatter switches the logger to one JSON object per line
+func SetJSONFormatter() {
+	log.Formatter = &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+	}
+	for _, categoryLogger := range categoryLoggers {
+		categoryLogger.Formatter = log.Formatter
+	}
 }
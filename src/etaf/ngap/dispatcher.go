@@ -0,0 +1,93 @@
+package ngap
+
+import (
+	"net"
+
+	"free5gc/lib/ngap"
+	"free5gc/lib/ngap/ngapType"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	ngap_message "free5gc/src/etaf/ngap/message"
+)
+
+// Dispatch handles the NGAP messages of a RAN association. The RAN context is
+// created on the first message and removed once the association is closed.
+func Dispatch(conn net.Conn, msg []byte) {
+	var ran *context.EtafRan
+	etafSelf := context.ETAF_Self()
+
+	ran, ok := etafSelf.EtafRanFindByConn(conn)
+	if len(msg) == 0 {
+		if ok {
+			ran.Log.Infof("RAN close the connection.")
+			ran.Remove()
+		}
+		return
+	}
+	if !ok {
+		logger.NgapLog.Infof("Create a new NG connection for: %s", conn.RemoteAddr().String())
+		ran = etafSelf.NewEtafRan(conn)
+	}
+
+	pdu, err := ngap.Decoder(msg)
+	if err != nil {
+		ran.Log.Errorf("NGAP decode error : %+v", err)
+		cause := ngapType.Cause{
+			Present: ngapType.CausePresentProtocol,
+			Protocol: &ngapType.CauseProtocol{
+				Value: ngapType.CauseProtocolPresentTransferSyntaxError,
+			},
+		}
+		ngap_message.SendErrorIndication(ran, nil, nil, &cause, nil)
+		return
+	}
+
+	switch pdu.Present {
+	case ngapType.NGAPPDUPresentInitiatingMessage:
+		initiatingMessage := pdu.InitiatingMessage
+		if initiatingMessage == nil {
+			ran.Log.Errorln("Initiating Message is nil")
+			return
+		}
+		switch initiatingMessage.ProcedureCode.Value {
+		case ngapType.ProcedureCodeNGSetup:
+			HandleNGSetupRequest(ran, pdu)
+		case ngapType.ProcedureCodeNGReset:
+			HandleNGReset(ran, pdu)
+		case ngapType.ProcedureCodeErrorIndication:
+			HandleErrorIndication(ran, pdu)
+		case ngapType.ProcedureCodeRANConfigurationUpdate:
+			HandleRanConfigurationUpdate(ran, pdu)
+		case ngapType.ProcedureCodePWSRestartIndication:
+			HandlePWSRestartIndication(ran, pdu)
+		case ngapType.ProcedureCodePWSFailureIndication:
+			HandlePWSFailureIndication(ran, pdu)
+		default:
+			ran.Log.Warnf("Not implemented(choice:%d, procedureCode:%d)", pdu.Present,
+				initiatingMessage.ProcedureCode.Value)
+		}
+	case ngapType.NGAPPDUPresentSuccessfulOutcome:
+		successfulOutcome := pdu.SuccessfulOutcome
+		if successfulOutcome == nil {
+			ran.Log.Errorln("successful Outcome is nil")
+			return
+		}
+		switch successfulOutcome.ProcedureCode.Value {
+		case ngapType.ProcedureCodeWriteReplaceWarning:
+			HandleWriteReplaceWarningResponse(ran, pdu)
+		case ngapType.ProcedureCodePWSCancel:
+			HandlePWSCancelResponse(ran, pdu)
+		default:
+			ran.Log.Warnf("Not implemented(choice:%d, procedureCode:%d)", pdu.Present,
+				successfulOutcome.ProcedureCode.Value)
+		}
+	case ngapType.NGAPPDUPresentUnsuccessfulOutcome:
+		unsuccessfulOutcome := pdu.UnsuccessfulOutcome
+		if unsuccessfulOutcome == nil {
+			ran.Log.Errorln("unsuccessful Outcome is nil")
+			return
+		}
+		ran.Log.Warnf("Not implemented(choice:%d, procedureCode:%d)", pdu.Present,
+			unsuccessfulOutcome.ProcedureCode.Value)
+	}
+}
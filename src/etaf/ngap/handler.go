@@ -0,0 +1,784 @@
+package ngap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"free5gc/lib/ngap/ngapConvert"
+	"free5gc/lib/ngap/ngapType"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/celldb"
+	"free5gc/src/etaf/context"
+	ngap_message "free5gc/src/etaf/ngap/message"
+	"free5gc/src/etaf/producer"
+)
+
+func HandleNGSetupRequest(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var globalRANNodeID *ngapType.GlobalRANNodeID
+	var rANNodeName *ngapType.RANNodeName
+	var supportedTAList *ngapType.SupportedTAList
+	var pagingDRX *ngapType.PagingDRX
+
+	var cause ngapType.Cause
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	initiatingMessage := message.InitiatingMessage
+	if initiatingMessage == nil {
+		ran.Log.Error("Initiating Message is nil")
+		return
+	}
+	nGSetupRequest := initiatingMessage.Value.NGSetupRequest
+	if nGSetupRequest == nil {
+		ran.Log.Error("NGSetupRequest is nil")
+		return
+	}
+	ran.Log.Info("Handle NG Setup request")
+	for i := 0; i < len(nGSetupRequest.ProtocolIEs.List); i++ {
+		ie := nGSetupRequest.ProtocolIEs.List[i]
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDGlobalRANNodeID:
+			globalRANNodeID = ie.Value.GlobalRANNodeID
+			ran.Log.Trace("Decode IE GlobalRANNodeID")
+			if globalRANNodeID == nil {
+				ran.Log.Error("GlobalRANNodeID is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDSupportedTAList:
+			supportedTAList = ie.Value.SupportedTAList
+			ran.Log.Trace("Decode IE SupportedTAList")
+			if supportedTAList == nil {
+				ran.Log.Error("SupportedTAList is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDRANNodeName:
+			rANNodeName = ie.Value.RANNodeName
+			ran.Log.Trace("Decode IE RANNodeName")
+			if rANNodeName == nil {
+				ran.Log.Error("RANNodeName is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDDefaultPagingDRX:
+			pagingDRX = ie.Value.DefaultPagingDRX
+			ran.Log.Trace("Decode IE DefaultPagingDRX")
+			if pagingDRX == nil {
+				ran.Log.Error("DefaultPagingDRX is nil")
+				return
+			}
+		}
+	}
+
+	if globalRANNodeID == nil || supportedTAList == nil {
+		ran.Log.Error("Mandatory IE missing in NG Setup request")
+		cause.Present = ngapType.CausePresentProtocol
+		cause.Protocol = &ngapType.CauseProtocol{
+			Value: ngapType.CauseProtocolPresentAbstractSyntaxErrorFalselyConstructedMessage,
+		}
+		ngap_message.SendNGSetupFailure(ran, cause)
+		ran.Remove()
+		return
+	}
+
+	ran.SetRanId(globalRANNodeID)
+	// a RAN reconnecting before the loss of its previous association was detected
+	context.ETAF_Self().EtafRanPool.Range(func(key, value interface{}) bool {
+		if stale := value.(*context.EtafRan); stale != ran && stale.RanNodeId() == ran.RanNodeId() {
+			stale.Log.Info("Remove the previous association of the RAN")
+			stale.Remove()
+			// ends the reading of the association, which finds the RAN removed
+			if err := stale.Conn.Close(); err != nil {
+				stale.Log.Warnf("Close the previous association error: %+v", err)
+			}
+		}
+		return true
+	})
+	if rANNodeName != nil {
+		ran.Name = rANNodeName.Value
+	}
+	if pagingDRX != nil {
+		ran.Log.Tracef("PagingDRX[%d]", pagingDRX.Value)
+	}
+
+	supportedTais := buildSupportedTAList(ran, supportedTAList)
+	cause = checkSupportedTAList(ran, supportedTais, "NG-Setup")
+	ran.SetSupportedTAList(supportedTais)
+
+	if cause.Present == ngapType.CausePresentNothing {
+		ran.Log.Infof("NG Setup with RAN[%s] succeeded", ran.RanNodeId())
+		ngap_message.SendNGSetupResponse(ran)
+		// a RAN coming back, or new, gets the alerts active in its area
+		producer.RedeliverAlerts(ran, nil, nil, nil)
+	} else {
+		ngap_message.SendNGSetupFailure(ran, cause)
+		ran.Remove()
+	}
+}
+
+// HandleNGReset releases the UE contexts of the whole NG interface or of the
+// UE-associated logical NG-connections listed
+func HandleNGReset(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var cause *ngapType.Cause
+	var resetType *ngapType.ResetType
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	initiatingMessage := message.InitiatingMessage
+	if initiatingMessage == nil {
+		ran.Log.Error("InitiatingMessage is nil")
+		return
+	}
+	nGReset := initiatingMessage.Value.NGReset
+	if nGReset == nil {
+		ran.Log.Error("NGReset is nil")
+		return
+	}
+	ran.Log.Info("Handle NG Reset")
+	for _, ie := range nGReset.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDCause:
+			cause = ie.Value.Cause
+			ran.Log.Trace("Decode IE Cause")
+			if cause == nil {
+				ran.Log.Error("Cause is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDResetType:
+			resetType = ie.Value.ResetType
+			ran.Log.Trace("Decode IE ResetType")
+			if resetType == nil {
+				ran.Log.Error("ResetType is nil")
+				return
+			}
+		}
+	}
+	if resetType == nil {
+		ran.Log.Error("ResetType is missing")
+		return
+	}
+
+	ran.Log.Infof("NG Reset cause: %s", causeToString(cause))
+	switch resetType.Present {
+	case ngapType.ResetTypePresentNGInterface:
+		ran.Log.Trace("ResetType Present: NG Interface")
+		ran.RemoveAllUeInRan()
+		ngap_message.SendNGResetAcknowledge(ran, nil, nil)
+	case ngapType.ResetTypePresentPartOfNGInterface:
+		ran.Log.Trace("ResetType Present: Part of NG Interface")
+		partOfNGInterface := resetType.PartOfNGInterface
+		if partOfNGInterface == nil || len(partOfNGInterface.List) == 0 {
+			ran.Log.Error("PartOfNGInterface is empty")
+			return
+		}
+		for _, item := range partOfNGInterface.List {
+			ranUe := findRanUe(ran, item.AMFUENGAPID, item.RANUENGAPID)
+			if ranUe == nil {
+				continue
+			}
+			if err := ranUe.Remove(); err != nil {
+				ran.Log.Errorf("Remove RanUe error: %v", err)
+			}
+		}
+		ngap_message.SendNGResetAcknowledge(ran, partOfNGInterface, nil)
+	default:
+		ran.Log.Warnf("Invalid ResetType[%d]", resetType.Present)
+	}
+}
+
+func HandleErrorIndication(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var aMFUENGAPID *ngapType.AMFUENGAPID
+	var rANUENGAPID *ngapType.RANUENGAPID
+	var cause *ngapType.Cause
+	var criticalityDiagnostics *ngapType.CriticalityDiagnostics
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	initiatingMessage := message.InitiatingMessage
+	if initiatingMessage == nil {
+		ran.Log.Error("InitiatingMessage is nil")
+		return
+	}
+	errorIndication := initiatingMessage.Value.ErrorIndication
+	if errorIndication == nil {
+		ran.Log.Error("ErrorIndication is nil")
+		return
+	}
+	ran.Log.Info("Handle Error Indication")
+	for _, ie := range errorIndication.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDAMFUENGAPID:
+			aMFUENGAPID = ie.Value.AMFUENGAPID
+			ran.Log.Trace("Decode IE AmfUeNgapID")
+		case ngapType.ProtocolIEIDRANUENGAPID:
+			rANUENGAPID = ie.Value.RANUENGAPID
+			ran.Log.Trace("Decode IE RanUeNgapID")
+		case ngapType.ProtocolIEIDCause:
+			cause = ie.Value.Cause
+			ran.Log.Trace("Decode IE Cause")
+		case ngapType.ProtocolIEIDCriticalityDiagnostics:
+			criticalityDiagnostics = ie.Value.CriticalityDiagnostics
+			ran.Log.Trace("Decode IE CriticalityDiagnostics")
+		}
+	}
+
+	if cause == nil && criticalityDiagnostics == nil {
+		ran.Log.Error("[ErrorIndication] both Cause IE and CriticalityDiagnostics IE are nil, should have at least one")
+		return
+	}
+
+	log := ran.Log
+	if aMFUENGAPID != nil {
+		log = log.WithField("amfUeNgapId", aMFUENGAPID.Value)
+	}
+	if rANUENGAPID != nil {
+		log = log.WithField("ranUeNgapId", rANUENGAPID.Value)
+	}
+	if cause != nil {
+		log = log.WithField("cause", causeToString(cause))
+	}
+	if criticalityDiagnostics != nil {
+		log = log.WithField("criticalityDiagnostics", criticalityDiagnosticsToString(criticalityDiagnostics))
+	}
+	log.Warn("Error Indication received")
+}
+
+// HandleRanConfigurationUpdate refreshes the name and the Supported TA List of the
+// RAN; the active alerts are sent for the TAIs added
+func HandleRanConfigurationUpdate(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var rANNodeName *ngapType.RANNodeName
+	var supportedTAList *ngapType.SupportedTAList
+	var pagingDRX *ngapType.PagingDRX
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	initiatingMessage := message.InitiatingMessage
+	if initiatingMessage == nil {
+		ran.Log.Error("Initiating Message is nil")
+		return
+	}
+	rANConfigurationUpdate := initiatingMessage.Value.RANConfigurationUpdate
+	if rANConfigurationUpdate == nil {
+		ran.Log.Error("RAN Configuration is nil")
+		return
+	}
+	ran.Log.Info("Handle RAN Configuration Update")
+	for _, ie := range rANConfigurationUpdate.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDRANNodeName:
+			rANNodeName = ie.Value.RANNodeName
+			ran.Log.Trace("Decode IE RANNodeName")
+			if rANNodeName == nil {
+				ran.Log.Error("RAN Node Name is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDSupportedTAList:
+			supportedTAList = ie.Value.SupportedTAList
+			ran.Log.Trace("Decode IE SupportedTAList")
+			if supportedTAList == nil {
+				ran.Log.Error("Supported TA List is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDDefaultPagingDRX:
+			pagingDRX = ie.Value.DefaultPagingDRX
+			ran.Log.Trace("Decode IE DefaultPagingDRX")
+			if pagingDRX == nil {
+				ran.Log.Error("PagingDRX is nil")
+				return
+			}
+		}
+	}
+
+	if rANNodeName != nil {
+		ran.Name = rANNodeName.Value
+	}
+	if pagingDRX != nil {
+		ran.Log.Tracef("PagingDRX[%d]", pagingDRX.Value)
+	}
+	if supportedTAList == nil {
+		ngap_message.SendRanConfigurationUpdateAcknowledge(ran, nil)
+		return
+	}
+
+	previous := ran.SupportedTais()
+	supportedTais := buildSupportedTAList(ran, supportedTAList)
+	if cause := checkSupportedTAList(ran, supportedTais, "RanConfigurationUpdate"); cause.Present != ngapType.CausePresentNothing {
+		ngap_message.SendRanConfigurationUpdateFailure(ran, cause, nil)
+		return
+	}
+	ran.SetSupportedTAList(supportedTais)
+	ngap_message.SendRanConfigurationUpdateAcknowledge(ran, nil)
+
+	var addedTaiList []models.Tai
+	for _, supportedTAI := range supportedTais {
+		found := false
+		for _, previousTAI := range previous {
+			if reflect.DeepEqual(previousTAI.Tai, supportedTAI.Tai) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addedTaiList = append(addedTaiList, supportedTAI.Tai)
+		}
+	}
+	if len(addedTaiList) > 0 {
+		ran.Log.Infof("RAN supports %d new TAIs", len(addedTaiList))
+		producer.RedeliverAlerts(ran, addedTaiList, nil, nil)
+	}
+}
+
+// buildSupportedTAList converts the Supported TA List IE, one SupportedTAI per
+// broadcast PLMN of each TAC
+func buildSupportedTAList(ran *context.EtafRan, supportedTAList *ngapType.SupportedTAList) []context.SupportedTAI {
+	list := make([]context.SupportedTAI, 0, context.MaxNumOfTAI*context.MaxNumOfBroadcastPLMNs)
+	for i := 0; i < len(supportedTAList.List); i++ {
+		supportedTAItem := supportedTAList.List[i]
+		tac := hex.EncodeToString(supportedTAItem.TAC.Value)
+		capOfSupportTai := cap(list)
+		for j := 0; j < len(supportedTAItem.BroadcastPLMNList.List); j++ {
+			supportedTAI := context.NewSupportedTAI()
+			supportedTAI.Tai.Tac = tac
+			broadcastPLMNItem := supportedTAItem.BroadcastPLMNList.List[j]
+			plmnId := ngapConvert.PlmnIdToModels(broadcastPLMNItem.PLMNIdentity)
+			supportedTAI.Tai.PlmnId = &plmnId
+			capOfSNssaiList := cap(supportedTAI.SNssaiList)
+			for k := 0; k < len(broadcastPLMNItem.TAISliceSupportList.List); k++ {
+				tAISliceSupportItem := broadcastPLMNItem.TAISliceSupportList.List[k]
+				if len(supportedTAI.SNssaiList) < capOfSNssaiList {
+					supportedTAI.SNssaiList = append(supportedTAI.SNssaiList,
+						ngapConvert.SNssaiToModels(tAISliceSupportItem.SNSSAI))
+				} else {
+					break
+				}
+			}
+			ran.Log.Tracef("PLMN_ID[MCC:%s MNC:%s] TAC[%s]", plmnId.Mcc, plmnId.Mnc, tac)
+			if len(list) < capOfSupportTai {
+				list = append(list, supportedTAI)
+			} else {
+				break
+			}
+		}
+	}
+	return list
+}
+
+// checkSupportedTAList returns the cause of the failure of the procedure if the
+// RAN supports no TAI served by the ETAF
+func checkSupportedTAList(ran *context.EtafRan, supportedTais []context.SupportedTAI, procedure string) (
+	cause ngapType.Cause) {
+	if len(supportedTais) == 0 {
+		ran.Log.Warnf("%s failure: No supported TA exist in %s request", procedure, procedure)
+		cause.Present = ngapType.CausePresentMisc
+		cause.Misc = &ngapType.CauseMisc{
+			Value: ngapType.CauseMiscPresentUnspecified,
+		}
+		return
+	}
+	taiList := context.ETAF_Self().SupportTaiLists
+	for i, tai := range supportedTais {
+		if context.InTaiList(tai.Tai, taiList) {
+			ran.Log.Tracef("SERVED_TAI_INDEX[%d]", i)
+			return
+		}
+	}
+	ran.Log.Warnf("%s failure: Cannot find Served TAI in ETAF", procedure)
+	cause.Present = ngapType.CausePresentMisc
+	cause.Misc = &ngapType.CauseMisc{
+		Value: ngapType.CauseMiscPresentUnknownPLMN,
+	}
+	return
+}
+
+func HandleWriteReplaceWarningResponse(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var messageIdentifier *ngapType.MessageIdentifier
+	var serialNumber *ngapType.SerialNumber
+	var broadcastCompletedAreaList *ngapType.BroadcastCompletedAreaList
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	successfulOutcome := message.SuccessfulOutcome
+	if successfulOutcome == nil {
+		ran.Log.Error("SuccessfulOutcome is nil")
+		return
+	}
+	writeReplaceWarningResponse := successfulOutcome.Value.WriteReplaceWarningResponse
+	if writeReplaceWarningResponse == nil {
+		ran.Log.Error("WriteReplaceWarningResponse is nil")
+		return
+	}
+	ran.Log.Info("Handle Write-Replace Warning Response")
+	for _, ie := range writeReplaceWarningResponse.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDMessageIdentifier:
+			messageIdentifier = ie.Value.MessageIdentifier
+			ran.Log.Trace("Decode IE MessageIdentifier")
+		case ngapType.ProtocolIEIDSerialNumber:
+			serialNumber = ie.Value.SerialNumber
+			ran.Log.Trace("Decode IE SerialNumber")
+		case ngapType.ProtocolIEIDBroadcastCompletedAreaList:
+			broadcastCompletedAreaList = ie.Value.BroadcastCompletedAreaList
+			ran.Log.Trace("Decode IE BroadcastCompletedAreaList")
+		case ngapType.ProtocolIEIDCriticalityDiagnostics:
+			ran.Log.Trace("Decode IE CriticalityDiagnostics")
+		}
+	}
+
+	alert := findAlert(ran, messageIdentifier, serialNumber)
+	if alert == nil {
+		return
+	}
+	if broadcastCompletedAreaList == nil {
+		ran.Log.Tracef("Alert[%s] broadcast in all the cells of the RAN", alert.Id)
+	}
+	alert.UpdateDelivery(ran.RanNodeId(), func(delivery *context.RanDelivery) {
+		if delivery.Status == context.DeliveryPending {
+			delivery.Status = context.DeliveryBroadcasting
+		}
+	})
+}
+
+func HandlePWSCancelResponse(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var messageIdentifier *ngapType.MessageIdentifier
+	var serialNumber *ngapType.SerialNumber
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	successfulOutcome := message.SuccessfulOutcome
+	if successfulOutcome == nil {
+		ran.Log.Error("SuccessfulOutcome is nil")
+		return
+	}
+	pWSCancelResponse := successfulOutcome.Value.PWSCancelResponse
+	if pWSCancelResponse == nil {
+		ran.Log.Error("PWSCancelResponse is nil")
+		return
+	}
+	ran.Log.Info("Handle PWS Cancel Response")
+	for _, ie := range pWSCancelResponse.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDMessageIdentifier:
+			messageIdentifier = ie.Value.MessageIdentifier
+			ran.Log.Trace("Decode IE MessageIdentifier")
+		case ngapType.ProtocolIEIDSerialNumber:
+			serialNumber = ie.Value.SerialNumber
+			ran.Log.Trace("Decode IE SerialNumber")
+		case ngapType.ProtocolIEIDBroadcastCancelledAreaList:
+			ran.Log.Trace("Decode IE BroadcastCancelledAreaList")
+		case ngapType.ProtocolIEIDCriticalityDiagnostics:
+			ran.Log.Trace("Decode IE CriticalityDiagnostics")
+		}
+	}
+
+	alert := findAlert(ran, messageIdentifier, serialNumber)
+	if alert == nil {
+		return
+	}
+	alert.UpdateDelivery(ran.RanNodeId(), func(delivery *context.RanDelivery) {
+		delivery.Status = context.DeliveryCancelled
+	})
+}
+
+// HandlePWSRestartIndication re-sends the active alerts to the RAN for the TAIs
+// and cells restarted
+func HandlePWSRestartIndication(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var cellIDListForRestart *ngapType.CellIDListForRestart
+	var tAIListForRestart *ngapType.TAIListForRestart
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	initiatingMessage := message.InitiatingMessage
+	if initiatingMessage == nil {
+		ran.Log.Error("InitiatingMessage is nil")
+		return
+	}
+	pWSRestartIndication := initiatingMessage.Value.PWSRestartIndication
+	if pWSRestartIndication == nil {
+		ran.Log.Error("PWSRestartIndication is nil")
+		return
+	}
+	ran.Log.Info("Handle PWS Restart Indication")
+	for _, ie := range pWSRestartIndication.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDCellIDListForRestart:
+			cellIDListForRestart = ie.Value.CellIDListForRestart
+			ran.Log.Trace("Decode IE CellIDListForRestart")
+		case ngapType.ProtocolIEIDGlobalRANNodeID:
+			ran.Log.Trace("Decode IE GlobalRANNodeID")
+		case ngapType.ProtocolIEIDTAIListForRestart:
+			tAIListForRestart = ie.Value.TAIListForRestart
+			ran.Log.Trace("Decode IE TAIListForRestart")
+		case ngapType.ProtocolIEIDEmergencyAreaIDListForRestart:
+			ran.Log.Trace("Decode IE EmergencyAreaIDListForRestart")
+		}
+	}
+
+	var taiList []models.Tai
+	if tAIListForRestart != nil {
+		for _, tAI := range tAIListForRestart.List {
+			taiList = append(taiList, taiToModels(tAI))
+		}
+	}
+	var ncgiList []models.Ncgi
+	var ecgiList []models.Ecgi
+	if cellIDListForRestart != nil {
+		switch cellIDListForRestart.Present {
+		case ngapType.CellIDListForRestartPresentNRCGIListforRestart:
+			ncgiList = nrCgiListToModels(cellIDListForRestart.NRCGIListforRestart)
+		case ngapType.CellIDListForRestartPresentEUTRACGIListforRestart:
+			ecgiList = eutraCgiListToModels(cellIDListForRestart.EUTRACGIListforRestart)
+		}
+	}
+	if len(taiList) == 0 && len(ncgiList) == 0 && len(ecgiList) == 0 {
+		ran.Log.Warn("PWS Restart Indication without TAI or cell restarted")
+		return
+	}
+
+	producer.RedeliverAlerts(ran, taiList, ncgiList, ecgiList)
+}
+
+// HandlePWSFailureIndication records the cells that failed in the delivery
+// report of the active alerts delivered to the RAN
+func HandlePWSFailureIndication(ran *context.EtafRan, message *ngapType.NGAPPDU) {
+	var pWSFailedCellIDList *ngapType.PWSFailedCellIDList
+
+	if ran == nil {
+		return
+	}
+	if message == nil {
+		ran.Log.Error("NGAP Message is nil")
+		return
+	}
+	initiatingMessage := message.InitiatingMessage
+	if initiatingMessage == nil {
+		ran.Log.Error("InitiatingMessage is nil")
+		return
+	}
+	pWSFailureIndication := initiatingMessage.Value.PWSFailureIndication
+	if pWSFailureIndication == nil {
+		ran.Log.Error("PWSFailureIndication is nil")
+		return
+	}
+	ran.Log.Info("Handle PWS Failure Indication")
+	for _, ie := range pWSFailureIndication.ProtocolIEs.List {
+		switch ie.Id.Value {
+		case ngapType.ProtocolIEIDPWSFailedCellIDList:
+			pWSFailedCellIDList = ie.Value.PWSFailedCellIDList
+			ran.Log.Trace("Decode IE PWSFailedCellIDList")
+			if pWSFailedCellIDList == nil {
+				ran.Log.Error("PWSFailedCellIDList is nil")
+				return
+			}
+		case ngapType.ProtocolIEIDGlobalRANNodeID:
+			ran.Log.Trace("Decode IE GlobalRANNodeID")
+		}
+	}
+	if pWSFailedCellIDList == nil {
+		ran.Log.Error("PWSFailedCellIDList is missing")
+		return
+	}
+
+	var failedCells []string
+	switch pWSFailedCellIDList.Present {
+	case ngapType.PWSFailedCellIDListPresentNRCGIPWSFailedList:
+		for _, ncgi := range nrCgiListToModels(pWSFailedCellIDList.NRCGIPWSFailedList) {
+			failedCells = append(failedCells, celldb.CellKey(celldb.RatNr, *ncgi.PlmnId, ncgi.NrCellId))
+		}
+	case ngapType.PWSFailedCellIDListPresentEUTRACGIPWSFailedList:
+		for _, ecgi := range eutraCgiListToModels(pWSFailedCellIDList.EUTRACGIPWSFailedList) {
+			failedCells = append(failedCells, celldb.CellKey(celldb.RatEutra, *ecgi.PlmnId, ecgi.EutraCellId))
+		}
+	}
+	ran.Log.Warnf("PWS failed in cells %v", failedCells)
+
+	ranNodeId := ran.RanNodeId()
+	for _, alert := range context.ETAF_Self().Alerts() {
+		if !alert.IsActive() || !deliveredTo(alert, ranNodeId) {
+			continue
+		}
+		alert.UpdateDelivery(ranNodeId, func(delivery *context.RanDelivery) {
+			delivery.Status = context.DeliveryFailed
+			for _, cell := range failedCells {
+				if !containsString(delivery.FailedCells, cell) {
+					delivery.FailedCells = append(delivery.FailedCells, cell)
+				}
+			}
+		})
+	}
+}
+
+// findAlert looks up the alert of a PWS procedure by its message identifier and
+// serial number
+func findAlert(ran *context.EtafRan, messageIdentifier *ngapType.MessageIdentifier,
+	serialNumber *ngapType.SerialNumber) *context.Alert {
+	if messageIdentifier == nil || serialNumber == nil {
+		ran.Log.Error("MessageIdentifier or SerialNumber is missing")
+		return nil
+	}
+	alert, ok := context.ETAF_Self().AlertFindByMessage(ngap_message.BitStringToUint16(messageIdentifier.Value),
+		ngap_message.BitStringToUint16(serialNumber.Value))
+	if !ok {
+		ran.Log.Warnf("No alert with MessageIdentifier[%d] SerialNumber[%d]",
+			ngap_message.BitStringToUint16(messageIdentifier.Value), ngap_message.BitStringToUint16(serialNumber.Value))
+		return nil
+	}
+	return alert
+}
+
+func taiToModels(tAI ngapType.TAI) models.Tai {
+	plmnId := ngapConvert.PlmnIdToModels(tAI.PLMNIdentity)
+	return models.Tai{
+		PlmnId: &plmnId,
+		Tac:    hex.EncodeToString(tAI.TAC.Value),
+	}
+}
+
+func nrCgiListToModels(nRCGIList *ngapType.NRCGIList) (ncgiList []models.Ncgi) {
+	if nRCGIList == nil {
+		return
+	}
+	for i := range nRCGIList.List {
+		nRCGI := &nRCGIList.List[i]
+		plmnId := ngapConvert.PlmnIdToModels(nRCGI.PLMNIdentity)
+		ncgiList = append(ncgiList, models.Ncgi{
+			PlmnId:   &plmnId,
+			NrCellId: ngapConvert.BitStringToHex(&nRCGI.NRCellIdentity.Value),
+		})
+	}
+	return
+}
+
+func eutraCgiListToModels(eUTRACGIList *ngapType.EUTRACGIList) (ecgiList []models.Ecgi) {
+	if eUTRACGIList == nil {
+		return
+	}
+	for i := range eUTRACGIList.List {
+		eUTRACGI := &eUTRACGIList.List[i]
+		plmnId := ngapConvert.PlmnIdToModels(eUTRACGI.PLMNIdentity)
+		ecgiList = append(ecgiList, models.Ecgi{
+			PlmnId:      &plmnId,
+			EutraCellId: ngapConvert.BitStringToHex(&eUTRACGI.EUTRACellIdentity.Value),
+		})
+	}
+	return
+}
+
+func deliveredTo(alert *context.Alert, ranNodeId string) bool {
+	for _, delivery := range alert.DeliveryReport() {
+		if delivery.RanNodeId == ranNodeId {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// findRanUe looks a RAN UE up by its RAN UE NGAP ID, else by its AMF UE NGAP ID
+func findRanUe(ran *context.EtafRan, aMFUENGAPID *ngapType.AMFUENGAPID,
+	rANUENGAPID *ngapType.RANUENGAPID) *context.RanUe {
+	var ranUe *context.RanUe
+	if rANUENGAPID != nil {
+		ranUe = ran.RanUeFindByRanUeNgapID(rANUENGAPID.Value)
+	}
+	if ranUe == nil && aMFUENGAPID != nil {
+		ranUe = context.ETAF_Self().RanUeFindByEtafUeNgapID(aMFUENGAPID.Value)
+	}
+	if ranUe == nil {
+		if rANUENGAPID != nil {
+			ran.Log.Errorf("No UE Context[RanUeNgapID: %d]", rANUENGAPID.Value)
+		} else {
+			ran.Log.Error("No UE Context")
+		}
+	}
+	return ranUe
+}
+
+// criticalityDiagnosticsToString describes the procedure and the IEs in error,
+// e.g. procedureCode=21 triggeringMessage=0 criticality=0 ies=[id=27 criticality=0 error=1]
+func criticalityDiagnosticsToString(criticalityDiagnostics *ngapType.CriticalityDiagnostics) string {
+	var description []string
+	if criticalityDiagnostics.ProcedureCode != nil {
+		description = append(description, fmt.Sprintf("procedureCode=%d", criticalityDiagnostics.ProcedureCode.Value))
+	}
+	if criticalityDiagnostics.TriggeringMessage != nil {
+		description = append(description,
+			fmt.Sprintf("triggeringMessage=%d", criticalityDiagnostics.TriggeringMessage.Value))
+	}
+	if criticalityDiagnostics.ProcedureCriticality != nil {
+		description = append(description,
+			fmt.Sprintf("criticality=%d", criticalityDiagnostics.ProcedureCriticality.Value))
+	}
+	if criticalityDiagnostics.IEsCriticalityDiagnostics != nil {
+		var ies []string
+		for _, item := range criticalityDiagnostics.IEsCriticalityDiagnostics.List {
+			ies = append(ies, fmt.Sprintf("id=%d criticality=%d error=%d", item.IEID.Value,
+				item.IECriticality.Value, item.TypeOfError.Value))
+		}
+		description = append(description, "ies=["+strings.Join(ies, ", ")+"]")
+	}
+	return strings.Join(description, " ")
+}
+
+// causeToString names the group of a cause with its value, e.g. radioNetwork/20
+func causeToString(cause *ngapType.Cause) string {
+	if cause == nil {
+		return "unspecified"
+	}
+	switch cause.Present {
+	case ngapType.CausePresentRadioNetwork:
+		return fmt.Sprintf("radioNetwork/%d", cause.RadioNetwork.Value)
+	case ngapType.CausePresentTransport:
+		return fmt.Sprintf("transport/%d", cause.Transport.Value)
+	case ngapType.CausePresentNas:
+		return fmt.Sprintf("nas/%d", cause.Nas.Value)
+	case ngapType.CausePresentProtocol:
+		return fmt.Sprintf("protocol/%d", cause.Protocol.Value)
+	case ngapType.CausePresentMisc:
+		return fmt.Sprintf("misc/%d", cause.Misc.Value)
+	default:
+		return "unspecified"
+	}
+}
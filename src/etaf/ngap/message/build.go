@@ -0,0 +1,536 @@
+package message
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf16"
+
+	"free5gc/lib/aper"
+	"free5gc/lib/ngap"
+	"free5gc/lib/ngap/ngapConvert"
+	"free5gc/lib/ngap/ngapType"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/context"
+)
+
+func BuildNGSetupResponse() ([]byte, error) {
+	etafSelf := context.ETAF_Self()
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentSuccessfulOutcome
+	pdu.SuccessfulOutcome = new(ngapType.SuccessfulOutcome)
+
+	successfulOutcome := pdu.SuccessfulOutcome
+	successfulOutcome.ProcedureCode.Value = ngapType.ProcedureCodeNGSetup
+	successfulOutcome.Criticality.Value = ngapType.CriticalityPresentReject
+	successfulOutcome.Value.Present = ngapType.SuccessfulOutcomePresentNGSetupResponse
+	successfulOutcome.Value.NGSetupResponse = new(ngapType.NGSetupResponse)
+
+	nGSetupResponse := successfulOutcome.Value.NGSetupResponse
+	nGSetupResponseIEs := &nGSetupResponse.ProtocolIEs
+
+	// AMFName
+	ie := ngapType.NGSetupResponseIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDAMFName
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.NGSetupResponseIEsPresentAMFName
+	ie.Value.AMFName = new(ngapType.AMFName)
+
+	aMFName := ie.Value.AMFName
+	aMFName.Value = etafSelf.Name
+
+	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
+
+	// ServedGUAMIList
+	ie = ngapType.NGSetupResponseIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDServedGUAMIList
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.NGSetupResponseIEsPresentServedGUAMIList
+	ie.Value.ServedGUAMIList = new(ngapType.ServedGUAMIList)
+
+	servedGUAMIList := ie.Value.ServedGUAMIList
+	for _, guami := range etafSelf.ServedGuamiList {
+		servedGUAMIItem := ngapType.ServedGUAMIItem{}
+		servedGUAMIItem.GUAMI = buildGUAMI(guami)
+		servedGUAMIList.List = append(servedGUAMIList.List, servedGUAMIItem)
+	}
+
+	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
+
+	// relativeAMFCapacity
+	ie = ngapType.NGSetupResponseIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDRelativeAMFCapacity
+	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+	ie.Value.Present = ngapType.NGSetupResponseIEsPresentRelativeAMFCapacity
+	ie.Value.RelativeAMFCapacity = new(ngapType.RelativeAMFCapacity)
+	ie.Value.RelativeAMFCapacity.Value = etafSelf.RelativeCapacity
+
+	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
+
+	// PLMNSupportList
+	ie = ngapType.NGSetupResponseIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDPLMNSupportList
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.NGSetupResponseIEsPresentPLMNSupportList
+	ie.Value.PLMNSupportList = new(ngapType.PLMNSupportList)
+
+	pLMNSupportList := ie.Value.PLMNSupportList
+	for _, plmnItem := range etafSelf.PlmnSupportList {
+		pLMNSupportItem := ngapType.PLMNSupportItem{}
+		pLMNSupportItem.PLMNIdentity = ngapConvert.PlmnIdToNgap(plmnItem.PlmnId)
+		for _, snssai := range plmnItem.SNssaiList {
+			sliceSupportItem := ngapType.SliceSupportItem{}
+			sliceSupportItem.SNSSAI = ngapConvert.SNssaiToNgap(snssai)
+			pLMNSupportItem.SliceSupportList.List = append(pLMNSupportItem.SliceSupportList.List, sliceSupportItem)
+		}
+		pLMNSupportList.List = append(pLMNSupportList.List, pLMNSupportItem)
+	}
+
+	nGSetupResponseIEs.List = append(nGSetupResponseIEs.List, ie)
+
+	return ngap.Encoder(pdu)
+}
+
+func BuildNGSetupFailure(cause ngapType.Cause) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentUnsuccessfulOutcome
+	pdu.UnsuccessfulOutcome = new(ngapType.UnsuccessfulOutcome)
+
+	unsuccessfulOutcome := pdu.UnsuccessfulOutcome
+	unsuccessfulOutcome.ProcedureCode.Value = ngapType.ProcedureCodeNGSetup
+	unsuccessfulOutcome.Criticality.Value = ngapType.CriticalityPresentReject
+	unsuccessfulOutcome.Value.Present = ngapType.UnsuccessfulOutcomePresentNGSetupFailure
+	unsuccessfulOutcome.Value.NGSetupFailure = new(ngapType.NGSetupFailure)
+
+	nGSetupFailure := unsuccessfulOutcome.Value.NGSetupFailure
+	nGSetupFailureIEs := &nGSetupFailure.ProtocolIEs
+
+	// Cause
+	ie := ngapType.NGSetupFailureIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDCause
+	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+	ie.Value.Present = ngapType.NGSetupFailureIEsPresentCause
+	ie.Value.Cause = &cause
+
+	nGSetupFailureIEs.List = append(nGSetupFailureIEs.List, ie)
+
+	return ngap.Encoder(pdu)
+}
+
+func buildGUAMI(guami models.Guami) (ngapGuami ngapType.GUAMI) {
+	ngapGuami.PLMNIdentity = ngapConvert.PlmnIdToNgap(*guami.PlmnId)
+	regionId, setId, ptrId := ngapConvert.AmfIdToNgap(guami.AmfId)
+	ngapGuami.AMFRegionID.Value = regionId
+	ngapGuami.AMFSetID.Value = setId
+	ngapGuami.AMFPointer.Value = ptrId
+	return
+}
+
+func buildTAI(tai models.Tai) (ngapTai ngapType.TAI) {
+	ngapTai.PLMNIdentity = ngapConvert.PlmnIdToNgap(*tai.PlmnId)
+	// the TAC was validated as hexadecimal with the area
+	ngapTai.TAC.Value, _ = hex.DecodeString(tai.Tac)
+	return
+}
+
+// Data Coding Scheme of the warning messages: UCS2, uncompressed (TS 23.038)
+const dataCodingSchemeUCS2 = 0x48
+
+// CB-Data pages of TS 23.041 9.4.2.2.5
+const (
+	maxCbsPages     = 15
+	cbsPageOctets   = 82
+	cbsPageChars    = cbsPageOctets / 2
+	maxWarningChars = maxCbsPages * cbsPageChars
+)
+
+func BuildWriteReplaceWarningRequest(alert *context.Alert, warningAreaList *ngapType.WarningAreaList) (
+	[]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
+	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
+
+	initiatingMessage := pdu.InitiatingMessage
+	initiatingMessage.ProcedureCode.Value = ngapType.ProcedureCodeWriteReplaceWarning
+	initiatingMessage.Criticality.Value = ngapType.CriticalityPresentReject
+	initiatingMessage.Value.Present = ngapType.InitiatingMessagePresentWriteReplaceWarningRequest
+	initiatingMessage.Value.WriteReplaceWarningRequest = new(ngapType.WriteReplaceWarningRequest)
+
+	writeReplaceWarningRequest := initiatingMessage.Value.WriteReplaceWarningRequest
+	writeReplaceWarningRequestIEs := &writeReplaceWarningRequest.ProtocolIEs
+
+	// Message Identifier
+	ie := ngapType.WriteReplaceWarningRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDMessageIdentifier
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentMessageIdentifier
+	ie.Value.MessageIdentifier = new(ngapType.MessageIdentifier)
+	ie.Value.MessageIdentifier.Value = uint16ToBitString(alert.MessageIdentifier)
+
+	writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+
+	// Serial Number
+	ie = ngapType.WriteReplaceWarningRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDSerialNumber
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentSerialNumber
+	ie.Value.SerialNumber = new(ngapType.SerialNumber)
+	ie.Value.SerialNumber.Value = uint16ToBitString(alert.SerialNumber)
+
+	writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+
+	// Warning Area List, all the cells of the RAN if absent
+	if warningAreaList != nil {
+		ie = ngapType.WriteReplaceWarningRequestIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDWarningAreaList
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentWarningAreaList
+		ie.Value.WarningAreaList = warningAreaList
+
+		writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+	}
+
+	// Repetition Period
+	ie = ngapType.WriteReplaceWarningRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDRepetitionPeriod
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentRepetitionPeriod
+	ie.Value.RepetitionPeriod = new(ngapType.RepetitionPeriod)
+	ie.Value.RepetitionPeriod.Value = int64(alert.RepetitionPeriod)
+
+	writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+
+	// Number of Broadcasts Requested
+	ie = ngapType.WriteReplaceWarningRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDNumberOfBroadcastsRequested
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentNumberOfBroadcastsRequested
+	ie.Value.NumberOfBroadcastsRequested = new(ngapType.NumberOfBroadcastsRequested)
+	ie.Value.NumberOfBroadcastsRequested.Value = int64(alert.NumberOfBroadcasts)
+
+	writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+
+	// Data Coding Scheme
+	ie = ngapType.WriteReplaceWarningRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDDataCodingScheme
+	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+	ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentDataCodingScheme
+	ie.Value.DataCodingScheme = new(ngapType.DataCodingScheme)
+	ie.Value.DataCodingScheme.Value = aper.BitString{
+		Bytes:     []byte{dataCodingSchemeUCS2},
+		BitLength: 8,
+	}
+
+	writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+
+	// Warning Message Contents
+	contents, err := EncodeWarningMessage(alert.Message)
+	if err != nil {
+		return nil, err
+	}
+	ie = ngapType.WriteReplaceWarningRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDWarningMessageContents
+	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+	ie.Value.Present = ngapType.WriteReplaceWarningRequestIEsPresentWarningMessageContents
+	ie.Value.WarningMessageContents = new(ngapType.WarningMessageContents)
+	ie.Value.WarningMessageContents.Value = contents
+
+	writeReplaceWarningRequestIEs.List = append(writeReplaceWarningRequestIEs.List, ie)
+
+	return ngap.Encoder(pdu)
+}
+
+func BuildPWSCancelRequest(alert *context.Alert, warningAreaList *ngapType.WarningAreaList) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
+	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
+
+	initiatingMessage := pdu.InitiatingMessage
+	initiatingMessage.ProcedureCode.Value = ngapType.ProcedureCodePWSCancel
+	initiatingMessage.Criticality.Value = ngapType.CriticalityPresentReject
+	initiatingMessage.Value.Present = ngapType.InitiatingMessagePresentPWSCancelRequest
+	initiatingMessage.Value.PWSCancelRequest = new(ngapType.PWSCancelRequest)
+
+	pWSCancelRequest := initiatingMessage.Value.PWSCancelRequest
+	pWSCancelRequestIEs := &pWSCancelRequest.ProtocolIEs
+
+	// Message Identifier
+	ie := ngapType.PWSCancelRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDMessageIdentifier
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSCancelRequestIEsPresentMessageIdentifier
+	ie.Value.MessageIdentifier = new(ngapType.MessageIdentifier)
+	ie.Value.MessageIdentifier.Value = uint16ToBitString(alert.MessageIdentifier)
+
+	pWSCancelRequestIEs.List = append(pWSCancelRequestIEs.List, ie)
+
+	// Serial Number
+	ie = ngapType.PWSCancelRequestIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDSerialNumber
+	ie.Criticality.Value = ngapType.CriticalityPresentReject
+	ie.Value.Present = ngapType.PWSCancelRequestIEsPresentSerialNumber
+	ie.Value.SerialNumber = new(ngapType.SerialNumber)
+	ie.Value.SerialNumber.Value = uint16ToBitString(alert.SerialNumber)
+
+	pWSCancelRequestIEs.List = append(pWSCancelRequestIEs.List, ie)
+
+	// Warning Area List
+	if warningAreaList != nil {
+		ie = ngapType.PWSCancelRequestIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDWarningAreaList
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.PWSCancelRequestIEsPresentWarningAreaList
+		ie.Value.WarningAreaList = warningAreaList
+
+		pWSCancelRequestIEs.List = append(pWSCancelRequestIEs.List, ie)
+	}
+
+	return ngap.Encoder(pdu)
+}
+
+// BuildWarningAreaList returns the TAIs if any, else the NR cells if any, else the
+// E-UTRA cells, as the list is a choice of one of them. The warning area of an
+// alert has TAIs or cells, and a node serves the cells of its RAT only, so a
+// single list is given for a node.
+func BuildWarningAreaList(taiList []models.Tai, ncgiList []models.Ncgi, ecgiList []models.Ecgi) (
+	warningAreaList ngapType.WarningAreaList) {
+	switch {
+	case len(taiList) > 0:
+		warningAreaList.Present = ngapType.WarningAreaListPresentTAIListForWarning
+		warningAreaList.TAIListForWarning = new(ngapType.TAIListForWarning)
+		for _, tai := range taiList {
+			warningAreaList.TAIListForWarning.List = append(warningAreaList.TAIListForWarning.List, buildTAI(tai))
+		}
+	case len(ncgiList) > 0:
+		warningAreaList.Present = ngapType.WarningAreaListPresentNRCGIListForWarning
+		warningAreaList.NRCGIListForWarning = new(ngapType.NRCGIListForWarning)
+		for _, ncgi := range ncgiList {
+			nRCGI := ngapType.NRCGI{}
+			nRCGI.PLMNIdentity = ngapConvert.PlmnIdToNgap(*ncgi.PlmnId)
+			nRCGI.NRCellIdentity.Value = ngapConvert.HexToBitString(ncgi.NrCellId, 36)
+			warningAreaList.NRCGIListForWarning.List = append(warningAreaList.NRCGIListForWarning.List, nRCGI)
+		}
+	case len(ecgiList) > 0:
+		warningAreaList.Present = ngapType.WarningAreaListPresentEUTRACGIListForWarning
+		warningAreaList.EUTRACGIListForWarning = new(ngapType.EUTRACGIListForWarning)
+		for _, ecgi := range ecgiList {
+			eUTRACGI := ngapType.EUTRACGI{}
+			eUTRACGI.PLMNIdentity = ngapConvert.PlmnIdToNgap(*ecgi.PlmnId)
+			eUTRACGI.EUTRACellIdentity.Value = ngapConvert.HexToBitString(ecgi.EutraCellId, 28)
+			warningAreaList.EUTRACGIListForWarning.List =
+				append(warningAreaList.EUTRACGIListForWarning.List, eUTRACGI)
+		}
+	}
+	return
+}
+
+// EncodeWarningMessage encodes a message in UCS2 as the CB-Data of TS 23.041: the
+// number of pages followed by the pages, each padded to 82 octets and followed by
+// the length of its content
+func EncodeWarningMessage(message string) ([]byte, error) {
+	chars := utf16.Encode([]rune(message))
+	if len(chars) == 0 || len(chars) > maxWarningChars {
+		return nil, fmt.Errorf("the warning message must have 1 to %d UCS2 characters", maxWarningChars)
+	}
+	pages := (len(chars) + cbsPageChars - 1) / cbsPageChars
+	contents := make([]byte, 1, 1+pages*(cbsPageOctets+1))
+	contents[0] = byte(pages)
+	for i := 0; i < pages; i++ {
+		page := make([]byte, cbsPageOctets+1)
+		n := 0
+		for ; n < cbsPageChars && i*cbsPageChars+n < len(chars); n++ {
+			binary.BigEndian.PutUint16(page[2*n:], chars[i*cbsPageChars+n])
+		}
+		page[cbsPageOctets] = byte(2 * n)
+		contents = append(contents, page...)
+	}
+	return contents, nil
+}
+
+func uint16ToBitString(value int32) aper.BitString {
+	return aper.BitString{
+		Bytes:     []byte{byte(value >> 8), byte(value)},
+		BitLength: 16,
+	}
+}
+
+// BitStringToUint16 decodes the Message Identifier and Serial Number IEs
+func BitStringToUint16(bitString aper.BitString) int32 {
+	if len(bitString.Bytes) < 2 {
+		return -1
+	}
+	return int32(bitString.Bytes[0])<<8 | int32(bitString.Bytes[1])
+}
+
+func BuildNGResetAcknowledge(partOfNGInterface *ngapType.UEAssociatedLogicalNGConnectionList,
+	diagnostics *ngapType.CriticalityDiagnostics) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentSuccessfulOutcome
+	pdu.SuccessfulOutcome = new(ngapType.SuccessfulOutcome)
+
+	successfulOutcome := pdu.SuccessfulOutcome
+	successfulOutcome.ProcedureCode.Value = ngapType.ProcedureCodeNGReset
+	successfulOutcome.Criticality.Value = ngapType.CriticalityPresentReject
+	successfulOutcome.Value.Present = ngapType.SuccessfulOutcomePresentNGResetAcknowledge
+	successfulOutcome.Value.NGResetAcknowledge = new(ngapType.NGResetAcknowledge)
+
+	nGResetAcknowledge := successfulOutcome.Value.NGResetAcknowledge
+	nGResetAcknowledgeIEs := &nGResetAcknowledge.ProtocolIEs
+
+	// UE-associated Logical NG-connection List, for a partial reset
+	if partOfNGInterface != nil && len(partOfNGInterface.List) > 0 {
+		ie := ngapType.NGResetAcknowledgeIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDUEAssociatedLogicalNGConnectionList
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.NGResetAcknowledgeIEsPresentUEAssociatedLogicalNGConnectionList
+		ie.Value.UEAssociatedLogicalNGConnectionList = new(ngapType.UEAssociatedLogicalNGConnectionList)
+		ie.Value.UEAssociatedLogicalNGConnectionList.List = partOfNGInterface.List
+
+		nGResetAcknowledgeIEs.List = append(nGResetAcknowledgeIEs.List, ie)
+	}
+
+	// Criticality Diagnostics
+	if diagnostics != nil {
+		ie := ngapType.NGResetAcknowledgeIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDCriticalityDiagnostics
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.NGResetAcknowledgeIEsPresentCriticalityDiagnostics
+		ie.Value.CriticalityDiagnostics = diagnostics
+
+		nGResetAcknowledgeIEs.List = append(nGResetAcknowledgeIEs.List, ie)
+	}
+
+	return ngap.Encoder(pdu)
+}
+
+func BuildErrorIndication(amfUeNgapId, ranUeNgapId *int64, cause *ngapType.Cause,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentInitiatingMessage
+	pdu.InitiatingMessage = new(ngapType.InitiatingMessage)
+
+	initiatingMessage := pdu.InitiatingMessage
+	initiatingMessage.ProcedureCode.Value = ngapType.ProcedureCodeErrorIndication
+	initiatingMessage.Criticality.Value = ngapType.CriticalityPresentIgnore
+	initiatingMessage.Value.Present = ngapType.InitiatingMessagePresentErrorIndication
+	initiatingMessage.Value.ErrorIndication = new(ngapType.ErrorIndication)
+
+	errorIndication := initiatingMessage.Value.ErrorIndication
+	errorIndicationIEs := &errorIndication.ProtocolIEs
+
+	if cause == nil && criticalityDiagnostics == nil {
+		return nil, fmt.Errorf("[Build Error Indication] shall contain at least either the Cause or the " +
+			"Criticality Diagnostics")
+	}
+
+	if amfUeNgapId != nil {
+		ie := ngapType.ErrorIndicationIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDAMFUENGAPID
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.ErrorIndicationIEsPresentAMFUENGAPID
+		ie.Value.AMFUENGAPID = new(ngapType.AMFUENGAPID)
+		ie.Value.AMFUENGAPID.Value = *amfUeNgapId
+
+		errorIndicationIEs.List = append(errorIndicationIEs.List, ie)
+	}
+
+	if ranUeNgapId != nil {
+		ie := ngapType.ErrorIndicationIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDRANUENGAPID
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.ErrorIndicationIEsPresentRANUENGAPID
+		ie.Value.RANUENGAPID = new(ngapType.RANUENGAPID)
+		ie.Value.RANUENGAPID.Value = *ranUeNgapId
+
+		errorIndicationIEs.List = append(errorIndicationIEs.List, ie)
+	}
+
+	if cause != nil {
+		ie := ngapType.ErrorIndicationIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDCause
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.ErrorIndicationIEsPresentCause
+		ie.Value.Cause = cause
+
+		errorIndicationIEs.List = append(errorIndicationIEs.List, ie)
+	}
+
+	if criticalityDiagnostics != nil {
+		ie := ngapType.ErrorIndicationIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDCriticalityDiagnostics
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.ErrorIndicationIEsPresentCriticalityDiagnostics
+		ie.Value.CriticalityDiagnostics = criticalityDiagnostics
+
+		errorIndicationIEs.List = append(errorIndicationIEs.List, ie)
+	}
+
+	return ngap.Encoder(pdu)
+}
+
+func BuildRanConfigurationUpdateAcknowledge(
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentSuccessfulOutcome
+	pdu.SuccessfulOutcome = new(ngapType.SuccessfulOutcome)
+
+	successfulOutcome := pdu.SuccessfulOutcome
+	successfulOutcome.ProcedureCode.Value = ngapType.ProcedureCodeRANConfigurationUpdate
+	successfulOutcome.Criticality.Value = ngapType.CriticalityPresentReject
+	successfulOutcome.Value.Present = ngapType.SuccessfulOutcomePresentRANConfigurationUpdateAcknowledge
+	successfulOutcome.Value.RANConfigurationUpdateAcknowledge = new(ngapType.RANConfigurationUpdateAcknowledge)
+
+	rANConfigurationUpdateAcknowledge := successfulOutcome.Value.RANConfigurationUpdateAcknowledge
+	rANConfigurationUpdateAcknowledgeIEs := &rANConfigurationUpdateAcknowledge.ProtocolIEs
+
+	// Criticality Diagnostics (optional)
+	if criticalityDiagnostics != nil {
+		ie := ngapType.RANConfigurationUpdateAcknowledgeIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDCriticalityDiagnostics
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.RANConfigurationUpdateAcknowledgeIEsPresentCriticalityDiagnostics
+		ie.Value.CriticalityDiagnostics = criticalityDiagnostics
+
+		rANConfigurationUpdateAcknowledgeIEs.List = append(rANConfigurationUpdateAcknowledgeIEs.List, ie)
+	}
+
+	return ngap.Encoder(pdu)
+}
+
+func BuildRanConfigurationUpdateFailure(cause ngapType.Cause,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics) ([]byte, error) {
+	var pdu ngapType.NGAPPDU
+	pdu.Present = ngapType.NGAPPDUPresentUnsuccessfulOutcome
+	pdu.UnsuccessfulOutcome = new(ngapType.UnsuccessfulOutcome)
+
+	unsuccessfulOutcome := pdu.UnsuccessfulOutcome
+	unsuccessfulOutcome.ProcedureCode.Value = ngapType.ProcedureCodeRANConfigurationUpdate
+	unsuccessfulOutcome.Criticality.Value = ngapType.CriticalityPresentReject
+	unsuccessfulOutcome.Value.Present = ngapType.UnsuccessfulOutcomePresentRANConfigurationUpdateFailure
+	unsuccessfulOutcome.Value.RANConfigurationUpdateFailure = new(ngapType.RANConfigurationUpdateFailure)
+
+	rANConfigurationUpdateFailure := unsuccessfulOutcome.Value.RANConfigurationUpdateFailure
+	rANConfigurationUpdateFailureIEs := &rANConfigurationUpdateFailure.ProtocolIEs
+
+	// Cause
+	ie := ngapType.RANConfigurationUpdateFailureIEs{}
+	ie.Id.Value = ngapType.ProtocolIEIDCause
+	ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+	ie.Value.Present = ngapType.RANConfigurationUpdateFailureIEsPresentCause
+	ie.Value.Cause = &cause
+
+	rANConfigurationUpdateFailureIEs.List = append(rANConfigurationUpdateFailureIEs.List, ie)
+
+	// Criticality Diagnostics (optional)
+	if criticalityDiagnostics != nil {
+		ie = ngapType.RANConfigurationUpdateFailureIEs{}
+		ie.Id.Value = ngapType.ProtocolIEIDCriticalityDiagnostics
+		ie.Criticality.Value = ngapType.CriticalityPresentIgnore
+		ie.Value.Present = ngapType.RANConfigurationUpdateFailureIEsPresentCriticalityDiagnostics
+		ie.Value.CriticalityDiagnostics = criticalityDiagnostics
+
+		rANConfigurationUpdateFailureIEs.List = append(rANConfigurationUpdateFailureIEs.List, ie)
+	}
+
+	return ngap.Encoder(pdu)
+}
@@ -0,0 +1,61 @@
+package message
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestEncodeWarningMessage(t *testing.T) {
+	testCases := []struct {
+		name    string
+		message string
+		pages   int
+		last    int // UCS2 characters on the last page
+		err     bool
+	}{
+		{name: "empty", message: "", err: true},
+		{name: "one character", message: "A", pages: 1, last: 1},
+		{name: "one full page", message: strings.Repeat("a", cbsPageChars), pages: 1, last: cbsPageChars},
+		{name: "two pages", message: strings.Repeat("a", cbsPageChars+1), pages: 2, last: 1},
+		{name: "non ASCII", message: "Évacuez", pages: 1, last: 7},
+		{name: "surrogate pair", message: "\U0001F6A8", pages: 1, last: 2},
+		{name: "longest", message: strings.Repeat("a", maxWarningChars), pages: maxCbsPages, last: cbsPageChars},
+		{name: "too long", message: strings.Repeat("a", maxWarningChars+1), err: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			contents, err := EncodeWarningMessage(testCase.message)
+			if testCase.err {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncodeWarningMessage error: %+v", err)
+			}
+			if len(contents) != 1+testCase.pages*(cbsPageOctets+1) {
+				t.Fatalf("%d octets, expected %d pages", len(contents), testCase.pages)
+			}
+			if int(contents[0]) != testCase.pages {
+				t.Errorf("number of pages %d, expected %d", contents[0], testCase.pages)
+			}
+			for i := 0; i < testCase.pages; i++ {
+				page := contents[1+i*(cbsPageOctets+1) : 1+(i+1)*(cbsPageOctets+1)]
+				length := 2 * cbsPageChars
+				if i == testCase.pages-1 {
+					length = 2 * testCase.last
+				}
+				if int(page[cbsPageOctets]) != length {
+					t.Errorf("page %d information length %d, expected %d", i+1, page[cbsPageOctets], length)
+				}
+			}
+			if first, expected := binary.BigEndian.Uint16(contents[1:]),
+				utf16.Encode([]rune(testCase.message))[0]; first != expected {
+				t.Errorf("first character %04x, expected %04x", first, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package message
+
+import (
+	"free5gc/lib/ngap/ngapType"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+)
+
+func SendToRan(ran *context.EtafRan, packet []byte) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			logger.NgapLog.Warnf("Send error, RAN may have been lost already: %+v", err)
+		}
+	}()
+
+	if ran == nil {
+		logger.NgapLog.Error("Ran is nil")
+		return
+	}
+
+	if len(packet) == 0 {
+		ran.Log.Error("packet len is 0")
+		return
+	}
+
+	if ran.Conn == nil {
+		ran.Log.Error("Ran conn is nil")
+		return
+	}
+
+	if ran.Conn.RemoteAddr() == nil {
+		ran.Log.Error("Ran addr is nil")
+		return
+	}
+
+	ran.Log.Debugf("Send NGAP message To Ran")
+
+	if n, err := ran.Conn.Write(packet); err != nil {
+		ran.Log.Errorf("Send error: %+v", err)
+		return
+	} else {
+		ran.Log.Debugf("Write %d bytes", n)
+	}
+}
+
+func SendNGSetupResponse(ran *context.EtafRan) {
+	ran.Log.Info("Send NG-Setup response")
+
+	pkt, err := BuildNGSetupResponse()
+	if err != nil {
+		ran.Log.Errorf("Build NGSetupResponse failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendNGSetupFailure(ran *context.EtafRan, cause ngapType.Cause) {
+	ran.Log.Info("Send NG-Setup failure")
+
+	if cause.Present == ngapType.CausePresentNothing {
+		ran.Log.Errorf("Cause present is nil")
+		return
+	}
+
+	pkt, err := BuildNGSetupFailure(cause)
+	if err != nil {
+		ran.Log.Errorf("Build NGSetupFailure failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendWriteReplaceWarningRequest(ran *context.EtafRan, alert *context.Alert,
+	warningAreaList *ngapType.WarningAreaList) {
+	ran.Log.Infof("Send Write-Replace Warning Request for alert[%s]", alert.Id)
+
+	pkt, err := BuildWriteReplaceWarningRequest(alert, warningAreaList)
+	if err != nil {
+		ran.Log.Errorf("Build WriteReplaceWarningRequest failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendPWSCancelRequest(ran *context.EtafRan, alert *context.Alert, warningAreaList *ngapType.WarningAreaList) {
+	ran.Log.Infof("Send PWS Cancel Request for alert[%s]", alert.Id)
+
+	pkt, err := BuildPWSCancelRequest(alert, warningAreaList)
+	if err != nil {
+		ran.Log.Errorf("Build PWSCancelRequest failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendNGResetAcknowledge(ran *context.EtafRan, partOfNGInterface *ngapType.UEAssociatedLogicalNGConnectionList,
+	diagnostics *ngapType.CriticalityDiagnostics) {
+	ran.Log.Info("Send NG Reset Acknowledge")
+
+	if partOfNGInterface != nil && len(partOfNGInterface.List) == 0 {
+		ran.Log.Error("length of partOfNGInterface is 0")
+		return
+	}
+
+	pkt, err := BuildNGResetAcknowledge(partOfNGInterface, diagnostics)
+	if err != nil {
+		ran.Log.Errorf("Build NGResetAcknowledge failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendErrorIndication(ran *context.EtafRan, amfUeNgapId, ranUeNgapId *int64, cause *ngapType.Cause,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
+	ran.Log.Info("Send Error Indication")
+
+	pkt, err := BuildErrorIndication(amfUeNgapId, ranUeNgapId, cause, criticalityDiagnostics)
+	if err != nil {
+		ran.Log.Errorf("Build ErrorIndication failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendRanConfigurationUpdateAcknowledge(ran *context.EtafRan,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
+	ran.Log.Info("Send RAN Configuration Update Acknowledge")
+
+	pkt, err := BuildRanConfigurationUpdateAcknowledge(criticalityDiagnostics)
+	if err != nil {
+		ran.Log.Errorf("Build RanConfigurationUpdateAcknowledge failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
+
+func SendRanConfigurationUpdateFailure(ran *context.EtafRan, cause ngapType.Cause,
+	criticalityDiagnostics *ngapType.CriticalityDiagnostics) {
+	ran.Log.Info("Send RAN Configuration Update Failure")
+
+	pkt, err := BuildRanConfigurationUpdateFailure(cause, criticalityDiagnostics)
+	if err != nil {
+		ran.Log.Errorf("Build RanConfigurationUpdateFailure failed : %s", err.Error())
+		return
+	}
+	SendToRan(ran, pkt)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"encoding/hex"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"git.cs.nctu.edu.tw/calee/sctp"
+
+	"free5gc/lib/ngap"
+	"free5gc/src/etaf/logger"
+)
+
+// NGAPHandler handles an NGAP message received on an association; an empty
+// message means the association is closed
+type NGAPHandler func(conn net.Conn, msg []byte)
+
+const readBufSize uint32 = 8192
+
+// set default read timeout to 2 seconds
+var readTimeout syscall.Timeval = syscall.Timeval{Sec: 2, Usec: 0}
+
+var (
+	sctpListener *sctp.SCTPListener
+	connections  sync.Map
+)
+
+var sctpConfig sctp.SocketConfig = sctp.SocketConfig{
+	InitMsg:   sctp.InitMsg{NumOstreams: 3, MaxInstreams: 5, MaxAttempts: 2, MaxInitTimeout: 2},
+	RtoInfo:   &sctp.RtoInfo{SrtoAssocID: 0, SrtoInitial: 500, SrtoMax: 1500, StroMin: 100},
+	AssocInfo: &sctp.AssocInfo{AsocMaxRxt: 4},
+}
+
+func Run(addresses []string, port int, handler NGAPHandler) {
+	ips := []net.IPAddr{}
+	for _, addr := range addresses {
+		if netAddr, err := net.ResolveIPAddr("ip", addr); err != nil {
+			logger.NgapLog.Errorf("Error resolving address '%s': %v", addr, err)
+		} else {
+			logger.NgapLog.Debugf("Resolved address '%s' to %s", addr, netAddr)
+			ips = append(ips, *netAddr)
+		}
+	}
+
+	addr := &sctp.SCTPAddr{
+		IPAddrs: ips,
+		Port:    port,
+	}
+
+	go listenAndServe(addr, handler)
+}
+
+func listenAndServe(addr *sctp.SCTPAddr, handler NGAPHandler) {
+	if listener, err := sctpConfig.Listen("sctp", addr); err != nil {
+		logger.NgapLog.Errorf("Failed to listen: %+v", err)
+		return
+	} else {
+		sctpListener = listener
+	}
+
+	logger.NgapLog.Infof("Listen on %s", sctpListener.Addr())
+
+	for {
+		newConn, err := sctpListener.AcceptSCTP()
+		if err != nil {
+			switch err {
+			case syscall.EINTR, syscall.EAGAIN:
+				logger.NgapLog.Debugf("AcceptSCTP: %+v", err)
+				continue
+			case syscall.EINVAL, syscall.EBADF:
+				// the listener was closed by Stop
+				return
+			default:
+				logger.NgapLog.Errorf("Failed to accept: %+v", err)
+				continue
+			}
+		}
+
+		var info *sctp.SndRcvInfo
+		if infoTmp, err := newConn.GetDefaultSentParam(); err != nil {
+			logger.NgapLog.Errorf("Get default sent param error: %+v, accept failed", err)
+			if err = newConn.Close(); err != nil {
+				logger.NgapLog.Errorf("Close error: %+v", err)
+			}
+			continue
+		} else {
+			info = infoTmp
+			logger.NgapLog.Debugf("Get default sent param[value: %+v]", info)
+		}
+
+		info.PPID = ngap.PPID
+		if err := newConn.SetDefaultSentParam(info); err != nil {
+			logger.NgapLog.Errorf("Set default sent param error: %+v, accept failed", err)
+			if err = newConn.Close(); err != nil {
+				logger.NgapLog.Errorf("Close error: %+v", err)
+			}
+			continue
+		} else {
+			logger.NgapLog.Debugf("Set default sent param[value: %+v]", info)
+		}
+
+		events := sctp.SCTP_EVENT_DATA_IO | sctp.SCTP_EVENT_SHUTDOWN | sctp.SCTP_EVENT_ASSOCIATION
+		if err := newConn.SubscribeEvents(events); err != nil {
+			logger.NgapLog.Errorf("Failed to accept: %+v", err)
+			if err = newConn.Close(); err != nil {
+				logger.NgapLog.Errorf("Close error: %+v", err)
+			}
+			continue
+		} else {
+			logger.NgapLog.Debugln("Subscribe SCTP event[DATA_IO, SHUTDOWN_EVENT, ASSOCIATION_CHANGE]")
+		}
+
+		if err := newConn.SetReadBuffer(int(readBufSize)); err != nil {
+			logger.NgapLog.Errorf("Set read buffer error: %+v, accept failed", err)
+			if err = newConn.Close(); err != nil {
+				logger.NgapLog.Errorf("Close error: %+v", err)
+			}
+			continue
+		} else {
+			logger.NgapLog.Debugf("Set read buffer to %d bytes", readBufSize)
+		}
+
+		if err := newConn.SetReadTimeout(readTimeout); err != nil {
+			logger.NgapLog.Errorf("Set read timeout error: %+v, accept failed", err)
+			if err = newConn.Close(); err != nil {
+				logger.NgapLog.Errorf("Close error: %+v", err)
+			}
+			continue
+		} else {
+			logger.NgapLog.Debugf("Set read timeout: %+v", readTimeout)
+		}
+
+		logger.NgapLog.Infof("[ETAF] SCTP Accept from: %s", newConn.RemoteAddr().String())
+		connections.Store(newConn, newConn)
+
+		go handleConnection(newConn, readBufSize, handler)
+	}
+}
+
+func Stop() {
+	logger.NgapLog.Infof("Close SCTP server...")
+	if sctpListener == nil {
+		return
+	}
+	if err := sctpListener.Close(); err != nil {
+		logger.NgapLog.Error(err)
+		logger.NgapLog.Infof("SCTP server may not close normally.")
+	}
+
+	connections.Range(func(key, value interface{}) bool {
+		conn := value.(net.Conn)
+		if err := conn.Close(); err != nil {
+			logger.NgapLog.Error(err)
+		}
+		return true
+	})
+
+	logger.NgapLog.Infof("SCTP server closed")
+}
+
+func handleConnection(conn *sctp.SCTPConn, bufsize uint32, handler NGAPHandler) {
+	defer func() {
+		// the association is gone, so is the RAN
+		handler(conn, nil)
+		// if ETAF call Stop(), then conn.Close() will return EBADF because conn has been closed inside Stop()
+		if err := conn.Close(); err != nil && err != syscall.EBADF {
+			logger.NgapLog.Errorf("close connection error: %+v", err)
+		}
+		connections.Delete(conn)
+	}()
+
+	for {
+		buf := make([]byte, bufsize)
+
+		n, info, notification, err := conn.SCTPRead(buf)
+		if err != nil {
+			switch err {
+			case io.EOF, io.ErrUnexpectedEOF:
+				logger.NgapLog.Debugln("Read EOF from client")
+				return
+			case syscall.EAGAIN:
+				logger.NgapLog.Debugln("SCTP read timeout")
+				continue
+			case syscall.EINTR:
+				logger.NgapLog.Debugf("SCTPRead: %+v", err)
+				continue
+			case syscall.EBADF:
+				logger.NgapLog.Debugln("Connection closed by ETAF")
+				return
+			default:
+				logger.NgapLog.Errorf("Handle connection[addr: %+v] error: %+v", conn.RemoteAddr(), err)
+				return
+			}
+		}
+
+		if notification != nil {
+			if associationClosed(conn, notification) {
+				return
+			}
+		} else {
+			if info == nil || info.PPID != ngap.PPID {
+				logger.NgapLog.Warnln("Received SCTP PPID != 60, discard this packet")
+				continue
+			}
+
+			logger.NgapLog.Tracef("Read %d bytes", n)
+			logger.NgapLog.Tracef("Packet content:\n%+v", hex.Dump(buf[:n]))
+
+			handler(conn, buf[:n])
+		}
+	}
+}
+
+// associationClosed reports whether the notification ends the association, on a
+// shutdown by the RAN or on the loss of the association
+func associationClosed(conn *sctp.SCTPConn, notification sctp.Notification) bool {
+	switch notification.Type() {
+	case sctp.SCTP_ASSOC_CHANGE:
+		event := notification.(*sctp.SCTPAssocChangeEvent)
+		switch event.State() {
+		case sctp.SCTP_COMM_LOST:
+			logger.NgapLog.Infof("SCTP association[addr: %+v] lost", conn.RemoteAddr())
+			return true
+		case sctp.SCTP_SHUTDOWN_COMP:
+			logger.NgapLog.Infof("SCTP association[addr: %+v] shut down", conn.RemoteAddr())
+			return true
+		default:
+			logger.NgapLog.Debugf("SCTP association[addr: %+v] state changed: 0x%x", conn.RemoteAddr(),
+				event.State())
+		}
+	case sctp.SCTP_SHUTDOWN_EVENT:
+		logger.NgapLog.Infof("SCTP association[addr: %+v] shutting down", conn.RemoteAddr())
+		return true
+	default:
+		logger.NgapLog.Debugf("Received sctp notification[type 0x%x]", notification.Type())
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"free5gc/lib/MongoDBLibrary"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+)
+
+const deadLetterCollName = "etaf.notificationDeadLetter"
+
+func init() {
+	privacy.RegisterPurger("notification dead letters", PurgeDeadLetters)
+}
+
+// DeadLetter records a notification given up on
+type DeadLetter struct {
+	SessionId       string       `json:"sessionId" bson:"sessionId"`
+	NotificationUri string       `json:"notificationUri" bson:"notificationUri"`
+	Notification    Notification `json:"notification" bson:"notification"`
+	Attempts        int          `json:"attempts" bson:"attempts"`
+	LastError       string       `json:"lastError" bson:"lastError"`
+	Time            time.Time    `json:"time" bson:"time"`
+	CorrelationId   string       `json:"correlationId,omitempty" bson:"correlationId,omitempty"`
+}
+
+func deadLetter(j *job, attempts int, lastErr string) {
+	j.session.UpdateDelivery(func(status *etaf_context.DeliveryStatus) {
+		status.DeadLettered++
+		status.LastError = lastErr
+	})
+	logger.WithCorrelationID(logger.CallbackLog, j.correlationID).WithField("sessionId", j.session.Id).
+		Errorf("Notification to [%s] dead-lettered: %s", j.session.NotificationUri, lastErr)
+
+	record := DeadLetter{
+		SessionId:       j.session.Id,
+		NotificationUri: j.session.NotificationUri,
+		Notification:    j.notification,
+		Attempts:        attempts,
+		LastError:       lastErr,
+		Time:            time.Now().UTC(),
+		CorrelationId:   j.correlationID,
+	}
+	if _, err := deadLetterCollection().InsertOne(context.Background(), record); err != nil {
+		logger.CallbackLog.Errorf("Store dead letter error: %+v", err)
+	}
+}
+
+// DeadLetters returns the dead-lettered notifications of a tracking session, the
+// most recent first
+func DeadLetters(sessionId string, limit int64) ([]DeadLetter, error) {
+	ctx := context.Background()
+	findOptions := options.Find().SetSort(bson.M{"time": -1})
+	if limit > 0 {
+		findOptions.SetLimit(limit)
+	}
+	cursor, err := deadLetterCollection().Find(ctx, bson.M{"sessionId": sessionId}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("Find dead letters error: %+v", err)
+	}
+	defer cursor.Close(ctx)
+
+	deadLetters := []DeadLetter{}
+	for cursor.Next(ctx) {
+		var record DeadLetter
+		if err := cursor.Decode(&record); err != nil {
+			return nil, fmt.Errorf("Decode dead letter error: %+v", err)
+		}
+		deadLetters = append(deadLetters, record)
+	}
+	return deadLetters, cursor.Err()
+}
+
+// PurgeDeadLetters drops the notifications dead-lettered before purgeBefore and
+// returns how many were dropped
+func PurgeDeadLetters(purgeBefore time.Time) (int64, error) {
+	result, err := deadLetterCollection().DeleteMany(context.Background(),
+		bson.M{"time": bson.M{"$lt": purgeBefore}})
+	if err != nil {
+		return 0, fmt.Errorf("Purge dead letters error: %+v", err)
+	}
+	return result.DeletedCount, nil
+}
+
+func deadLetterCollection() *mongo.Collection {
+	return MongoDBLibrary.Client.Database(factory.EtafConfig.Configuration.MongoDBName).Collection(deadLetterCollName)
+}
@@ -0,0 +1,266 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/trace"
+
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"free5gc/src/etaf/stream"
+	"free5gc/src/etaf/util"
+)
+
+const (
+	queueSize      = 256
+	maxAttempts    = 6
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+	requestTimeout = 5 * time.Second
+	idleTimeout    = 5 * time.Minute // a destination without traffic releases its worker
+)
+
+// Notification is the body POSTed to the notification URI of a tracking session
+type Notification struct {
+	SessionId   string              `json:"sessionId"`
+	UeId        string              `json:"ueId"`
+	Time        time.Time           `json:"time"`
+	Tai         models.Tai          `json:"tai"`
+	Location    models.UserLocation `json:"location"`
+	TaiChanged  bool                `json:"taiChanged"`
+	CellChanged bool                `json:"cellChanged"`
+	Emergency   bool                `json:"emergency"`
+	// Set on the periodic reports of the last known location
+	Periodic bool `json:"periodic,omitempty"`
+}
+
+type job struct {
+	session       *etaf_context.TrackingSession
+	notification  Notification
+	correlationID string
+}
+
+// destination serialises the notifications of one notification URI, so a slow or
+// failing client only delays its own notifications; the notifications of high
+// priority sessions are taken first
+type destination struct {
+	uri           string
+	queue         chan *job
+	priorityQueue chan *job
+}
+
+var destinations = make(map[string]*destination) // notification URI as key
+var destinationMutex sync.Mutex
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// Notify queues a location update for a tracking session. It never blocks: an
+// update matching the previous one is dropped, and an update that does not fit in
+// the queue of the destination is dead-lettered.
+func Notify(session *etaf_context.TrackingSession, update stream.LocationUpdate, correlationID string) {
+	switch stream.Filter(session.Filter) {
+	case stream.FilterTaiChange:
+		if !update.TaiChanged {
+			return
+		}
+	case stream.FilterCellChange:
+		if !update.CellChanged {
+			return
+		}
+	case stream.FilterRegistrationAreaChange:
+		if !update.RegistrationAreaChanged {
+			return
+		}
+	}
+	previous, recorded := session.RecordNotified(update.Supi, update.Location)
+	if !recorded {
+		return
+	}
+	if !enqueue(session, newJob(session, update, correlationID)) {
+		session.RestoreNotified(update.Supi, update.Location, previous)
+	}
+}
+
+// NotifyPeriodic queues the periodic report of the last known location of a UE,
+// which neither the filter nor the deduplication of the session apply to
+func NotifyPeriodic(session *etaf_context.TrackingSession, update stream.LocationUpdate, correlationID string) {
+	j := newJob(session, update, correlationID)
+	j.notification.Periodic = true
+	enqueue(session, j)
+}
+
+func newJob(session *etaf_context.TrackingSession, update stream.LocationUpdate, correlationID string) *job {
+	return &job{
+		session: session,
+		notification: Notification{
+			SessionId:   session.Id,
+			UeId:        privacy.ProtectIdentifier(session.Caller, update.Supi),
+			Time:        update.Time,
+			Tai:         update.Tai,
+			Location:    update.Location,
+			TaiChanged:  update.TaiChanged,
+			CellChanged: update.CellChanged,
+			Emergency:   update.Emergency,
+		},
+		correlationID: correlationID,
+	}
+}
+
+// enqueue returns false if the notification was dead-lettered instead
+func enqueue(session *etaf_context.TrackingSession, j *job) bool {
+	destinationMutex.Lock()
+	defer destinationMutex.Unlock()
+
+	dest, ok := destinations[session.NotificationUri]
+	if !ok {
+		dest = &destination{
+			uri:           session.NotificationUri,
+			queue:         make(chan *job, queueSize),
+			priorityQueue: make(chan *job, queueSize),
+		}
+		destinations[dest.uri] = dest
+		go dest.run()
+	}
+	queue := dest.queue
+	if session.Priority {
+		queue = dest.priorityQueue
+	}
+	select {
+	case queue <- j:
+		session.UpdateDelivery(func(status *etaf_context.DeliveryStatus) {
+			status.Queued++
+		})
+		return true
+	default:
+		go deadLetter(j, 0, "notification queue full")
+		return false
+	}
+}
+
+func (dest *destination) run() {
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+
+	for {
+		var j *job
+		select {
+		case j = <-dest.priorityQueue:
+		default:
+			select {
+			case j = <-dest.priorityQueue:
+			case j = <-dest.queue:
+			case <-idle.C:
+				destinationMutex.Lock()
+				if len(dest.queue) == 0 && len(dest.priorityQueue) == 0 {
+					delete(destinations, dest.uri)
+					destinationMutex.Unlock()
+					return
+				}
+				destinationMutex.Unlock()
+				idle.Reset(idleTimeout)
+				continue
+			}
+		}
+
+		j.session.UpdateDelivery(func(status *etaf_context.DeliveryStatus) {
+			status.Queued--
+		})
+		dest.deliver(j)
+		if !idle.Stop() {
+			<-idle.C
+		}
+		idle.Reset(idleTimeout)
+	}
+}
+
+// deliver retries with exponential backoff until the client accepts the
+// notification, the session is deleted or the attempts run out
+func (dest *destination) deliver(j *job) {
+	log := logger.WithCorrelationID(logger.CallbackLog, j.correlationID).WithField("sessionId", j.session.Id)
+	body, err := json.Marshal(j.notification)
+	if err != nil {
+		log.Errorf("Marshal notification error: %+v", err)
+		return
+	}
+
+	backoff := initialBackoff
+	var lastErr string
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			if _, ok := etaf_context.ETAF_Self().TrackingSessionFindById(j.session.Id); !ok {
+				log.Infof("Session deleted, notification dropped")
+				return
+			}
+		}
+
+		status, err := dest.post(body, j.correlationID)
+		now := time.Now().UTC()
+		if err == nil && status >= 200 && status < 300 {
+			j.session.UpdateDelivery(func(deliveryStatus *etaf_context.DeliveryStatus) {
+				deliveryStatus.Delivered++
+				deliveryStatus.LastAttempt = &now
+				deliveryStatus.LastDelivered = &now
+				deliveryStatus.LastHttpStatus = status
+				deliveryStatus.LastError = ""
+			})
+			return
+		}
+
+		if err != nil {
+			lastErr = err.Error()
+		} else {
+			lastErr = fmt.Sprintf("HTTP status %d", status)
+		}
+		j.session.UpdateDelivery(func(deliveryStatus *etaf_context.DeliveryStatus) {
+			if attempt > 1 {
+				deliveryStatus.Retries++
+			}
+			deliveryStatus.LastAttempt = &now
+			deliveryStatus.LastHttpStatus = status
+			deliveryStatus.LastError = lastErr
+		})
+		log.Warnf("Notify [%s] attempt %d failed: %s", dest.uri, attempt, lastErr)
+	}
+
+	deadLetter(j, maxAttempts, lastErr)
+}
+
+func (dest *destination) post(body []byte, correlationID string) (int, error) {
+	spanCtx, span := util.Tracer().Start(context.Background(), "POST tracking notification",
+		trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	request, err := http.NewRequest(http.MethodPost, dest.uri, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if correlationID != "" {
+		request.Header.Set(logger.CorrelationIDHeader, correlationID)
+	}
+	global.TextMapPropagator().Inject(spanCtx, request.Header)
+
+	response, err := httpClient.Do(request.WithContext(spanCtx))
+	util.SetSpanError(spanCtx, span, err)
+	if err != nil {
+		return 0, err
+	}
+	if err := response.Body.Close(); err != nil {
+		logger.CallbackLog.Warnf("Close notification response error: %+v", err)
+	}
+	return response.StatusCode, nil
+}
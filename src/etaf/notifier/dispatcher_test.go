@@ -0,0 +1,191 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/stream"
+)
+
+func testLocation(nrCellId string) models.UserLocation {
+	plmnId := models.PlmnId{Mcc: "208", Mnc: "93"}
+	return models.UserLocation{NrLocation: &models.NrLocation{
+		Tai:  &models.Tai{PlmnId: &plmnId, Tac: "000001"},
+		Ncgi: &models.Ncgi{PlmnId: &plmnId, NrCellId: nrCellId},
+	}}
+}
+
+// stalledDestination registers a destination without its worker, so the
+// notifications queued stay in its queues
+func stalledDestination(uri string) (*destination, func()) {
+	dest := &destination{
+		uri:           uri,
+		queue:         make(chan *job, queueSize),
+		priorityQueue: make(chan *job, queueSize),
+	}
+	destinationMutex.Lock()
+	destinations[uri] = dest
+	destinationMutex.Unlock()
+	return dest, func() {
+		destinationMutex.Lock()
+		delete(destinations, uri)
+		destinationMutex.Unlock()
+	}
+}
+
+func TestNotifyDeduplicate(t *testing.T) {
+	if factory.EtafConfig.Configuration == nil {
+		factory.EtafConfig.Configuration = &factory.Configuration{}
+		defer func() { factory.EtafConfig.Configuration = nil }()
+	}
+
+	supi := "imsi-208930000000001"
+	testCases := []struct {
+		name   string
+		filter stream.Filter
+		update stream.LocationUpdate
+		queued bool
+	}{
+		{
+			name:   "first location",
+			filter: stream.FilterAll,
+			update: stream.LocationUpdate{Location: testLocation("000000010"), CellChanged: true},
+			queued: true,
+		},
+		{
+			name:   "same location",
+			filter: stream.FilterAll,
+			update: stream.LocationUpdate{Location: testLocation("000000010")},
+		},
+		{
+			name:   "new cell",
+			filter: stream.FilterAll,
+			update: stream.LocationUpdate{Location: testLocation("000000020"), CellChanged: true},
+			queued: true,
+		},
+		{
+			name:   "TAI filter, same TAI",
+			filter: stream.FilterTaiChange,
+			update: stream.LocationUpdate{Location: testLocation("000000030"), CellChanged: true},
+		},
+		{
+			name:   "cell filter, new cell",
+			filter: stream.FilterCellChange,
+			update: stream.LocationUpdate{Location: testLocation("000000030"), CellChanged: true},
+			queued: true,
+		},
+		{
+			name:   "back to a previous cell",
+			filter: stream.FilterAll,
+			update: stream.LocationUpdate{Location: testLocation("000000010"), CellChanged: true},
+			queued: true,
+		},
+	}
+
+	session := etaf_context.ETAF_Self().NewTrackingSession(supi)
+	session.NotificationUri = "http://127.0.0.1:0/deduplicate"
+	dest, cleanup := stalledDestination(session.NotificationUri)
+	defer cleanup()
+
+	deduplicated := uint64(0)
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			session.Filter = string(testCase.filter)
+			testCase.update.Supi = supi
+			queued := len(dest.queue)
+			Notify(session, testCase.update, "")
+			if (len(dest.queue) > queued) != testCase.queued {
+				t.Errorf("queued %t, expected %t", len(dest.queue) > queued, testCase.queued)
+			}
+			if testCase.name == "same location" {
+				deduplicated++
+			}
+			if delivery := session.Delivery(); delivery.Deduplicated != deduplicated ||
+				delivery.Queued != len(dest.queue) {
+				t.Errorf("delivery status %+v", delivery)
+			}
+		})
+	}
+
+	// periodic reports are neither filtered nor deduplicated
+	queued := len(dest.queue)
+	NotifyPeriodic(session, stream.LocationUpdate{Supi: supi, Location: testLocation("000000010")}, "")
+	if len(dest.queue) != queued+1 {
+		t.Errorf("periodic report not queued")
+	}
+}
+
+func TestNotifyPriority(t *testing.T) {
+	if factory.EtafConfig.Configuration == nil {
+		factory.EtafConfig.Configuration = &factory.Configuration{}
+		defer func() { factory.EtafConfig.Configuration = nil }()
+	}
+
+	var mutex sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mutex.Lock()
+		received = append(received, notification.UeId)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dest, cleanup := stalledDestination(server.URL)
+	defer cleanup()
+
+	testCases := []struct {
+		supi     string
+		priority bool
+	}{
+		{"imsi-208930000000001", false},
+		{"imsi-208930000000002", true},
+		{"imsi-208930000000003", false},
+		{"imsi-208930000000004", true},
+	}
+	for _, testCase := range testCases {
+		session := etaf_context.ETAF_Self().NewTrackingSession(testCase.supi)
+		session.NotificationUri = server.URL
+		session.Filter = string(stream.FilterAll)
+		session.Priority = testCase.priority
+		Notify(session, stream.LocationUpdate{Supi: testCase.supi, Location: testLocation("000000010")}, "")
+	}
+	go dest.run()
+
+	expected := []string{"imsi-208930000000002", "imsi-208930000000004",
+		"imsi-208930000000001", "imsi-208930000000003"}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mutex.Lock()
+		done := len(received) == len(expected)
+		mutex.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != len(expected) {
+		t.Fatalf("%d notifications delivered, expected %d", len(received), len(expected))
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Errorf("notifications delivered in order %v, expected %v", received, expected)
+			break
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package oam
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/celldb"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/producer"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HTTPListCells(c *gin.Context) {
+	setCorsHeader(c)
+
+	req := http_wrapper.NewRequest(c.Request, nil)
+
+	rsp := producer.HandleOAMListCells(req)
+
+	sendCellResponse(c, rsp)
+}
+
+func HTTPGetCell(c *gin.Context) {
+	setCorsHeader(c)
+
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["cellId"] = c.Params.ByName("cellId")
+
+	rsp := producer.HandleOAMGetCell(req)
+
+	sendCellResponse(c, rsp)
+}
+
+func HTTPPutCell(c *gin.Context) {
+	setCorsHeader(c)
+
+	var cell celldb.Cell
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		logger.MtLog.Errorf("Get Request Body error: %+v", err)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&cell, requestBody, "application/json")
+	if err != nil {
+		problemDetail := "[Request Body] " + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.MtLog.Errorln(problemDetail)
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	req := http_wrapper.NewRequest(c.Request, cell)
+	req.Params["cellId"] = c.Params.ByName("cellId")
+
+	rsp := producer.HandleOAMPutCell(req)
+
+	sendCellResponse(c, rsp)
+}
+
+func HTTPDeleteCell(c *gin.Context) {
+	setCorsHeader(c)
+
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["cellId"] = c.Params.ByName("cellId")
+
+	rsp := producer.HandleOAMDeleteCell(req)
+
+	sendCellResponse(c, rsp)
+}
+
+func sendCellResponse(c *gin.Context, rsp *http_wrapper.Response) {
+	if rsp.Body == nil {
+		c.Status(rsp.Status)
+		return
+	}
+	responseBody, err := openapi.Serialize(rsp.Body, "application/json")
+	if err != nil {
+		logger.MtLog.Errorln(err)
+		problemDetails := models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, problemDetails)
+	} else {
+		c.Data(rsp.Status, "application/json", responseBody)
+	}
+}
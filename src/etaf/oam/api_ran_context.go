@@ -0,0 +1,38 @@
+package oam
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/producer"
+	"free5gc/src/etaf/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HTTPRanContext(c *gin.Context) {
+	setCorsHeader(c)
+
+	req := http_wrapper.NewRequest(c.Request, nil)
+	if globalRanNodeId, exists := c.Params.Get("globalRanNodeId"); exists {
+		req.Params["globalRanNodeId"] = globalRanNodeId
+	}
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleOAMRanContext(req)
+
+	responseBody, err := openapi.Serialize(rsp.Body, "application/json")
+	if err != nil {
+		logger.MtLog.Errorln(err)
+		problemDetails := models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, problemDetails)
+	} else {
+		c.Data(rsp.Status, "application/json", responseBody)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"free5gc/lib/openapi/models"
 	"free5gc/src/etaf/logger"
 	"free5gc/src/etaf/producer"
+	"free5gc/src/etaf/util"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +14,10 @@ import (
 
 func setCorsHeader(c *gin.Context) {
 	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 	c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+	c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, "+logger.CorrelationIDHeader+", "+
+		producer.NextCursorHeader+", "+producer.TotalCountHeader)
 }
 
 func HTTPRegisteredUEContext(c *gin.Context) {
@@ -25,9 +27,13 @@ func HTTPRegisteredUEContext(c *gin.Context) {
 	if supi, exists := c.Params.Get("supi"); exists {
 		req.Params["supi"] = supi
 	}
+	req.Params["caller"] = util.CallerIdentity(c.Request)
 
 	rsp := producer.HandleOAMRegisteredUEContext(req)
 
+	for key, val := range rsp.Header {
+		c.Header(key, val[0])
+	}
 	responseBody, err := openapi.Serialize(rsp.Body, "application/json")
 	if err != nil {
 		logger.MtLog.Errorln(err)
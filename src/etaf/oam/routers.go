@@ -2,8 +2,11 @@ package oam
 
 import (
 	"free5gc/lib/logger_util"
+	"free5gc/lib/openapi/models"
 	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
 	"net/http"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 
@@ -31,12 +34,11 @@ func NewRouter() *gin.Engine {
 	AddService(router)
 
 	router.Use(cors.New(cors.Config{
-		AllowMethods:     []string{"GET", "POST", "OPTIONS", "PUT", "PATCH", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "User-Agent", "Referrer", "Host", "Token", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		AllowAllOrigins:  true,
-		MaxAge:           86400,
+		AllowMethods:    []string{"GET", "POST", "OPTIONS", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:    []string{"Origin", "Content-Length", "Content-Type", "User-Agent", "Referrer", "Host", "Token", "X-Requested-With"},
+		ExposeHeaders:   []string{"Content-Length"},
+		AllowAllOrigins: true,
+		MaxAge:          86400,
 	}))
 
 	return router
@@ -46,9 +48,14 @@ func AddService(engine *gin.Engine) *gin.RouterGroup {
 	group := engine.Group("/netaf-oam/v1")
 
 	for _, route := range routes {
+		handlerFunc := util.TracingHandler("netaf-oam", group.BasePath()+route.Pattern, route.HandlerFunc)
 		switch route.Method {
 		case "GET":
-			group.GET(route.Pattern, route.HandlerFunc)
+			group.GET(route.Pattern, handlerFunc)
+		case "PUT":
+			group.PUT(route.Pattern, handlerFunc)
+		case "DELETE":
+			group.DELETE(route.Pattern, handlerFunc)
 		}
 	}
 	return group
@@ -59,6 +66,29 @@ func Index(c *gin.Context) {
 	c.String(http.StatusOK, "Hello World!")
 }
 
+// operatorOnly lets only the operators run handler: the other callers get 403,
+// and 401 when they did not authenticate
+func operatorOnly(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := util.CallerIdentity(c.Request)
+		if !util.IsOperator(caller) {
+			setCorsHeader(c)
+			status := http.StatusForbidden
+			if strings.HasPrefix(caller, util.CallerAddressPrefix) {
+				status = http.StatusUnauthorized
+			}
+			logger.MtLog.Warnf("Caller[%s] is not allowed to %s %s", caller, c.Request.Method, c.Request.URL.Path)
+			c.JSON(status, models.ProblemDetails{
+				Status: int32(status),
+				Cause:  "UNAUTHORIZED_CALLER",
+				Detail: "the caller is not an authenticated operator",
+			})
+			return
+		}
+		handler(c)
+	}
+}
+
 var routes = Routes{
 	{
 		"Index",
@@ -80,4 +110,81 @@ var routes = Routes{
 		"/registered-ue-context/:supi",
 		HTTPRegisteredUEContext,
 	},
+
+	{
+		"RAN Context",
+		"GET",
+		"/ran",
+		HTTPRanContext,
+	},
+
+	{
+		"Individual RAN Context",
+		"GET",
+		"/ran/:globalRanNodeId",
+		HTTPRanContext,
+	},
+
+	{
+		"Logging",
+		"GET",
+		"/logging",
+		HTTPGetLogging,
+	},
+
+	{
+		"Set Logging",
+		"PUT",
+		"/logging",
+		operatorOnly(HTTPSetLogging),
+	},
+
+	{
+		"Audit Log",
+		"GET",
+		"/audit",
+		operatorOnly(HTTPGetAuditLog),
+	},
+
+	{
+		"Verify Audit Log",
+		"GET",
+		"/audit/verify",
+		operatorOnly(HTTPVerifyAuditLog),
+	},
+
+	{
+		"Purge Location Data",
+		"DELETE",
+		"/location-data/:supi",
+		operatorOnly(HTTPPurgeLocationData),
+	},
+
+	{
+		"Cells",
+		"GET",
+		"/cells",
+		HTTPListCells,
+	},
+
+	{
+		"Individual Cell",
+		"GET",
+		"/cells/:cellId",
+		HTTPGetCell,
+	},
+
+	{
+		"Put Cell",
+		"PUT",
+		"/cells/:cellId",
+		operatorOnly(HTTPPutCell),
+	},
+
+	{
+		"Delete Cell",
+		"DELETE",
+		"/cells/:cellId",
+		operatorOnly(HTTPDeleteCell),
+	},
 }
@@ -0,0 +1,174 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
+)
+
+// PseudonymPrefix marks a pseudonymised SUPI or GPSI
+const PseudonymPrefix = "pseudo-"
+
+var pseudonymKey []byte
+
+// pseudonymIndexes maps each caller having resolved a pseudonym to the UE IDs
+// of the pseudonyms of the pooled UEs for that caller, their SUPI or GPSI ones
+var pseudonymIndexes = make(map[string]map[string]string)
+var pseudonymIndexesMutex sync.RWMutex
+
+func init() {
+	context.WatchUeIdentifiers(indexPseudonyms)
+}
+
+// Init loads the pseudonym key and starts enforcing the retention policy
+func Init() error {
+	privacy := factory.EtafConfig.Configuration.Privacy
+	if privacy != nil && privacy.PseudonymKeyPath != "" {
+		key, err := ioutil.ReadFile(privacy.PseudonymKeyPath)
+		if err != nil {
+			return fmt.Errorf("Read pseudonym key error: %+v", err)
+		}
+		pseudonymKey = []byte(strings.TrimSpace(string(key)))
+	} else {
+		pseudonymKey = make([]byte, 32)
+		if _, err := rand.Read(pseudonymKey); err != nil {
+			return fmt.Errorf("Generate pseudonym key error: %+v", err)
+		}
+		logger.PrivacyLog.Warnln("No pseudonym key configured, pseudonyms will change on restart")
+	}
+
+	// the pseudonyms indexed so far were derived from the previous key
+	pseudonymIndexesMutex.Lock()
+	pseudonymIndexes = make(map[string]map[string]string)
+	pseudonymIndexesMutex.Unlock()
+
+	if privacy != nil && privacy.Retention != nil {
+		startRetention(privacy.Retention)
+	}
+	return nil
+}
+
+// Pseudonymise tells whether the identifiers returned to caller must be pseudonymised.
+// The settings of the clients only apply to authenticated callers; the others,
+// named by their address, get the default.
+func Pseudonymise(caller string) bool {
+	privacy := factory.EtafConfig.Configuration.Privacy
+	if privacy == nil {
+		return false
+	}
+	if !strings.HasPrefix(caller, util.CallerAddressPrefix) {
+		for _, client := range privacy.Clients {
+			if client.Caller == caller {
+				return client.Pseudonymise
+			}
+		}
+	}
+	return privacy.PseudonymiseByDefault
+}
+
+// Pseudonym derives the pseudonym of a SUPI or GPSI for caller. Each caller gets
+// its own pseudonyms, so two callers cannot link their results.
+func Pseudonym(caller, id string) string {
+	mac := hmac.New(sha256.New, pseudonymKey)
+	mac.Write([]byte(caller))
+	mac.Write([]byte{0})
+	mac.Write([]byte(id))
+	return PseudonymPrefix + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ProtectIdentifier returns the identifier to disclose to caller
+func ProtectIdentifier(caller, id string) string {
+	if id == "" || !Pseudonymise(caller) {
+		return id
+	}
+	return Pseudonym(caller, id)
+}
+
+// ResolveSupi maps an identifier given by caller back to a SUPI. Pseudonyms and
+// IMEI or IMEISV PEIs are resolved against the registered UEs, to the canonical
+// PEI of a UE without SUPI; an unknown PEI is returned in its canonical form.
+// Anything else is returned unchanged.
+func ResolveSupi(caller, id string) string {
+	if context.IsImeiPei(id) {
+		if ue, ok := context.ETAF_Self().EtafUeFindByPei(id); ok {
+			return ue.UeId()
+		}
+		if pei, err := context.CanonicalPei(id); err == nil {
+			return pei
+		}
+		return id
+	}
+	if !strings.HasPrefix(id, PseudonymPrefix) {
+		return id
+	}
+	if ueId, ok := pseudonymUeId(caller, id); ok {
+		return ueId
+	}
+	return id
+}
+
+// ResolveUeId resolves an identifier as ResolveSupi does, after checking the
+// format and check digit of IMEI and IMEISV PEIs
+func ResolveUeId(caller, id string) (string, error) {
+	if context.IsImeiPei(id) {
+		if _, err := context.CanonicalPei(id); err != nil {
+			return "", err
+		}
+	}
+	return ResolveSupi(caller, id), nil
+}
+
+// pseudonymUeId looks up the UE ID of a pseudonym of caller, indexing the pooled
+// UEs the first time caller resolves one
+func pseudonymUeId(caller, pseudonym string) (string, bool) {
+	pseudonymIndexesMutex.RLock()
+	index, ok := pseudonymIndexes[caller]
+	ueId, found := index[pseudonym]
+	pseudonymIndexesMutex.RUnlock()
+	if ok {
+		return ueId, found
+	}
+
+	pseudonymIndexesMutex.Lock()
+	defer pseudonymIndexesMutex.Unlock()
+	if index, ok = pseudonymIndexes[caller]; !ok {
+		index = make(map[string]string)
+		context.ETAF_Self().UePool.Range(func(key, value interface{}) bool {
+			ue := value.(*context.EtafUe)
+			index[Pseudonym(caller, ue.UeId())] = ue.UeId()
+			if ue.Gpsi != "" {
+				index[Pseudonym(caller, ue.Gpsi)] = ue.UeId()
+			}
+			return true
+		})
+		pseudonymIndexes[caller] = index
+	}
+	ueId, found = index[pseudonym]
+	return ueId, found
+}
+
+// indexPseudonyms keeps the pseudonym indexes up to date as the UEs are pooled,
+// their GPSI assigned and the UEs removed
+func indexPseudonyms(ueId, id string, added bool) {
+	pseudonymIndexesMutex.Lock()
+	defer pseudonymIndexesMutex.Unlock()
+
+	for caller, index := range pseudonymIndexes {
+		pseudonym := Pseudonym(caller, id)
+		if added {
+			index[pseudonym] = ueId
+		} else if index[pseudonym] == ueId {
+			delete(index, pseudonym)
+		}
+	}
+}
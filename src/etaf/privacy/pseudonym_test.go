@@ -0,0 +1,36 @@
+package privacy
+
+import (
+	"testing"
+
+	"free5gc/src/etaf/context"
+)
+
+func TestResolveSupiPseudonym(t *testing.T) {
+	const caller = "lcs-client"
+	const supi = "imsi-208930000000001"
+	const gpsi = "msisdn-0900000001"
+
+	ue := &context.EtafUe{}
+	context.ETAF_Self().AddEtafUeToUePool(ue, supi)
+	// the first resolution indexes the pooled UEs for caller
+	if got := ResolveSupi(caller, Pseudonym(caller, supi)); got != supi {
+		t.Errorf("SUPI pseudonym resolved to %s, want %s", got, supi)
+	}
+
+	// the UEs pooled or given a GPSI later are indexed as they are
+	ue.SetGpsi(gpsi)
+	if got := ResolveSupi(caller, Pseudonym(caller, gpsi)); got != supi {
+		t.Errorf("GPSI pseudonym resolved to %s, want %s", got, supi)
+	}
+	if got := ResolveSupi("other-client", Pseudonym(caller, supi)); got != Pseudonym(caller, supi) {
+		t.Errorf("pseudonym of another caller resolved to %s", got)
+	}
+
+	ue.Remove()
+	for _, id := range []string{supi, gpsi} {
+		if got := ResolveSupi(caller, Pseudonym(caller, id)); got != Pseudonym(caller, id) {
+			t.Errorf("pseudonym of removed UE resolved to %s", got)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package privacy
+
+import (
+	"sync"
+	"time"
+
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/stream"
+)
+
+const defaultRetentionCheckInterval = 60 // seconds
+
+var retentionDone chan struct{}
+
+// Purger drops the location data a component recorded before purgeBefore and
+// returns how many records it dropped
+type Purger func(purgeBefore time.Time) (int64, error)
+
+var purgers = make(map[string]Purger)
+var purgersMutex sync.Mutex
+
+// RegisterPurger subjects the location data kept by a component depending on this
+// package to the retention policy; name designates the data in the logs
+func RegisterPurger(name string, purger Purger) {
+	purgersMutex.Lock()
+	defer purgersMutex.Unlock()
+
+	purgers[name] = purger
+}
+
+func startRetention(retention *factory.Retention) {
+	if retention.CoarsenAfter <= 0 && retention.PurgeAfter <= 0 {
+		return
+	}
+	interval := retention.CheckInterval
+	if interval <= 0 {
+		interval = defaultRetentionCheckInterval
+	}
+
+	retentionDone = make(chan struct{})
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	go func(done chan struct{}) {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ApplyRetention(retention)
+			case <-done:
+				return
+			}
+		}
+	}(retentionDone)
+}
+
+// Stop stops enforcing the retention policy
+func Stop() {
+	if retentionDone != nil {
+		close(retentionDone)
+		retentionDone = nil
+	}
+}
+
+// ApplyRetention coarsens and purges the location history of every UE, and
+// purges the last known locations, the data disclosed in the audit trail and the
+// location data of the registered purgers
+func ApplyRetention(retention *factory.Retention) {
+	now := time.Now().UTC()
+	var coarsenBefore, purgeBefore time.Time
+	if retention.CoarsenAfter > 0 {
+		coarsenBefore = now.Add(-time.Duration(retention.CoarsenAfter) * time.Second)
+	}
+	if retention.PurgeAfter > 0 {
+		purgeBefore = now.Add(-time.Duration(retention.PurgeAfter) * time.Second)
+	}
+
+	totalPurged, totalCoarsened := 0, 0
+	context.ETAF_Self().UePool.Range(func(key, value interface{}) bool {
+		ue := value.(*context.EtafUe)
+		purged, coarsened := ue.LocationHistory.ApplyRetention(coarsenBefore, purgeBefore)
+		totalPurged += purged
+		totalCoarsened += coarsened
+		return true
+	})
+	if totalPurged != 0 || totalCoarsened != 0 {
+		logger.PrivacyLog.Infof("Location retention: %d points purged, %d coarsened", totalPurged, totalCoarsened)
+	}
+	if purgeBefore.IsZero() {
+		return
+	}
+
+	if purged := stream.PurgeLastLocations(purgeBefore); purged != 0 {
+		logger.PrivacyLog.Infof("Location retention: %d last known locations purged", purged)
+	}
+	if purged, err := audit.PurgeData(purgeBefore); err != nil {
+		logger.PrivacyLog.Errorf("Location retention of the audit trail failed: %+v", err)
+	} else if purged != 0 {
+		logger.PrivacyLog.Infof("Location retention: data of %d audit entries purged", purged)
+	}
+
+	purgersMutex.Lock()
+	defer purgersMutex.Unlock()
+	for name, purger := range purgers {
+		if purged, err := purger(purgeBefore); err != nil {
+			logger.PrivacyLog.Errorf("Location retention of the %s failed: %+v", name, err)
+		} else if purged != 0 {
+			logger.PrivacyLog.Infof("Location retention: %d %s purged", purged, name)
+		}
+	}
+}
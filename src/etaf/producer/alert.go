@@ -0,0 +1,272 @@
+package producer
+
+import (
+	"fmt"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	ngap_message "free5gc/src/etaf/ngap/message"
+	"net/http"
+	"time"
+)
+
+// Message identifiers of the CBS reserved for public warnings (TS 23.041)
+const (
+	minPwsMessageIdentifier = 0x1100
+	maxPwsMessageIdentifier = 0x18FF
+)
+
+// Alert status of AlertView
+const (
+	AlertStatusActive    = "ACTIVE"
+	AlertStatusCancelled = "CANCELLED"
+)
+
+// AlertCreateData issues a public warning in the warning area, which may include
+// the area of the geofence GeofenceId
+type AlertCreateData struct {
+	MessageIdentifier int32                    `json:"messageIdentifier"`
+	SerialNumber      int32                    `json:"serialNumber"`
+	WarningArea       etaf_context.WarningArea `json:"warningArea"`
+	GeofenceId        string                   `json:"geofenceId,omitempty"`
+	Message           string                   `json:"message"`
+	// Seconds between broadcasts and number of broadcasts, 0 broadcasting until cancelled
+	RepetitionPeriod   int32  `json:"repetitionPeriod,omitempty"`
+	NumberOfBroadcasts int32  `json:"numberOfBroadcasts,omitempty"`
+	Purpose            string `json:"purpose,omitempty"`
+}
+
+type AlertView struct {
+	AlertId            string                   `json:"alertId"`
+	MessageIdentifier  int32                    `json:"messageIdentifier"`
+	SerialNumber       int32                    `json:"serialNumber"`
+	WarningArea        etaf_context.WarningArea `json:"warningArea"`
+	Message            string                   `json:"message"`
+	RepetitionPeriod   int32                    `json:"repetitionPeriod,omitempty"`
+	NumberOfBroadcasts int32                    `json:"numberOfBroadcasts,omitempty"`
+	Status             string                   `json:"status"`
+	IssuedAt           time.Time                `json:"issuedAt"`
+	CancelledAt        *time.Time               `json:"cancelledAt,omitempty"`
+	// Delivery to the RANs serving the warning area over NGAP
+	Delivery []etaf_context.RanDelivery `json:"delivery,omitempty"`
+}
+
+func HandleIssueAlert(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	createData := request.Body.(AlertCreateData)
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithCorrelationID(logger.ProducerLog, correlationID)
+	log.Infof("Handle Issue Alert")
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionAlertIssue,
+		Purpose:       createData.Purpose,
+		CorrelationId: correlationID,
+	}
+
+	view, problemDetails := IssueAlertProcedure(caller, createData)
+	if problemDetails != nil {
+		log.Warnf("Issue Alert failed: %s", problemDetails.Detail)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	auditEntry.AlertId = view.AlertId
+	auditEntry.Outcome = audit.OutcomeSuccess
+	audit.Record(auditEntry, map[string]interface{}{"messageIdentifier": view.MessageIdentifier,
+		"serialNumber": view.SerialNumber, "warningArea": view.WarningArea})
+	header := http.Header{
+		"Location": {etaf_context.ETAF_Self().GetIPv4Uri() + "/netaf-track/v1/alerts/" + view.AlertId},
+	}
+	return http_wrapper.NewResponse(http.StatusCreated, header, view)
+}
+
+func HandleGetAlert(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("Handle Get Alert")
+
+	alert, problemDetails := findAlert(request.Params["caller"], request.Params["alertId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, buildAlertView(alert))
+}
+
+// HandleGetAlerts lists the alerts issued by the caller
+func HandleGetAlerts(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("Handle Get Alerts")
+
+	caller := request.Params["caller"]
+	activeOnly := request.Query.Get("active") == "true"
+	views := []*AlertView{}
+	for _, alert := range etaf_context.ETAF_Self().Alerts() {
+		if alert.Caller == caller && (!activeOnly || alert.IsActive()) {
+			views = append(views, buildAlertView(alert))
+		}
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, views)
+}
+
+// HandleCancelAlert cancels an alert issued by the caller; cancelling it again
+// has no effect
+func HandleCancelAlert(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithAlertID(logger.WithCorrelationID(logger.ProducerLog, correlationID), request.Params["alertId"])
+	log.Infof("Handle Cancel Alert")
+
+	alert, problemDetails := findAlert(caller, request.Params["alertId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	if alert.Cancel() {
+		cancelAlertDelivery(alert)
+		audit.Record(audit.Entry{
+			Caller:        caller,
+			Action:        audit.ActionAlertCancel,
+			AlertId:       alert.Id,
+			Purpose:       request.Query.Get("purpose"),
+			Outcome:       audit.OutcomeSuccess,
+			CorrelationId: correlationID,
+		}, nil)
+	}
+	return http_wrapper.NewResponse(http.StatusNoContent, nil, nil)
+}
+
+// IssueAlertProcedure validates and records an alert
+func IssueAlertProcedure(caller string, createData AlertCreateData) (*AlertView, *models.ProblemDetails) {
+	warningArea := createData.WarningArea
+	if createData.GeofenceId != "" {
+		geofence, ok := findGeofence(createData.GeofenceId)
+		if !ok {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusNotFound,
+				Cause:  "CONTEXT_NOT_FOUND",
+				Detail: fmt.Sprintf("geofence[%s] not found", createData.GeofenceId),
+			}
+		}
+		warningArea.TaiList = append(warningArea.TaiList, geofence.TaiList...)
+		warningArea.NcgiList = append(warningArea.NcgiList, geofence.NcgiList...)
+		warningArea.EcgiList = append(warningArea.EcgiList, geofence.EcgiList...)
+		if warningArea.Area == nil {
+			warningArea.Area = geofence.Area
+		} else if geofence.Area != nil {
+			return nil, invalidAlertData(fmt.Sprintf("geofence[%s] already has an area", createData.GeofenceId))
+		}
+	}
+	if warningArea.IsEmpty() {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "warningArea or geofenceId is required",
+		}
+	}
+	// the warning area of NGAP is a choice of TAIs or cells, the cells of a node
+	// being of its RAT only
+	if len(warningArea.TaiList) > 0 &&
+		(len(warningArea.NcgiList) > 0 || len(warningArea.EcgiList) > 0 || warningArea.Area != nil) {
+		return nil, invalidAlertData("a warning area may not combine TAIs with cells or an area")
+	}
+	if warningArea.Area != nil {
+		if err := warningArea.Area.Validate(); err != nil {
+			return nil, invalidAlertData("warningArea.area: " + err.Error())
+		}
+	}
+	if createData.MessageIdentifier < minPwsMessageIdentifier || createData.MessageIdentifier > maxPwsMessageIdentifier {
+		return nil, invalidAlertData(fmt.Sprintf("messageIdentifier must be in [%d, %d]",
+			minPwsMessageIdentifier, maxPwsMessageIdentifier))
+	}
+	if createData.SerialNumber < 0 || createData.SerialNumber > 0xFFFF {
+		return nil, invalidAlertData("serialNumber must be in [0, 65535]")
+	}
+	if createData.Message == "" {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "message is required",
+		}
+	}
+	if _, err := ngap_message.EncodeWarningMessage(createData.Message); err != nil {
+		return nil, invalidAlertData(err.Error())
+	}
+	// ranges of the Repetition Period and Number of Broadcasts Requested IEs of NGAP
+	if createData.RepetitionPeriod < 0 || createData.RepetitionPeriod > 131071 {
+		return nil, invalidAlertData("repetitionPeriod must be in [0, 131071]")
+	}
+	if createData.NumberOfBroadcasts < 0 || createData.NumberOfBroadcasts > 0xFFFF {
+		return nil, invalidAlertData("numberOfBroadcasts must be in [0, 65535]")
+	}
+
+	etafSelf := etaf_context.ETAF_Self()
+	alert, err := etafSelf.NewAlert()
+	if err != nil {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+	}
+	alert.MessageIdentifier = createData.MessageIdentifier
+	alert.SerialNumber = createData.SerialNumber
+	alert.WarningArea = warningArea
+	alert.Message = createData.Message
+	alert.RepetitionPeriod = createData.RepetitionPeriod
+	alert.NumberOfBroadcasts = createData.NumberOfBroadcasts
+	alert.Caller = caller
+	etafSelf.AddAlert(alert)
+	deliverAlert(alert)
+	return buildAlertView(alert), nil
+}
+
+// findAlert only finds the alerts issued by caller; the alerts of other clients
+// are reported as missing rather than forbidden
+func findAlert(caller, alertId string) (*etaf_context.Alert, *models.ProblemDetails) {
+	alert, ok := etaf_context.ETAF_Self().AlertFindById(alertId)
+	if !ok || alert.Caller != caller {
+		return nil, alertNotFound(alertId)
+	}
+	return alert, nil
+}
+
+func alertNotFound(alertId string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Status: http.StatusNotFound,
+		Cause:  "CONTEXT_NOT_FOUND",
+		Detail: fmt.Sprintf("alert[%s] not found", alertId),
+	}
+}
+
+func invalidAlertData(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Status: http.StatusBadRequest,
+		Cause:  "MANDATORY_IE_INCORRECT",
+		Detail: detail,
+	}
+}
+
+func buildAlertView(alert *etaf_context.Alert) *AlertView {
+	view := &AlertView{
+		AlertId:            alert.Id,
+		MessageIdentifier:  alert.MessageIdentifier,
+		SerialNumber:       alert.SerialNumber,
+		WarningArea:        alert.WarningArea,
+		Message:            alert.Message,
+		RepetitionPeriod:   alert.RepetitionPeriod,
+		NumberOfBroadcasts: alert.NumberOfBroadcasts,
+		Status:             AlertStatusActive,
+		IssuedAt:           alert.IssuedAt,
+		CancelledAt:        alert.CancelledAt(),
+		Delivery:           alert.DeliveryReport(),
+	}
+	if view.CancelledAt != nil {
+		view.Status = AlertStatusCancelled
+	}
+	return view
+}
@@ -0,0 +1,184 @@
+package producer
+
+import (
+	"free5gc/lib/ngap/ngapType"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/celldb"
+	etaf_context "free5gc/src/etaf/context"
+	ngap_message "free5gc/src/etaf/ngap/message"
+	"strings"
+)
+
+// deliverAlert sends a Write-Replace Warning Request to every RAN serving part
+// of the warning area of the alert, with that part as warning area
+func deliverAlert(alert *etaf_context.Alert) {
+	ncgiList, ecgiList := areaCells(alert.WarningArea.Area)
+	for _, ran := range ngRans() {
+		ranArea, ok := warningAreaOfRan(ran, alert.WarningArea, ncgiList, ecgiList)
+		if !ok {
+			continue
+		}
+		warningAreaList := ngap_message.BuildWarningAreaList(ranArea.TaiList, ranArea.NcgiList, ranArea.EcgiList)
+		ngap_message.SendWriteReplaceWarningRequest(ran, alert, &warningAreaList)
+		alert.UpdateDelivery(ran.RanNodeId(), func(delivery *etaf_context.RanDelivery) {
+			delivery.Status = etaf_context.DeliveryPending
+		})
+	}
+}
+
+// cancelAlertDelivery sends a PWS Cancel Request to the RANs the alert was
+// delivered to
+func cancelAlertDelivery(alert *etaf_context.Alert) {
+	ncgiList, ecgiList := areaCells(alert.WarningArea.Area)
+	for _, delivery := range alert.DeliveryReport() {
+		ran, ok := ngRanFindByNodeId(delivery.RanNodeId)
+		if !ok {
+			continue
+		}
+		var warningAreaList *ngapType.WarningAreaList
+		if ranArea, ok := warningAreaOfRan(ran, alert.WarningArea, ncgiList, ecgiList); ok {
+			list := ngap_message.BuildWarningAreaList(ranArea.TaiList, ranArea.NcgiList, ranArea.EcgiList)
+			warningAreaList = &list
+		}
+		ngap_message.SendPWSCancelRequest(ran, alert, warningAreaList)
+		alert.UpdateDelivery(delivery.RanNodeId, func(delivery *etaf_context.RanDelivery) {
+			delivery.Status = etaf_context.DeliveryCancelPending
+		})
+	}
+}
+
+// RedeliverAlerts re-sends the active alerts to a RAN after a PWS Restart
+// Indication, restricted to the TAIs and cells restarted; with none given, as
+// after NG Setup, the alerts are sent for the whole part of their warning area
+// the RAN serves
+func RedeliverAlerts(ran *etaf_context.EtafRan, restartTaiList []models.Tai,
+	restartNcgiList []models.Ncgi, restartEcgiList []models.Ecgi) {
+	restricted := len(restartTaiList) > 0 || len(restartNcgiList) > 0 || len(restartEcgiList) > 0
+	for _, alert := range etaf_context.ETAF_Self().Alerts() {
+		if !alert.IsActive() {
+			continue
+		}
+		ncgiList, ecgiList := areaCells(alert.WarningArea.Area)
+		ranArea, ok := warningAreaOfRan(ran, alert.WarningArea, ncgiList, ecgiList)
+		if !ok {
+			continue
+		}
+		if restricted {
+			ranArea = etaf_context.WarningArea{
+				TaiList:  intersectTais(ranArea.TaiList, restartTaiList),
+				NcgiList: intersectNcgis(ranArea.NcgiList, restartNcgiList),
+				EcgiList: intersectEcgis(ranArea.EcgiList, restartEcgiList),
+			}
+			if ranArea.IsEmpty() {
+				continue
+			}
+		}
+		warningAreaList := ngap_message.BuildWarningAreaList(ranArea.TaiList, ranArea.NcgiList, ranArea.EcgiList)
+		ngap_message.SendWriteReplaceWarningRequest(ran, alert, &warningAreaList)
+		alert.UpdateDelivery(ran.RanNodeId(), func(delivery *etaf_context.RanDelivery) {
+			if delivery.Status != "" {
+				delivery.Restarts++
+			}
+			delivery.Status = etaf_context.DeliveryPending
+		})
+	}
+}
+
+// warningAreaOfRan returns the TAIs and cells of a warning area served by the RAN,
+// the cells of the geographic area of the warning area being given by ncgiList
+// and ecgiList
+func warningAreaOfRan(ran *etaf_context.EtafRan, area etaf_context.WarningArea, ncgiList []models.Ncgi,
+	ecgiList []models.Ecgi) (ranArea etaf_context.WarningArea, ok bool) {
+	for _, tai := range area.TaiList {
+		if ran.SupportsTai(tai) {
+			ranArea.TaiList = append(ranArea.TaiList, tai)
+		}
+	}
+	for _, ncgi := range append(append([]models.Ncgi{}, area.NcgiList...), ncgiList...) {
+		if ran.ServesNrCell(ncgi) {
+			ranArea.NcgiList = append(ranArea.NcgiList, ncgi)
+		}
+	}
+	for _, ecgi := range append(append([]models.Ecgi{}, area.EcgiList...), ecgiList...) {
+		if ran.ServesEutraCell(ecgi) {
+			ranArea.EcgiList = append(ranArea.EcgiList, ecgi)
+		}
+	}
+	return ranArea, !ranArea.IsEmpty()
+}
+
+// areaCells returns the cells of the cell database whose site is in the area
+func areaCells(area *etaf_context.GeographicArea) (ncgiList []models.Ncgi, ecgiList []models.Ecgi) {
+	if area == nil {
+		return
+	}
+	for _, cell := range celldb.List() {
+		if !area.Contains(etaf_context.GeographicalCoordinates{Lat: cell.Lat, Lon: cell.Lon}) {
+			continue
+		}
+		plmnId := cell.PlmnId
+		switch cell.Rat {
+		case celldb.RatNr:
+			ncgiList = append(ncgiList, models.Ncgi{PlmnId: &plmnId, NrCellId: cell.CellId})
+		case celldb.RatEutra:
+			ecgiList = append(ecgiList, models.Ecgi{PlmnId: &plmnId, EutraCellId: cell.CellId})
+		}
+	}
+	return
+}
+
+// ngRans returns the 3GPP RANs that completed NG Setup
+func ngRans() (rans []*etaf_context.EtafRan) {
+	etaf_context.ETAF_Self().EtafRanPool.Range(func(key, value interface{}) bool {
+		ran := value.(*etaf_context.EtafRan)
+		if ran.RanId != nil && ran.AnType == models.AccessType__3_GPP_ACCESS {
+			rans = append(rans, ran)
+		}
+		return true
+	})
+	return
+}
+
+func ngRanFindByNodeId(ranNodeId string) (*etaf_context.EtafRan, bool) {
+	for _, ran := range ngRans() {
+		if ran.RanNodeId() == ranNodeId {
+			return ran, true
+		}
+	}
+	return nil, false
+}
+
+func intersectTais(taiList, restartTaiList []models.Tai) (intersection []models.Tai) {
+	for _, tai := range taiList {
+		if etaf_context.InTaiList(tai, restartTaiList) {
+			intersection = append(intersection, tai)
+		}
+	}
+	return
+}
+
+func intersectNcgis(ncgiList, restartNcgiList []models.Ncgi) (intersection []models.Ncgi) {
+	for _, ncgi := range ncgiList {
+		for _, restartNcgi := range restartNcgiList {
+			if etaf_context.SamePlmn(ncgi.PlmnId, restartNcgi.PlmnId) &&
+				strings.EqualFold(ncgi.NrCellId, restartNcgi.NrCellId) {
+				intersection = append(intersection, ncgi)
+				break
+			}
+		}
+	}
+	return
+}
+
+func intersectEcgis(ecgiList, restartEcgiList []models.Ecgi) (intersection []models.Ecgi) {
+	for _, ecgi := range ecgiList {
+		for _, restartEcgi := range restartEcgiList {
+			if etaf_context.SamePlmn(ecgi.PlmnId, restartEcgi.PlmnId) &&
+				strings.EqualFold(ecgi.EutraCellId, restartEcgi.EutraCellId) {
+				intersection = append(intersection, ecgi)
+				break
+			}
+		}
+	}
+	return
+}
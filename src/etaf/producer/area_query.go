@@ -0,0 +1,409 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/celldb"
+	"free5gc/src/etaf/consumer"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"free5gc/src/etaf/stream"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sources of a location estimate
+const (
+	EstimateSourceLmf          = "lmf"
+	EstimateSourceCellDatabase = "cellDatabase" // approximated by the serving cell
+)
+
+// AreaQuery selects the UEs located in the union of its TAIs, its cells, its
+// geographic area and the area of the geofence GeofenceId
+type AreaQuery struct {
+	TaiList  []models.Tai  `json:"taiList,omitempty"`
+	NcgiList []models.Ncgi `json:"ncgiList,omitempty"`
+	EcgiList []models.Ecgi `json:"ecgiList,omitempty"`
+	// Contains the UEs whose location estimate is centered in it
+	Area       *etaf_context.GeographicArea `json:"area,omitempty"`
+	GeofenceId string                       `json:"geofenceId,omitempty"`
+	// Seconds after which a location is stale; locations of unknown age are always stale
+	MaxAge int `json:"maxAge,omitempty"`
+	// Ask the serving AMF for the current location of the stale UEs
+	RefreshStale bool `json:"refreshStale,omitempty"`
+	// Also select the registered UEs in CM-IDLE whose paging area, their
+	// registration area, overlaps the TAIs; only for queries made of TAIs
+	PagingArea bool   `json:"pagingArea,omitempty"`
+	Purpose    string `json:"purpose,omitempty"`
+}
+
+type AreaQueryResult struct {
+	Time time.Time `json:"time"`
+	Ues  []AreaUe  `json:"ues"`
+}
+
+type AreaUe struct {
+	UeId       string                       `json:"ueId"`
+	Gpsi       string                       `json:"gpsi,omitempty"`
+	Registered bool                         `json:"registered"` // in the UE pool; otherwise known from location reports only
+	Emergency  bool                         `json:"emergency"`
+	Tai        models.Tai                   `json:"tai"`
+	Location   *models.UserLocation         `json:"location,omitempty"`
+	Estimate   *etaf_context.GeographicArea `json:"estimate,omitempty"`
+	// lmf, or cellDatabase for the approximation by the serving cell
+	EstimateSource string     `json:"estimateSource,omitempty"`
+	Time           *time.Time `json:"time,omitempty"`
+	// Age of the location in seconds, absent when unknown
+	Age              *int64 `json:"age,omitempty"`
+	Stale            bool   `json:"stale"`
+	RefreshRequested bool   `json:"refreshRequested,omitempty"`
+	// Registration area of a UE selected by its paging area only
+	PagingArea []models.Tai `json:"pagingArea,omitempty"`
+
+	supi string // UeId before pseudonymisation, for the audit trail
+}
+
+// knownLocation is the most recent location known for a UE; the location of a
+// coarsened history point is nil. The estimate is the LMF fix of a positioning,
+// or else the approximation of the serving cell by the cell database.
+type knownLocation struct {
+	Time           *time.Time
+	Tai            models.Tai
+	Location       *models.UserLocation
+	Estimate       *etaf_context.GeographicArea
+	EstimateSource string
+}
+
+func HandleAreaQuery(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	query := request.Body.(AreaQuery)
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithCorrelationID(logger.ProducerLog, correlationID)
+	log.Infof("Handle Area Query")
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		Purpose:       query.Purpose,
+		CorrelationId: correlationID,
+	}
+
+	result, problemDetails := AreaQueryProcedure(caller, query, correlationID)
+	if problemDetails != nil {
+		log.Warnf("Area Query failed: %s", problemDetails.Detail)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	// the query is recorded with the UEs found, then the disclosure of each UE
+	ueIds := make([]string, 0, len(result.Ues))
+	disclosures := make(map[string]interface{}, len(result.Ues))
+	for _, areaUe := range result.Ues {
+		ueIds = append(ueIds, areaUe.supi)
+		disclosures[areaUe.supi] = areaUe
+	}
+	problemDetails = auditDisclosure(auditEntry, map[string]interface{}{"areaQuery": query,
+		"matches": len(result.Ues), "ueIds": ueIds})
+	if problemDetails == nil {
+		problemDetails = auditUeDisclosures(auditEntry, disclosures)
+	}
+	if problemDetails != nil {
+		log.Errorf("Area Query result not disclosed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, result)
+}
+
+// AreaQueryProcedure lists the registered UEs and the UEs known from location
+// reports whose last known location is in the area of query
+func AreaQueryProcedure(caller string, query AreaQuery, correlationID string) (
+	*AreaQueryResult, *models.ProblemDetails) {
+	if query.GeofenceId != "" {
+		geofence, ok := findGeofence(query.GeofenceId)
+		if !ok {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusNotFound,
+				Cause:  "CONTEXT_NOT_FOUND",
+				Detail: fmt.Sprintf("geofence[%s] not found", query.GeofenceId),
+			}
+		}
+		query.TaiList = append(query.TaiList, geofence.TaiList...)
+		query.NcgiList = append(query.NcgiList, geofence.NcgiList...)
+		query.EcgiList = append(query.EcgiList, geofence.EcgiList...)
+		if query.Area == nil {
+			query.Area = geofence.Area
+		} else if geofence.Area != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusBadRequest,
+				Cause:  "MANDATORY_IE_INCORRECT",
+				Detail: fmt.Sprintf("geofence[%s] already has an area", query.GeofenceId),
+			}
+		}
+	}
+	if len(query.TaiList) == 0 && len(query.NcgiList) == 0 && len(query.EcgiList) == 0 && query.Area == nil {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "one of taiList, ncgiList, ecgiList, area and geofenceId is required",
+		}
+	}
+	if query.PagingArea && (len(query.NcgiList) != 0 || len(query.EcgiList) != 0 || query.Area != nil) {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "pagingArea applies to TAIs only, not to cells or areas",
+		}
+	}
+	if query.Area != nil {
+		if err := query.Area.Validate(); err != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusBadRequest,
+				Cause:  "MANDATORY_IE_INCORRECT",
+				Detail: "area: " + err.Error(),
+			}
+		}
+	}
+	if query.MaxAge < 0 {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "maxAge must not be negative",
+		}
+	}
+
+	etafSelf := etaf_context.ETAF_Self()
+	supis := make(map[string]bool)
+	etafSelf.UePool.Range(func(key, value interface{}) bool {
+		supis[value.(*etaf_context.EtafUe).UeId()] = true
+		return true
+	})
+	for _, update := range stream.LastLocations() {
+		supis[update.Supi] = true
+	}
+
+	now := time.Now().UTC()
+	result := &AreaQueryResult{
+		Time: now,
+		Ues:  []AreaUe{},
+	}
+	var refresh []*etaf_context.EtafUe
+	for supi := range supis {
+		known, ok := lastKnownLocation(supi)
+		var pagingArea []models.Tai
+		if !ok || !query.contains(known) {
+			if pagingArea = query.pagingAreaOf(supi); pagingArea == nil {
+				continue
+			}
+		}
+		areaUe := AreaUe{
+			UeId:           privacy.ProtectIdentifier(caller, supi),
+			supi:           supi,
+			Tai:            known.Tai,
+			Location:       known.Location,
+			Estimate:       known.Estimate,
+			EstimateSource: known.EstimateSource,
+			Time:           known.Time,
+			Stale:          known.Time == nil,
+			PagingArea:     pagingArea,
+		}
+		if known.Time != nil {
+			age := int64(now.Sub(*known.Time) / time.Second)
+			areaUe.Age = &age
+			areaUe.Stale = query.MaxAge > 0 && age > int64(query.MaxAge)
+		}
+		if update, ok := stream.LastLocation(supi); ok {
+			areaUe.Emergency = update.Emergency
+		}
+		if ue, ok := etafSelf.EtafUeFindBySupi(supi); ok {
+			areaUe.Registered = true
+			areaUe.Emergency = isEmergency(ue)
+			areaUe.Gpsi = privacy.ProtectIdentifier(caller, ue.Gpsi)
+			if query.RefreshStale && areaUe.Stale && ue.AmfUri != "" {
+				areaUe.RefreshRequested = true
+				refresh = append(refresh, ue)
+			}
+		}
+		result.Ues = append(result.Ues, areaUe)
+	}
+
+	if len(refresh) > 0 {
+		go refreshLocations(logger.ContextWithCorrelationID(context.Background(), correlationID), refresh)
+	}
+	return result, nil
+}
+
+func (query *AreaQuery) contains(known knownLocation) bool {
+	for _, tai := range query.TaiList {
+		if sameTai(tai, known.Tai) {
+			return true
+		}
+	}
+	if query.Area != nil && known.Estimate != nil {
+		if center, ok := known.Estimate.Center(); ok && query.Area.Contains(center) {
+			return true
+		}
+	}
+	if known.Location == nil {
+		return false
+	}
+	if nrLocation := known.Location.NrLocation; nrLocation != nil && nrLocation.Ncgi != nil {
+		for _, ncgi := range query.NcgiList {
+			if inAreaPlmn(ncgi.PlmnId, nrLocation.Ncgi.PlmnId) &&
+				strings.EqualFold(ncgi.NrCellId, nrLocation.Ncgi.NrCellId) {
+				return true
+			}
+		}
+	}
+	if eutraLocation := known.Location.EutraLocation; eutraLocation != nil && eutraLocation.Ecgi != nil {
+		for _, ecgi := range query.EcgiList {
+			if inAreaPlmn(ecgi.PlmnId, eutraLocation.Ecgi.PlmnId) &&
+				strings.EqualFold(ecgi.EutraCellId, eutraLocation.Ecgi.EutraCellId) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pagingAreaOf returns the paging area of a registered UE in CM-IDLE when the
+// query asks for it and it overlaps the TAIs of the query
+func (query *AreaQuery) pagingAreaOf(supi string) []models.Tai {
+	if !query.PagingArea {
+		return nil
+	}
+	ue, ok := etaf_context.ETAF_Self().EtafUeFindBySupi(supi)
+	if !ok {
+		return nil
+	}
+	for _, accessType := range []models.AccessType{models.AccessType__3_GPP_ACCESS,
+		models.AccessType_NON_3_GPP_ACCESS} {
+		if !ue.State[accessType].Is(etaf_context.Registered) || ue.CmConnect(accessType) {
+			continue
+		}
+		pagingArea := ue.PagingArea(accessType)
+		for _, tai := range pagingArea {
+			for _, areaTai := range query.TaiList {
+				if sameTai(areaTai, tai) {
+					return pagingArea
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func sameTai(areaTai, tai models.Tai) bool {
+	return inAreaPlmn(areaTai.PlmnId, tai.PlmnId) && strings.EqualFold(areaTai.Tac, tai.Tac)
+}
+
+// inAreaPlmn matches any PLMN when the PLMN of the area is not given
+func inAreaPlmn(areaPlmnId, plmnId *models.PlmnId) bool {
+	return areaPlmnId == nil || etaf_context.SamePlmn(areaPlmnId, plmnId)
+}
+
+func findGeofence(id string) (*factory.Geofence, bool) {
+	for i := range factory.EtafConfig.Configuration.Geofences {
+		if geofence := &factory.EtafConfig.Configuration.Geofences[i]; geofence.Id == id {
+			return geofence, true
+		}
+	}
+	return nil, false
+}
+
+// lastKnownLocation returns the most recent of the last location report and the
+// last location history point of a UE. A registered UE without either still has
+// its context location, of unknown age. Without an LMF fix, the serving cell is
+// looked up in the cell database.
+func lastKnownLocation(supi string) (known knownLocation, ok bool) {
+	if supi == "" {
+		return
+	}
+	if update, found := stream.LastLocation(supi); found {
+		location := update.Location
+		known = knownLocation{Time: &update.Time, Tai: update.Tai, Location: &location}
+		ok = true
+	}
+	ue, found := etaf_context.ETAF_Self().EtafUeFindBySupi(supi)
+	if !found {
+		known.locateByCell()
+		return
+	}
+	if points := ue.LocationHistory.Points(time.Time{}); len(points) > 0 {
+		latest := points[len(points)-1]
+		if !ok || latest.Time.After(*known.Time) {
+			known = knownLocation{Time: &latest.Time, Tai: latest.Tai, Location: latest.Location,
+				Estimate: latest.Estimate}
+			if latest.Estimate != nil {
+				known.EstimateSource = EstimateSourceLmf
+			}
+			ok = true
+		}
+	}
+	if !ok {
+		ue.LocationMutex.Lock()
+		if ue.Tai.Tac != "" {
+			location := ue.Location
+			known = knownLocation{Tai: ue.Tai, Location: &location}
+			ok = true
+		}
+		ue.LocationMutex.Unlock()
+	}
+	known.locateByCell()
+	return
+}
+
+// locateByCell approximates the known location by its serving cell when there is
+// no LMF fix
+func (known *knownLocation) locateByCell() {
+	if known.Estimate != nil || known.Location == nil {
+		return
+	}
+	if estimate, ok := celldb.Locate(*known.Location); ok {
+		known.Estimate = estimate
+		known.EstimateSource = EstimateSourceCellDatabase
+	}
+}
+
+// refreshLocations asks the serving AMF of every UE for a single immediate
+// location report; the reports update the location store like any other
+func refreshLocations(ctx context.Context, ues []*etaf_context.EtafUe) {
+	for _, ue := range ues {
+		log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, logger.CorrelationIDFromContext(ctx)),
+			ue.UeId())
+		subscriptionId, reports, problemDetails, err := consumer.AmfLocationReportSubscribe(ctx, ue.AmfUri,
+			refreshCorrelationId(ue), models.AmfEventSubscription{
+				Supi: ue.Supi,
+				Pei:  ue.Pei,
+				Options: &models.AmfEventMode{
+					Trigger:    models.AmfEventTrigger_ONE_TIME,
+					MaxReports: 1,
+				},
+			})
+		if problemDetails != nil {
+			log.Warnf("Location refresh failed: %s", problemDetails.Cause)
+			continue
+		} else if err != nil {
+			log.Warnf("Location refresh error: %+v", err)
+			continue
+		}
+		if len(reports) > 0 {
+			LocationInfoNotifyProcedure(models.AmfEventNotification{
+				NotifyCorrelationId: subscriptionId,
+				ReportList:          reports,
+			}, logger.CorrelationIDFromContext(ctx))
+		}
+	}
+}
+
+// refreshCorrelationId correlates the location refresh reports of a UE
+func refreshCorrelationId(ue *etaf_context.EtafUe) string {
+	return "refresh-" + ue.UeId()
+}
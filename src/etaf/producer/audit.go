@@ -0,0 +1,161 @@
+package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func HandleOAMGetAuditLog(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithCorrelationID(logger.ProducerLog, correlationID)
+	log.Infof("[OAM] Handle Get Audit Log")
+
+	entries, problemDetails := OAMGetAuditLogProcedure(caller, request.Query)
+	if problemDetails == nil {
+		problemDetails = auditDisclosure(audit.Entry{
+			Caller:        caller,
+			Action:        audit.ActionAuditRead,
+			CorrelationId: correlationID,
+		}, nil)
+	}
+	if problemDetails != nil {
+		log.Warnf("[OAM] Get Audit Log failed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else {
+		return http_wrapper.NewResponse(http.StatusOK, nil, entries)
+	}
+}
+
+func HandleOAMVerifyAuditLog(request *http_wrapper.Request) *http_wrapper.Response {
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithCorrelationID(logger.ProducerLog, correlationID)
+	log.Infof("[OAM] Handle Verify Audit Log")
+
+	verification, err := audit.Verify()
+	if err != nil {
+		log.Errorln(err)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		return http_wrapper.NewResponse(http.StatusInternalServerError, nil, problemDetails)
+	}
+	if problemDetails := auditDisclosure(audit.Entry{
+		Caller:        request.Params["caller"],
+		Action:        audit.ActionAuditRead,
+		CorrelationId: correlationID,
+	}, nil); problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	if !verification.Valid {
+		log.Errorf("[OAM] Audit chain broken at entry[%d]", verification.FirstInvalid)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, verification)
+}
+
+// OAMGetAuditLogProcedure returns the entries matching query without the data
+// disclosed, whose DataHash is kept, and with the UE IDs protected for caller
+func OAMGetAuditLogProcedure(caller string, query url.Values) ([]audit.Entry, *models.ProblemDetails) {
+	ueId := query.Get("ueId")
+	if ueId != "" {
+		var problemDetails *models.ProblemDetails
+		if ueId, problemDetails = resolveUeId(caller, ueId); problemDetails != nil {
+			return nil, problemDetails
+		}
+	}
+	filter := audit.Filter{
+		Caller:  query.Get("caller"),
+		Action:  audit.Action(query.Get("action")),
+		UeId:    ueId,
+		AlertId: query.Get("alertId"),
+		Outcome: audit.Outcome(query.Get("outcome")),
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if raw := query.Get(bound.name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, &models.ProblemDetails{
+					Status: http.StatusBadRequest,
+					Cause:  "MANDATORY_IE_INCORRECT",
+					Detail: bound.name + " must be an RFC 3339 date-time",
+				}
+			}
+			*bound.value = &t
+		}
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusBadRequest,
+				Cause:  "MANDATORY_IE_INCORRECT",
+				Detail: "limit must be a positive integer",
+			}
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := audit.Query(filter)
+	if err != nil {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	for index := range entries {
+		entries[index].UeId = privacy.ProtectIdentifier(caller, entries[index].UeId)
+		entries[index].Data = ""
+	}
+	return entries, nil
+}
+
+// auditUeDisclosures records the disclosure of the location of several UEs, one
+// entry per UE with the data disclosed about it, so each UE finds them by its ID
+func auditUeDisclosures(entry audit.Entry, disclosures map[string]interface{}) *models.ProblemDetails {
+	ueIds := make([]string, 0, len(disclosures))
+	for ueId := range disclosures {
+		ueIds = append(ueIds, ueId)
+	}
+	sort.Strings(ueIds)
+	for _, ueId := range ueIds {
+		entry.UeId = ueId
+		if problemDetails := auditDisclosure(entry, disclosures[ueId]); problemDetails != nil {
+			return problemDetails
+		}
+	}
+	return nil
+}
+
+// auditDisclosure records a successful disclosure in the audit trail. Location
+// data must not be disclosed without an audit trail, so the disclosure fails
+// when it cannot be recorded.
+func auditDisclosure(entry audit.Entry, data interface{}) *models.ProblemDetails {
+	entry.Outcome = audit.OutcomeSuccess
+	if err := audit.Record(entry, data); err != nil {
+		return &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: "the disclosure could not be audited",
+		}
+	}
+	return nil
+}
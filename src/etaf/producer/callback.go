@@ -1 +1,123 @@
 package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/notifier"
+	"free5gc/src/etaf/stream"
+	"net/http"
+	"time"
+)
+
+func HandleLocationInfoNotify(request *http_wrapper.Request) *http_wrapper.Response {
+	notification := request.Body.(models.AmfEventNotification)
+	// the notification carries on the correlation ID of the request that subscribed
+	correlationID := context.ETAF_Self().NotifyCorrelation(notification.NotifyCorrelationId)
+	if correlationID == "" {
+		correlationID = request.Header.Get(logger.CorrelationIDHeader)
+	}
+	log := logger.WithCorrelationID(logger.CallbackLog, correlationID)
+	log.Infof("Handle Location Info Notify")
+
+	LocationInfoNotifyProcedure(notification, correlationID)
+	return http_wrapper.NewResponse(http.StatusNoContent, nil, nil)
+}
+
+// LocationInfoNotifyProcedure applies the location reports of an AMF event
+// notification to the UE contexts, publishes them to the location streams and
+// queues them for the tracking sessions of the UEs. Registration state reports
+// keep the emergency registrations up to date, presence reports the presence of
+// the UEs in their areas of interest.
+func LocationInfoNotifyProcedure(notification models.AmfEventNotification, correlationID string) {
+	if len(notification.ReportList) == 0 {
+		// AMF status change notifications share the callback URI
+		logger.CallbackLog.Debugf("Notification[%s] carries no event report", notification.NotifyCorrelationId)
+		return
+	}
+
+	for _, report := range notification.ReportList {
+		switch report.Type {
+		case models.AmfEventType_REGISTRATION_STATE_REPORT:
+			registrationStateReportProcedure(report, correlationID)
+			continue
+		case models.AmfEventType_PRESENCE_IN_AOI_REPORT:
+			presenceReportProcedure(report)
+			continue
+		}
+		// a UE without SUPI is known by its PEI
+		ueId := report.Supi
+		if ueId == "" && report.Pei != "" {
+			if ue, ok := context.ETAF_Self().EtafUeFindByPei(report.Pei); ok {
+				ueId = ue.UeId()
+			}
+		}
+		if report.Type != models.AmfEventType_LOCATION_REPORT || report.Location == nil || ueId == "" {
+			continue
+		}
+
+		timestamp := time.Now().UTC()
+		if report.TimeStamp != nil {
+			timestamp = report.TimeStamp.UTC()
+		}
+
+		// the UE context is updated first, so the update carries its registration area
+		if ue, ok := context.ETAF_Self().EtafUeFindBySupi(ueId); ok {
+			updateLocation(ue, ueId, report.Gpsi, timestamp, *report.Location, correlationID)
+		} else {
+			PublishLocation(ueId, report.Gpsi, timestamp, *report.Location, correlationID)
+		}
+	}
+}
+
+// updateLocation applies a location report to the UE context and publishes it.
+// The location mutex of the UE is held until the update is queued, so concurrent
+// reports reach the streams and tracking sessions in the order they were applied.
+func updateLocation(ue *context.EtafUe, ueId, gpsi string, timestamp time.Time, location models.UserLocation,
+	correlationID string) {
+	ue.LocationMutex.Lock()
+	defer ue.LocationMutex.Unlock()
+
+	accessType := locationAccessType(location)
+	tai, _ := stream.LocationTai(location)
+	if ue.Tai.Tac != "" && ue.Tai.Tac != tai.Tac {
+		ue.LocationChanged = true
+	}
+	ue.Location = location
+	ue.Tai = tai
+	ue.UpdateRegistrationArea(accessType)
+	ue.RecordLocation(accessType, timestamp)
+
+	PublishLocation(ueId, gpsi, timestamp, location, correlationID)
+}
+
+func locationAccessType(location models.UserLocation) models.AccessType {
+	if location.N3gaLocation != nil {
+		return models.AccessType_NON_3_GPP_ACCESS
+	}
+	return models.AccessType__3_GPP_ACCESS
+}
+
+// PublishLocation publishes a location of a UE to the location streams and
+// queues it for the tracking sessions of the UE, starting or ending first the
+// tracking session of the UE in emergency services
+func PublishLocation(supi, gpsi string, timestamp time.Time, location models.UserLocation,
+	correlationID string) stream.LocationUpdate {
+	update := stream.LocationUpdate{
+		Supi:     supi,
+		Time:     timestamp,
+		Location: location,
+	}
+	if ue, ok := context.ETAF_Self().EtafUeFindBySupi(supi); ok {
+		checkEmergency(ue, correlationID)
+		update.Emergency = isEmergency(ue)
+		update.RegistrationArea = ue.PagingArea(locationAccessType(location))
+	}
+	update = stream.Publish(update)
+
+	for _, session := range context.ETAF_Self().TrackingSessionsByUe(supi, gpsi) {
+		notifier.Notify(session, update, correlationID)
+	}
+	return update
+}
@@ -0,0 +1,100 @@
+package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/celldb"
+	"free5gc/src/etaf/logger"
+	"net/http"
+)
+
+func HandleOAMListCells(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("[OAM] Handle List Cells")
+
+	return http_wrapper.NewResponse(http.StatusOK, nil, celldb.List())
+}
+
+func HandleOAMGetCell(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("[OAM] Handle Get Cell")
+
+	cell, ok := celldb.Get(request.Params["cellId"])
+	if !ok {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return http_wrapper.NewResponse(http.StatusNotFound, nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, cell)
+}
+
+func HandleOAMPutCell(request *http_wrapper.Request) *http_wrapper.Response {
+	log := logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader))
+	log.Infof("[OAM] Handle Put Cell")
+
+	cell := request.Body.(celldb.Cell)
+
+	created, problemDetails := OAMPutCellProcedure(request.Params["cellId"], &cell)
+	if problemDetails != nil {
+		log.Warnf("[OAM] Put Cell failed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else if created {
+		return http_wrapper.NewResponse(http.StatusCreated, nil, cell)
+	} else {
+		return http_wrapper.NewResponse(http.StatusOK, nil, cell)
+	}
+}
+
+func HandleOAMDeleteCell(request *http_wrapper.Request) *http_wrapper.Response {
+	log := logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader))
+	log.Infof("[OAM] Handle Delete Cell")
+
+	deleted, err := celldb.Delete(request.Params["cellId"])
+	if err != nil {
+		log.Errorf("[OAM] Delete Cell error: %+v", err)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		return http_wrapper.NewResponse(http.StatusInternalServerError, nil, problemDetails)
+	} else if !deleted {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return http_wrapper.NewResponse(http.StatusNotFound, nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusNoContent, nil, nil)
+}
+
+// OAMPutCellProcedure adds or replaces the cell cellId, which must be the Id
+// derived from the cell; it returns true if the cell is new
+func OAMPutCellProcedure(cellId string, cell *celldb.Cell) (bool, *models.ProblemDetails) {
+	if err := cell.Validate(); err != nil {
+		return false, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: err.Error(),
+		}
+	}
+	if cell.Id != cellId {
+		return false, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "cellId must be " + cell.Id,
+		}
+	}
+
+	created, err := celldb.Put(*cell)
+	if err != nil {
+		return false, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+	}
+	return created, nil
+}
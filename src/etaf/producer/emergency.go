@@ -0,0 +1,250 @@
+package producer
+
+import (
+	"context"
+	"free5gc/lib/nas/nasMessage"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/consumer"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/notifier"
+	"free5gc/src/etaf/stream"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// emergencyCaller is the client identity of the tracking sessions following the
+	// UEs in emergency services
+	emergencyCaller  = "etaf-emergency"
+	emergencyPurpose = "emergency services"
+	// emergencyCorrelationId correlates the registration state reports of the AMFs
+	emergencyCorrelationId         = "emergency-registration"
+	defaultEmergencyReportInterval = 5 * time.Second
+)
+
+var emergencySubscriptions []etaf_context.AmfSubscription
+var emergencySubscriptionMutex sync.Mutex
+
+// SubscribeEmergencyRegistrations subscribes to the registration state reports of
+// every AMF, when emergency services are configured
+func SubscribeEmergencyRegistrations(ctx context.Context) {
+	if factory.EtafConfig.Configuration.Emergency == nil {
+		return
+	}
+
+	var subscriptions []etaf_context.AmfSubscription
+	amfInfos := consumer.SearchAvailableAMFs(ctx, etaf_context.ETAF_Self().NrfUri, models.ServiceName_NAMF_EVTS)
+	for _, amfInfo := range amfInfos {
+		subscriptionId, problemDetails, err :=
+			consumer.AmfRegistrationStateSubscribe(ctx, amfInfo.AmfUri, emergencyCorrelationId)
+		if problemDetails != nil {
+			logger.ProducerLog.Warnf("AMF[%s] registration state subscription failed: %s", amfInfo.AmfUri,
+				problemDetails.Cause)
+			continue
+		} else if err != nil {
+			logger.ProducerLog.Warnf("AMF[%s] registration state subscription error: %+v", amfInfo.AmfUri, err)
+			continue
+		}
+		subscriptions = append(subscriptions, etaf_context.AmfSubscription{
+			AmfUri:         amfInfo.AmfUri,
+			SubscriptionId: subscriptionId,
+		})
+	}
+
+	emergencySubscriptionMutex.Lock()
+	defer emergencySubscriptionMutex.Unlock()
+	emergencySubscriptions = append(emergencySubscriptions, subscriptions...)
+}
+
+func UnsubscribeEmergencyRegistrations(ctx context.Context) {
+	emergencySubscriptionMutex.Lock()
+	subscriptions := emergencySubscriptions
+	emergencySubscriptions = nil
+	emergencySubscriptionMutex.Unlock()
+
+	unsubscribeAmfEvents(ctx, subscriptions)
+}
+
+// registrationStateReportProcedure applies a registration state report of the
+// AMF. A UE registered without SUPI, known by its PEI only, is emergency
+// registered (TS 23.502 4.2.2.2.1); it is pooled under its PEI until it
+// deregisters.
+func registrationStateReportProcedure(report models.AmfEventReport, correlationID string) {
+	etafSelf := etaf_context.ETAF_Self()
+	var ue *etaf_context.EtafUe
+	var ok bool
+	if report.Supi != "" {
+		ue, ok = etafSelf.EtafUeFindBySupi(report.Supi)
+	} else if report.Pei != "" {
+		ue, ok = etafSelf.EtafUeFindByPei(report.Pei)
+		if !ok && registered(report.RmInfoList) {
+			ue = etafSelf.NewEtafUe("")
+			if err := etafSelf.AddEtafUeToUePoolByPei(ue, report.Pei); err != nil {
+				logger.CallbackLog.Warnf("Emergency registered UE ignored: %+v", err)
+				removeUe(context.Background(), ue)
+				return
+			}
+			ue.SetGpsi(report.Gpsi)
+			ok = true
+		}
+	}
+	if !ok {
+		logger.CallbackLog.Debugf("Registration state report of an unknown UE ignored")
+		return
+	}
+
+	for _, rmInfo := range report.RmInfoList {
+		switch rmInfo.RmState {
+		case models.RmState_REGISTERED:
+			if report.Supi == "" {
+				ue.EmergencyRegistered = true
+			}
+		case models.RmState_DEREGISTERED:
+			// the PDU session contexts are left to the AMF, which releases them with
+			// the registration
+			ue.EmergencyRegistered = false
+		}
+	}
+	checkEmergency(ue, correlationID)
+	if ue.Supi == "" && len(report.RmInfoList) > 0 && !registered(report.RmInfoList) {
+		removeUe(logger.ContextWithCorrelationID(context.Background(), correlationID), ue)
+	}
+}
+
+func registered(rmInfoList []models.RmInfo) bool {
+	for _, rmInfo := range rmInfoList {
+		if rmInfo.RmState == models.RmState_REGISTERED {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmergency reports whether the UE is emergency registered or has an emergency
+// PDU session
+func isEmergency(ue *etaf_context.EtafUe) bool {
+	if ue.EmergencyRegistered || ue.RegistrationType5GS == nasMessage.RegistrationType5GSEmergencyRegistration {
+		return true
+	}
+	for _, smContext := range ue.SmContextList {
+		if isEmergencyPduSession(smContext.PduSessionContext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmergencySupi reports whether the UE identified by supi is in emergency services
+func isEmergencySupi(supi string) bool {
+	ue, ok := etaf_context.ETAF_Self().EtafUeFindBySupi(supi)
+	return ok && isEmergency(ue)
+}
+
+// isEmergencyPduSession reports whether the PDU session is to one of the DNNs or
+// slices configured for emergency services
+func isEmergencyPduSession(pduSessionContext *models.PduSessionContext) bool {
+	config := factory.EtafConfig.Configuration.Emergency
+	if config == nil || pduSessionContext == nil {
+		return false
+	}
+	for _, dnn := range config.Dnns {
+		if strings.EqualFold(dnn, pduSessionContext.Dnn) {
+			return true
+		}
+	}
+	if snssai := pduSessionContext.SNssai; snssai != nil {
+		for _, emergencySnssai := range config.Snssais {
+			if emergencySnssai.Sst == snssai.Sst && strings.EqualFold(emergencySnssai.Sd, snssai.Sd) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkEmergency starts the tracking session of a UE entering emergency services,
+// and ends it once the UE left them
+func checkEmergency(ue *etaf_context.EtafUe, correlationID string) {
+	ue.EmergencyMutex.Lock()
+	defer ue.EmergencyMutex.Unlock()
+
+	emergency := isEmergency(ue)
+	if emergency && ue.EmergencySessionId == "" {
+		startEmergencySession(ue, correlationID)
+	} else if !emergency && ue.EmergencySessionId != "" {
+		endEmergencySession(ue, "UE left emergency services", correlationID)
+	}
+}
+
+// endEmergencySession ends the tracking session of a UE in emergency services,
+// with its periodic reports; the emergency mutex of the UE is held
+func endEmergencySession(ue *etaf_context.EtafUe, reason, correlationID string) {
+	if session, ok := etaf_context.ETAF_Self().TrackingSessionFindById(ue.EmergencySessionId); ok {
+		logger.WithSupi(logger.ProducerLog, ue.UeId()).Infof("%s, tracking session[%s] ended", reason, session.Id)
+		endTrackingSession(session, correlationID)
+	}
+	ue.EmergencySessionId = ""
+}
+
+// startEmergencySession creates the high priority tracking session of a UE in
+// emergency services, reporting its location periodically on top of its changes
+func startEmergencySession(ue *etaf_context.EtafUe, correlationID string) {
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), ue.UeId())
+	config := factory.EtafConfig.Configuration.Emergency
+	if config == nil || config.NotificationUri == "" {
+		log.Infof("UE in emergency services, no notification URI configured to track it")
+		return
+	}
+	if ue.UeId() == "" {
+		log.Warnf("UE in emergency services without SUPI nor PEI cannot be tracked")
+		return
+	}
+
+	etafSelf := etaf_context.ETAF_Self()
+	session := etafSelf.NewTrackingSession(ue.UeId())
+	session.Caller = emergencyCaller
+	session.Purpose = emergencyPurpose
+	session.NotificationUri = config.NotificationUri
+	session.Filter = string(stream.FilterAll)
+	session.Priority = true
+	session.ReportInterval = defaultEmergencyReportInterval
+	if config.ReportInterval > 0 {
+		session.ReportInterval = time.Duration(config.ReportInterval) * time.Second
+	}
+	etafSelf.AddTrackingSession(session)
+	ue.EmergencySessionId = session.Id
+	log.Infof("UE in emergency services, tracking session[%s] started", session.Id)
+
+	problemDetails := auditDisclosure(audit.Entry{
+		Caller:        session.Caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          session.Supi,
+		Purpose:       session.Purpose,
+		CorrelationId: correlationID,
+	}, map[string]interface{}{"session": "created", "sessionId": session.Id,
+		"notificationUri": session.NotificationUri})
+	if problemDetails != nil {
+		log.Errorf("Emergency tracking session[%s] ended: %s", session.Id, problemDetails.Detail)
+		ue.EmergencySessionId = ""
+		endTrackingSession(session, correlationID)
+		return
+	}
+	schedulePeriodicReport(session)
+}
+
+// schedulePeriodicReport notifies the last known location of the UE of the
+// session every report interval, until the session is deleted
+func schedulePeriodicReport(session *etaf_context.TrackingSession) {
+	timer := time.AfterFunc(session.ReportInterval, func() {
+		if update, ok := stream.LastLocation(session.Supi); ok {
+			update.Emergency = isEmergencySupi(session.Supi)
+			notifier.NotifyPeriodic(session, update, "")
+		}
+		schedulePeriodicReport(session)
+	})
+	session.SetReportTimer(timer)
+}
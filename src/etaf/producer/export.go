@@ -0,0 +1,275 @@
+package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/celldb"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/export"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var fileExtensions = map[string]string{
+	export.FormatGeoJSON: ".geojson",
+	export.FormatKML:     ".kml",
+	export.FormatCSV:     ".csv",
+}
+
+// HandleExportUeTrack renders the location history of a UE in the format
+// negotiated, placing each point by its LMF fix or its serving cell
+func HandleExportUeTrack(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	supi, problemDetails := resolveUeId(caller, request.Params["ueId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi)
+	log.Infof("Handle Export UE Track")
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          supi,
+		Purpose:       request.Query.Get("purpose"),
+		CorrelationId: correlationID,
+	}
+
+	format, since, problemDetails := exportParameters(request)
+	if problemDetails == nil {
+		if _, ok := etaf_context.ETAF_Self().EtafUeFindBySupi(supi); !ok {
+			problemDetails = &models.ProblemDetails{
+				Status: http.StatusNotFound,
+				Cause:  "CONTEXT_NOT_FOUND",
+			}
+		}
+	}
+	if problemDetails != nil {
+		log.Warnf("Export UE Track failed: %s", problemDetails.Cause)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	track := buildTrack(caller, supi, since)
+	problemDetails = auditDisclosure(auditEntry, map[string]interface{}{"export": format, "fixes": len(track.Fixes)})
+	if problemDetails != nil {
+		log.Errorf("UE Track not exported: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return renderTracks(format, "track-"+track.UeId, []export.Track{track})
+}
+
+// HandleExportTrackingSessionTrack renders the location histories of the UEs
+// followed by a tracking session, one track per UE
+func HandleExportTrackingSessionTrack(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithCorrelationID(logger.ProducerLog, correlationID)
+	log.Infof("Handle Export Tracking Session Track")
+
+	session, problemDetails := findTrackingSession(caller, request.Params["sessionId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	format, since, problemDetails := exportParameters(request)
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	members := session.Members
+	if !session.IsGroup() {
+		members = []etaf_context.GroupMember{{Supi: session.Supi}}
+	}
+	tracks := make([]export.Track, 0, len(members))
+	disclosures := make(map[string]interface{}, len(members))
+	for _, member := range members {
+		if member.Supi == "" {
+			ue, ok := etaf_context.ETAF_Self().EtafUeFindByGpsi(member.Gpsi)
+			if !ok {
+				continue
+			}
+			member.Supi = ue.UeId()
+		}
+		track := buildTrack(caller, member.Supi, since)
+		tracks = append(tracks, track)
+		disclosures[member.Supi] = map[string]interface{}{"session": "export", "sessionId": session.Id,
+			"groupId": session.GroupId, "export": format, "fixes": len(track.Fixes)}
+	}
+
+	problemDetails = auditUeDisclosures(audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		Purpose:       session.Purpose,
+		CorrelationId: correlationID,
+	}, disclosures)
+	if problemDetails != nil {
+		log.Errorf("Tracking Session Track not exported: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return renderTracks(format, "session-"+session.Id, tracks)
+}
+
+// HandleExportAlertArea renders the warning area of an alert: its geographic
+// area and the cells of its cell lists found in the cell database. TAIs have no
+// geometry and are left out.
+func HandleExportAlertArea(request *http_wrapper.Request) *http_wrapper.Response {
+	log := logger.WithAlertID(logger.WithCorrelationID(logger.ProducerLog,
+		request.Header.Get(logger.CorrelationIDHeader)), request.Params["alertId"])
+	log.Infof("Handle Export Alert Area")
+
+	alert, problemDetails := findAlert(request.Params["caller"], request.Params["alertId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	format, _, problemDetails := exportParameters(request)
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	var areas []export.Area
+	if alert.WarningArea.Area != nil {
+		areas = append(areas, export.Area{Name: "area", Area: *alert.WarningArea.Area})
+	}
+	for _, ncgi := range alert.WarningArea.NcgiList {
+		location := models.UserLocation{NrLocation: &models.NrLocation{Ncgi: &ncgi}}
+		if cell, ok := celldb.Find(location); ok {
+			areas = append(areas, export.Area{Name: cell.Id, Area: cell.Coverage()})
+		}
+	}
+	for _, ecgi := range alert.WarningArea.EcgiList {
+		location := models.UserLocation{EutraLocation: &models.EutraLocation{Ecgi: &ecgi}}
+		if cell, ok := celldb.Find(location); ok {
+			areas = append(areas, export.Area{Name: cell.Id, Area: cell.Coverage()})
+		}
+	}
+
+	view := buildAlertView(alert)
+	properties := map[string]string{
+		"alertId":           alert.Id,
+		"messageIdentifier": strconv.Itoa(int(alert.MessageIdentifier)),
+		"serialNumber":      strconv.Itoa(int(alert.SerialNumber)),
+		"status":            view.Status,
+	}
+	body, err := export.RenderAreas(format, "alert-"+alert.Id, properties, areas)
+	if err != nil {
+		return exportFailure(err)
+	}
+	return exportResponse(format, "alert-"+alert.Id, body)
+}
+
+// exportParameters negotiates the format and parses the since query parameter,
+// an RFC 3339 time from which the points are exported
+func exportParameters(request *http_wrapper.Request) (string, time.Time, *models.ProblemDetails) {
+	format, err := export.NegotiateFormat(request.Query.Get("format"), request.Header.Get("Accept"))
+	if err != nil {
+		if request.Query.Get("format") != "" {
+			return "", time.Time{}, invalidQueryParameter(err.Error())
+		}
+		return "", time.Time{}, &models.ProblemDetails{
+			Status: http.StatusNotAcceptable,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: err.Error(),
+		}
+	}
+	var since time.Time
+	if value := request.Query.Get("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			return "", time.Time{}, invalidQueryParameter("since must be an RFC 3339 time")
+		}
+	}
+	return format, since, nil
+}
+
+// buildTrack places the history points of a UE recorded since; coarsened points
+// and points whose cell is not in the cell database are left out
+func buildTrack(caller, supi string, since time.Time) export.Track {
+	track := export.Track{UeId: privacy.ProtectIdentifier(caller, supi)}
+	ue, ok := etaf_context.ETAF_Self().EtafUeFindBySupi(supi)
+	if !ok {
+		return track
+	}
+	track.Emergency = isEmergency(ue)
+	for _, point := range ue.LocationHistory.Points(since) {
+		if fix, ok := fixFromPoint(point); ok {
+			track.Fixes = append(track.Fixes, fix)
+		}
+	}
+	return track
+}
+
+func renderTracks(format, name string, tracks []export.Track) *http_wrapper.Response {
+	body, err := export.RenderTracks(format, tracks)
+	if err != nil {
+		return exportFailure(err)
+	}
+	return exportResponse(format, name, body)
+}
+
+func exportResponse(format, name string, body []byte) *http_wrapper.Response {
+	header := http.Header{
+		"Content-Type":        {export.ContentType(format)},
+		"Content-Disposition": {`attachment; filename="` + name + fileExtensions[format] + `"`},
+	}
+	return http_wrapper.NewResponse(http.StatusOK, header, body)
+}
+
+func exportFailure(err error) *http_wrapper.Response {
+	logger.ProducerLog.Errorf("Render export error: %+v", err)
+	problemDetails := &models.ProblemDetails{
+		Status: http.StatusInternalServerError,
+		Cause:  "SYSTEM_FAILURE",
+		Detail: err.Error(),
+	}
+	return http_wrapper.NewResponse(http.StatusInternalServerError, nil, problemDetails)
+}
+
+// fixFromPoint places a history point by its LMF fix or else by its serving cell
+// in the cell database; it returns false when neither gives coordinates
+func fixFromPoint(point etaf_context.LocationPoint) (export.Fix, bool) {
+	fix := export.Fix{
+		Time:   point.Time,
+		Tai:    point.Tai,
+		Source: EstimateSourceLmf,
+	}
+	if point.Location != nil {
+		fix.CellId = cellId(*point.Location)
+	}
+	estimate := point.Estimate
+	if estimate == nil && point.Location != nil {
+		var ok bool
+		if estimate, ok = celldb.Locate(*point.Location); !ok {
+			return fix, false
+		}
+		fix.Source = EstimateSourceCellDatabase
+	}
+	if estimate == nil {
+		return fix, false
+	}
+	center, ok := estimate.Center()
+	if !ok {
+		return fix, false
+	}
+	fix.Point = center
+	fix.Uncertainty = estimate.Uncertainty
+	if estimate.UncertaintyEllipse != nil {
+		fix.Uncertainty = estimate.UncertaintyEllipse.SemiMajor
+	}
+	return fix, true
+}
+
+func cellId(location models.UserLocation) string {
+	if location.NrLocation != nil && location.NrLocation.Ncgi != nil {
+		return location.NrLocation.Ncgi.NrCellId
+	}
+	if location.EutraLocation != nil && location.EutraLocation.Ecgi != nil {
+		return location.EutraLocation.Ecgi.EutraCellId
+	}
+	return ""
+}
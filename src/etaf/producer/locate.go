@@ -0,0 +1,437 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/Nnrf_NFDiscovery"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/celldb"
+	"free5gc/src/etaf/consumer"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/lmf"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultLocateTimeout = 10  // seconds
+	maxLocateTimeout     = 120 // seconds
+)
+
+type LocateRequest struct {
+	// Seconds to wait for the AMF or the LMF, 10 by default
+	Timeout int `json:"timeout,omitempty"`
+	// Ask the LMF for geographic coordinates rather than the AMF for the serving cell
+	Positioning bool `json:"positioning,omitempty"`
+	// Positioning QoS; the LMF configuration gives the defaults
+	HorizontalAccuracy float32 `json:"horizontalAccuracy,omitempty"` // meters
+	ResponseTime       string  `json:"responseTime,omitempty"`       // NO_DELAY, LOW_DELAY or DELAY_TOLERANT
+	// LCS client type and priority of the positioning; EMERGENCY_SERVICES and
+	// HIGHEST_PRIORITY, the defaults for the UEs in emergency services, are
+	// reserved to them. The other UEs default to VALUE_ADDED_SERVICES and
+	// NORMAL_PRIORITY.
+	ClientType models.ExternalClientType `json:"clientType,omitempty"`
+	Priority   models.LcsPriority        `json:"priority,omitempty"`
+	Purpose    string                    `json:"purpose,omitempty"`
+}
+
+// LocateResult is the current location of a UE or, when the AMF did not provide
+// it in time, its last known location with the cause
+type LocateResult struct {
+	UeId     string                 `json:"ueId"`
+	Current  bool                   `json:"current"`
+	CmState  models.CmState         `json:"cmState"` // CM-IDLE UEs are paged by the AMF
+	Time     *time.Time             `json:"time,omitempty"`
+	Age      *int64                 `json:"age,omitempty"` // seconds
+	Tai      *models.Tai            `json:"tai,omitempty"`
+	Location *models.UserLocation   `json:"location,omitempty"`
+	GeoInfo  *models.GeographicArea `json:"geoInfo,omitempty"` // as provided by the AMF
+	// Estimate of the LMF and whether it fulfils the accuracy requested, or the
+	// approximation of the serving cell by the cell database
+	Estimate          *etaf_context.GeographicArea `json:"estimate,omitempty"`
+	EstimateSource    string                       `json:"estimateSource,omitempty"`
+	AccuracyFulfilled *bool                        `json:"accuracyFulfilled,omitempty"`
+	RatType           models.RatType               `json:"ratType,omitempty"`
+	Emergency         bool                         `json:"emergency"` // the UE is in emergency services
+	Cause             string                       `json:"cause,omitempty"`
+}
+
+func HandleLocateUe(ctx context.Context, request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	locateRequest := request.Body.(LocateRequest)
+	supi, problemDetails := resolveUeId(caller, request.Params["ueId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi)
+	log.Infof("Handle Locate UE")
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          supi,
+		Purpose:       locateRequest.Purpose,
+		CorrelationId: correlationID,
+	}
+
+	result, problemDetails := LocateUeProcedure(ctx, caller, supi, locateRequest)
+	if problemDetails != nil {
+		log.Warnf("Locate UE failed: %s", problemDetails.Detail)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	problemDetails = auditDisclosure(auditEntry, map[string]interface{}{"locate": true, "current": result.Current,
+		"positioning": locateRequest.Positioning, "cause": result.Cause})
+	if problemDetails != nil {
+		log.Errorf("Location of UE not disclosed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, result)
+}
+
+// LocateUeProcedure asks the serving AMF of a UE for its current location and
+// waits for it up to the timeout of locateRequest. The location obtained is
+// applied like a location report.
+func LocateUeProcedure(ctx context.Context, caller, supi string, locateRequest LocateRequest) (
+	*LocateResult, *models.ProblemDetails) {
+	timeout := locateRequest.Timeout
+	if timeout == 0 {
+		timeout = defaultLocateTimeout
+	}
+	if timeout < 0 || timeout > maxLocateTimeout {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: fmt.Sprintf("timeout must be between 1 and %d seconds", maxLocateTimeout),
+		}
+	}
+	switch locateRequest.ResponseTime {
+	case "", lmf.ResponseTimeNoDelay, lmf.ResponseTimeLowDelay, lmf.ResponseTimeDelayTolerant:
+	default:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "responseTime must be NO_DELAY, LOW_DELAY or DELAY_TOLERANT",
+		}
+	}
+	if locateRequest.HorizontalAccuracy < 0 {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "horizontalAccuracy must not be negative",
+		}
+	}
+	switch locateRequest.ClientType {
+	case "", models.ExternalClientType_EMERGENCY_SERVICES, models.ExternalClientType_VALUE_ADDED_SERVICES,
+		models.ExternalClientType_PLMN_OPERATOR_SERVICES, models.ExternalClientType_LAWFUL_INTERCEPT_SERVICES,
+		models.ExternalClientType_PLMN_OPERATOR_BROADCAST_SERVICES, models.ExternalClientType_PLMN_OPERATOR_OM,
+		models.ExternalClientType_PLMN_OPERATOR_ANONYMOUS_STATISTICS,
+		models.ExternalClientType_PLMN_OPERATOR_TARGET_MS_SERVICE_SUPPORT:
+	default:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "clientType must be an LCS external client type",
+		}
+	}
+	switch locateRequest.Priority {
+	case "", models.LcsPriority_HIGHEST_PRIORITY, models.LcsPriority_NORMAL_PRIORITY:
+	default:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "priority must be HIGHEST_PRIORITY or NORMAL_PRIORITY",
+		}
+	}
+
+	etafSelf := etaf_context.ETAF_Self()
+	ue, ok := etafSelf.EtafUeFindBySupi(supi)
+	if !ok {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: fmt.Sprintf("UE[%s] not found", privacy.ProtectIdentifier(caller, supi)),
+		}
+	}
+
+	result := &LocateResult{
+		UeId:      privacy.ProtectIdentifier(caller, supi),
+		CmState:   models.CmState_IDLE,
+		Emergency: isEmergency(ue),
+	}
+	if locateRequest.Positioning {
+		if problemDetails := setLcsClient(&locateRequest, result.Emergency); problemDetails != nil {
+			return nil, problemDetails
+		}
+	}
+	if ue.CmConnect(models.AccessType__3_GPP_ACCESS) || ue.CmConnect(models.AccessType_NON_3_GPP_ACCESS) {
+		result.CmState = models.CmState_CONNECTED
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var problemDetails *models.ProblemDetails
+	var err error
+	if locateRequest.Positioning {
+		problemDetails, err = positionUe(ctx, ue, locateRequest, result)
+	} else {
+		problemDetails, err = locateAtAmf(ctx, ue, result)
+	}
+	if problemDetails == nil && err == nil {
+		return result, nil
+	}
+
+	// fall back on the last known location
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Cause = "TIMED_OUT_REQUEST"
+	case problemDetails != nil:
+		result.Cause = problemDetails.Cause
+	default:
+		result.Cause = "SYSTEM_FAILURE"
+	}
+	if problemDetails != nil {
+		logger.WithSupi(logger.ProducerLog, supi).Warnf("Locate UE failed: %s", problemDetails.Cause)
+	} else {
+		logger.WithSupi(logger.ProducerLog, supi).Warnf("Locate UE error: %+v", err)
+	}
+
+	known, ok := lastKnownLocation(supi)
+	if !ok {
+		if result.Cause == "TIMED_OUT_REQUEST" {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusGatewayTimeout,
+				Cause:  result.Cause,
+				Detail: "the location was not provided in time and no location is known",
+			}
+		} else if problemDetails != nil {
+			return nil, problemDetails
+		}
+		return nil, &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+	}
+	result.Time = known.Time
+	result.Tai = &known.Tai
+	result.Location = known.Location
+	result.Estimate = known.Estimate
+	result.EstimateSource = known.EstimateSource
+	if known.Time != nil {
+		age := int64(time.Since(*known.Time) / time.Second)
+		result.Age = &age
+	}
+	return result, nil
+}
+
+// setLcsClient defaults the LCS client type and priority of a positioning by
+// whether the UE is in emergency services, and rejects the emergency values for
+// the other UEs
+func setLcsClient(locateRequest *LocateRequest, emergency bool) *models.ProblemDetails {
+	if emergency {
+		if locateRequest.ClientType == "" {
+			locateRequest.ClientType = models.ExternalClientType_EMERGENCY_SERVICES
+		}
+		if locateRequest.Priority == "" {
+			locateRequest.Priority = models.LcsPriority_HIGHEST_PRIORITY
+		}
+		return nil
+	}
+
+	if locateRequest.ClientType == models.ExternalClientType_EMERGENCY_SERVICES ||
+		locateRequest.Priority == models.LcsPriority_HIGHEST_PRIORITY {
+		return &models.ProblemDetails{
+			Status: http.StatusForbidden,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "EMERGENCY_SERVICES and HIGHEST_PRIORITY are reserved to the UEs in emergency services",
+		}
+	}
+	if locateRequest.ClientType == "" {
+		locateRequest.ClientType = models.ExternalClientType_VALUE_ADDED_SERVICES
+	}
+	if locateRequest.Priority == "" {
+		locateRequest.Priority = models.LcsPriority_NORMAL_PRIORITY
+	}
+	return nil
+}
+
+// locateAtAmf fills result with the location provided by the serving AMF of the
+// UE, selecting the AMF through the NRF if the UE has none
+func locateAtAmf(ctx context.Context, ue *etaf_context.EtafUe, result *LocateResult) (
+	*models.ProblemDetails, error) {
+	if err := selectAmf(ctx, ue); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	locInfo, problemDetails, err := consumer.ProvideLocationInfo(ctx, ue.AmfUri, ue.UeId())
+	if problemDetails != nil || err != nil {
+		return problemDetails, err
+	}
+	// the age of a location is given in minutes
+	timestamp := now.Add(-time.Duration(locInfo.LocatoinAge) * time.Minute)
+	age := int64(now.Sub(timestamp) / time.Second)
+	result.Current = locInfo.CurrentLoc
+	result.Time = &timestamp
+	result.Age = &age
+	result.Location = locInfo.Location
+	result.GeoInfo = locInfo.GeoInfo
+	result.RatType = locInfo.RatType
+	if locInfo.Location != nil {
+		LocationInfoNotifyProcedure(models.AmfEventNotification{
+			ReportList: []models.AmfEventReport{
+				{
+					Type:      models.AmfEventType_LOCATION_REPORT,
+					Supi:      ue.Supi,
+					Gpsi:      ue.Gpsi,
+					Pei:       ue.Pei,
+					Location:  locInfo.Location,
+					TimeStamp: &timestamp,
+				},
+			},
+		}, logger.CorrelationIDFromContext(ctx))
+		if known, ok := lastKnownLocation(ue.UeId()); ok {
+			result.Tai = &known.Tai
+		}
+		if estimate, ok := celldb.Locate(*locInfo.Location); ok {
+			result.Estimate = estimate
+			result.EstimateSource = EstimateSourceCellDatabase
+		}
+	}
+	if !result.Current {
+		result.Cause = "LOCATION_NOT_CURRENT"
+	}
+	return nil, nil
+}
+
+// positionUe fills result with the location estimate of the LMF, falling back on
+// the positioning of the serving AMF when no LMF can be selected. The estimate is
+// stored next to the current location of the UE.
+func positionUe(ctx context.Context, ue *etaf_context.EtafUe, locateRequest LocateRequest,
+	result *LocateResult) (*models.ProblemDetails, error) {
+	lmfUri, err := selectLmf(ctx)
+	if err != nil {
+		logger.WithSupi(logger.ProducerLog, ue.UeId()).Warnf("%+v; positioning through the AMF", err)
+		return positionAtAmf(ctx, ue, locateRequest, result)
+	}
+
+	qos := &lmf.LocationQoS{
+		HAccuracy:    locateRequest.HorizontalAccuracy,
+		ResponseTime: locateRequest.ResponseTime,
+	}
+	if lmfConfig := factory.EtafConfig.Configuration.Lmf; lmfConfig != nil {
+		if qos.HAccuracy == 0 {
+			qos.HAccuracy = lmfConfig.HorizontalAccuracy
+		}
+		if qos.ResponseTime == "" {
+			qos.ResponseTime = lmfConfig.ResponseTime
+		}
+	}
+	if qos.ResponseTime == "" {
+		qos.ResponseTime = lmf.ResponseTimeLowDelay
+	}
+	input := lmf.InputData{
+		ExternalClientType: string(locateRequest.ClientType),
+		CorrelationID:      logger.CorrelationIDFromContext(ctx),
+		AmfId:              ue.AmfId,
+		LocationQoS:        qos,
+		SupportedGADShapes: []string{etaf_context.GADShapePoint, etaf_context.GADShapePointUncertaintyCircle,
+			etaf_context.GADShapePointUncertaintyEllipse, etaf_context.GADShapePolygon},
+		Supi:     ue.Supi,
+		Gpsi:     ue.Gpsi,
+		Priority: string(locateRequest.Priority),
+	}
+	if ue.Location.NrLocation != nil {
+		input.Ncgi = ue.Location.NrLocation.Ncgi
+	} else if ue.Location.EutraLocation != nil {
+		input.Ecgi = ue.Location.EutraLocation.Ecgi
+	}
+
+	locationData, problemDetails, err := consumer.DetermineLocation(ctx, lmfUri, input)
+	if problemDetails != nil || err != nil {
+		return problemDetails, err
+	}
+
+	now := time.Now().UTC()
+	// the age of a location estimate is given in minutes
+	timestamp := now.Add(-time.Duration(locationData.AgeOfLocationEstimate) * time.Minute)
+	if locationData.TimestampOfLocationEstimate != nil {
+		timestamp = locationData.TimestampOfLocationEstimate.UTC()
+	}
+	ue.LocationMutex.Lock()
+	ue.RecordLocationEstimate(models.AccessType__3_GPP_ACCESS, locationData.LocationEstimate, timestamp)
+	location := ue.Location
+	tai := ue.Tai
+	ue.LocationMutex.Unlock()
+
+	age := int64(now.Sub(timestamp) / time.Second)
+	accuracyFulfilled := locationData.AccuracyFulfilmentIndicator != lmf.AccuracyNotFulfilled
+	result.Current = true
+	result.Time = &timestamp
+	result.Age = &age
+	result.Tai = &tai
+	result.Location = &location
+	result.Estimate = &locationData.LocationEstimate
+	result.EstimateSource = EstimateSourceLmf
+	result.AccuracyFulfilled = &accuracyFulfilled
+	return nil, nil
+}
+
+// positionAtAmf fills result with the positioning of the serving AMF of the UE
+func positionAtAmf(ctx context.Context, ue *etaf_context.EtafUe, locateRequest LocateRequest,
+	result *LocateResult) (*models.ProblemDetails, error) {
+	if err := selectAmf(ctx, ue); err != nil {
+		return nil, err
+	}
+
+	posInfo, problemDetails, err := consumer.ProvidePositioningInfo(ctx, ue.AmfUri, ue.UeId(),
+		locateRequest.ClientType, locateRequest.Priority)
+	if problemDetails != nil || err != nil {
+		return problemDetails, err
+	}
+	now := time.Now().UTC()
+	// the age of a location estimate is given in minutes
+	timestamp := now.Add(-time.Duration(posInfo.AgeOfLocationEstimate) * time.Minute)
+	age := int64(now.Sub(timestamp) / time.Second)
+	result.Current = true
+	result.Time = &timestamp
+	result.Age = &age
+	result.GeoInfo = posInfo.LocationEstimate
+	return nil, nil
+}
+
+// selectAmf selects the AMF of the UE through the NRF if the UE has none
+func selectAmf(ctx context.Context, ue *etaf_context.EtafUe) error {
+	if ue.AmfUri != "" {
+		return nil
+	}
+	param := Nnrf_NFDiscovery.SearchNFInstancesParamOpts{}
+	return consumer.SearchAmfCommunicationInstance(ctx, ue, etaf_context.ETAF_Self().NrfUri,
+		models.NfType_AMF, models.NfType_ETAF, &param)
+}
+
+// selectLmf returns the configured LMF, the stand-in LMF of the ETAF if it is
+// enabled, or else an LMF discovered through the NRF
+func selectLmf(ctx context.Context) (string, error) {
+	etafSelf := etaf_context.ETAF_Self()
+	if lmfConfig := factory.EtafConfig.Configuration.Lmf; lmfConfig != nil {
+		if lmfConfig.Uri != "" {
+			return lmfConfig.Uri, nil
+		}
+		if lmfConfig.StandIn != nil && lmfConfig.StandIn.Enable {
+			return etafSelf.GetIPv4Uri(), nil
+		}
+	}
+	return consumer.SearchLmfUri(ctx, etafSelf.NrfUri)
+}
@@ -0,0 +1,381 @@
+package producer
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/consumer"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"strconv"
+)
+
+// LocationReportingRequest asks for the location of a UE once, at every change of
+// serving cell, or when it enters or leaves an area of interest. The serving AMF
+// of the UE controls the reporting of the NG-RAN, and its reports reach the
+// location streams and tracking sessions.
+type LocationReportingRequest struct {
+	// DIRECT, CHANGE_OF_SERVE_CELL or AREA_OF_INTEREST
+	Type string `json:"type"`
+	// Area of interest of AREA_OF_INTEREST, given by value or by the TAIs and cells
+	// of the geofence GeofenceId
+	AreaOfInterest *etaf_context.AreaOfInterest `json:"areaOfInterest,omitempty"`
+	GeofenceId     string                       `json:"geofenceId,omitempty"`
+}
+
+type LocationReportingView struct {
+	UeId              string                        `json:"ueId"`
+	ChangeOfServeCell bool                          `json:"changeOfServeCell"`
+	AreasOfInterest   []etaf_context.AreaOfInterest `json:"areasOfInterest,omitempty"`
+	Emergency         bool                          `json:"emergency"`
+	// Reference ID allocated to the area of interest requested
+	ReferenceId int64 `json:"referenceId,omitempty"`
+}
+
+func HandleRequestLocationReporting(ctx context.Context, request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	reportingRequest := request.Body.(LocationReportingRequest)
+	supi, problemDetails := resolveUeId(caller, request.Params["ueId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi)
+	log.Infof("Handle Request Location Reporting")
+
+	ctx = logger.ContextWithCorrelationID(ctx, correlationID)
+	view, problemDetails := RequestLocationReportingProcedure(ctx, caller, supi, reportingRequest)
+	if problemDetails != nil {
+		log.Warnf("Request Location Reporting failed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	// the locations are reported asynchronously
+	return http_wrapper.NewResponse(http.StatusAccepted, nil, view)
+}
+
+func HandleGetLocationReporting(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	supi, problemDetails := resolveUeId(caller, request.Params["ueId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)),
+		supi).Infof("Handle Get Location Reporting")
+
+	ue, problemDetails := reportingUe(caller, supi)
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, buildLocationReportingView(caller, ue))
+}
+
+// HandleStopLocationReporting stops the reporting on the area of interest given
+// by the referenceId query parameter, or else all the location reporting of the UE
+func HandleStopLocationReporting(ctx context.Context, request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	supi, problemDetails := resolveUeId(caller, request.Params["ueId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi).
+		Infof("Handle Stop Location Reporting")
+
+	var referenceId int64
+	if value := request.Query.Get("referenceId"); value != "" {
+		var err error
+		if referenceId, err = strconv.ParseInt(value, 10, 64); err != nil || referenceId < 1 {
+			problemDetails := invalidQueryParameter("referenceId must be a reference ID of an area of interest")
+			return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+		}
+	}
+
+	ctx = logger.ContextWithCorrelationID(ctx, correlationID)
+	if problemDetails := StopLocationReportingProcedure(ctx, caller, supi, referenceId); problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusNoContent, nil, nil)
+}
+
+// RequestLocationReportingProcedure subscribes to the location reports of the UE
+// at its serving AMF, which sends Location Reporting Control to the NG-RAN
+// (TS 23.502 4.15.3.2.2): a one-time report for DIRECT, continuous reports for
+// CHANGE_OF_SERVE_CELL, and presence reports in the area of AREA_OF_INTEREST
+func RequestLocationReportingProcedure(ctx context.Context, caller, supi string,
+	reportingRequest LocationReportingRequest) (*LocationReportingView, *models.ProblemDetails) {
+	var area etaf_context.AreaOfInterest
+	switch reportingRequest.Type {
+	case etaf_context.LocationReportingDirect, etaf_context.LocationReportingChangeOfServeCell:
+	case etaf_context.LocationReportingAreaOfInterest:
+		if reportingRequest.AreaOfInterest != nil {
+			area = *reportingRequest.AreaOfInterest
+		}
+		if reportingRequest.GeofenceId != "" {
+			geofence, ok := findGeofence(reportingRequest.GeofenceId)
+			if !ok {
+				return nil, &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Cause:  "CONTEXT_NOT_FOUND",
+					Detail: fmt.Sprintf("geofence[%s] not found", reportingRequest.GeofenceId),
+				}
+			}
+			area.TaiList = append(area.TaiList, geofence.TaiList...)
+			area.NcgiList = append(area.NcgiList, geofence.NcgiList...)
+			area.EcgiList = append(area.EcgiList, geofence.EcgiList...)
+		}
+		if err := validateAreaOfInterest(area); err != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusBadRequest,
+				Cause:  "MANDATORY_IE_INCORRECT",
+				Detail: err.Error(),
+			}
+		}
+	default:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "type must be DIRECT, CHANGE_OF_SERVE_CELL or AREA_OF_INTEREST",
+		}
+	}
+
+	ue, problemDetails := reportingUe(caller, supi)
+	if problemDetails != nil {
+		return nil, problemDetails
+	}
+	if err := selectAmf(ctx, ue); err != nil {
+		return nil, amfFailure(err)
+	}
+	target := ueEventSubscription(etaf_context.GroupMember{Supi: ue.UeId()})
+	correlationId := locationReportingCorrelationId(ue)
+
+	var referenceId int64
+	switch reportingRequest.Type {
+	case etaf_context.LocationReportingDirect:
+		target.Options = &models.AmfEventMode{
+			Trigger:    models.AmfEventTrigger_ONE_TIME,
+			MaxReports: 1,
+		}
+		if _, problemDetails := subscribeLocationReporting(ctx, ue, correlationId, target, nil); problemDetails != nil {
+			return nil, problemDetails
+		}
+	case etaf_context.LocationReportingChangeOfServeCell:
+		if ue.LocationReporting.ChangeOfServeCell() {
+			break
+		}
+		subscription, problemDetails := subscribeLocationReporting(ctx, ue, correlationId, target, nil)
+		if problemDetails != nil {
+			return nil, problemDetails
+		}
+		if previous := ue.LocationReporting.SetChangeOfServeCell(subscription); previous != nil {
+			unsubscribeAmfEvents(ctx, []etaf_context.AmfSubscription{*previous})
+		}
+	case etaf_context.LocationReportingAreaOfInterest:
+		var err error
+		if referenceId, err = ue.LocationReporting.AddAreaOfInterest(area); err != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusForbidden,
+				Cause:  "INSUFFICIENT_RESOURCES",
+				Detail: err.Error(),
+			}
+		}
+		presenceInfo := &models.PresenceInfo{
+			PraId:            strconv.FormatInt(referenceId, 10),
+			TrackingAreaList: area.TaiList,
+			NcgiList:         area.NcgiList,
+			EcgiList:         area.EcgiList,
+		}
+		subscription, problemDetails := subscribeLocationReporting(ctx, ue, correlationId, target, presenceInfo)
+		if problemDetails != nil {
+			ue.LocationReporting.RemoveAreaOfInterest(referenceId)
+			return nil, problemDetails
+		}
+		if !ue.LocationReporting.SetAreaSubscription(referenceId, *subscription) {
+			unsubscribeAmfEvents(ctx, []etaf_context.AmfSubscription{*subscription})
+		}
+	}
+
+	view := buildLocationReportingView(caller, ue)
+	view.ReferenceId = referenceId
+	return view, nil
+}
+
+// StopLocationReportingProcedure stops the reporting on the area of interest
+// referenceId, or all the location reporting of the UE if referenceId is 0
+func StopLocationReportingProcedure(ctx context.Context, caller, supi string,
+	referenceId int64) *models.ProblemDetails {
+	ue, problemDetails := reportingUe(caller, supi)
+	if problemDetails != nil {
+		return problemDetails
+	}
+
+	if referenceId != 0 {
+		subscription, ok := ue.LocationReporting.RemoveAreaOfInterest(referenceId)
+		if !ok {
+			return &models.ProblemDetails{
+				Status: http.StatusNotFound,
+				Cause:  "CONTEXT_NOT_FOUND",
+				Detail: fmt.Sprintf("area of interest[%d] not found", referenceId),
+			}
+		}
+		if subscription != nil {
+			unsubscribeAmfEvents(ctx, []etaf_context.AmfSubscription{*subscription})
+		}
+		return nil
+	}
+
+	unsubscribeAmfEvents(ctx, ue.LocationReporting.Cancel())
+	return nil
+}
+
+// subscribeLocationReporting subscribes to the location reports of the UE at its
+// serving AMF, or to its presence reports in presenceInfo if given. The reports
+// the AMF returns immediately are applied at once.
+func subscribeLocationReporting(ctx context.Context, ue *etaf_context.EtafUe, correlationId string,
+	target models.AmfEventSubscription, presenceInfo *models.PresenceInfo) (
+	*etaf_context.AmfSubscription, *models.ProblemDetails) {
+	var subscriptionId string
+	var reports []models.AmfEventReport
+	var problemDetails *models.ProblemDetails
+	var err error
+	if presenceInfo != nil {
+		subscriptionId, reports, problemDetails, err =
+			consumer.AmfPresenceReportSubscribe(ctx, ue.AmfUri, correlationId, target, *presenceInfo)
+	} else {
+		subscriptionId, reports, problemDetails, err =
+			consumer.AmfLocationReportSubscribe(ctx, ue.AmfUri, correlationId, target)
+	}
+	if problemDetails != nil {
+		return nil, problemDetails
+	} else if err != nil {
+		return nil, amfFailure(err)
+	}
+
+	if len(reports) > 0 {
+		LocationInfoNotifyProcedure(models.AmfEventNotification{
+			NotifyCorrelationId: correlationId,
+			ReportList:          reports,
+		}, logger.CorrelationIDFromContext(ctx))
+	}
+	return &etaf_context.AmfSubscription{
+		AmfUri:         ue.AmfUri,
+		SubscriptionId: subscriptionId,
+	}, nil
+}
+
+// locationReportingCorrelationId correlates the reports of the location reporting
+// of a UE
+func locationReportingCorrelationId(ue *etaf_context.EtafUe) string {
+	return "reporting-" + ue.UeId()
+}
+
+// presenceReportProcedure applies a presence in area of interest report of the
+// AMF; the area is identified by its reference ID, given as presence reporting
+// area ID
+func presenceReportProcedure(report models.AmfEventReport) {
+	ue, ok := etaf_context.ETAF_Self().EtafUeFindBySupi(report.Supi)
+	if !ok && report.Pei != "" {
+		ue, ok = etaf_context.ETAF_Self().EtafUeFindByPei(report.Pei)
+	}
+	if !ok {
+		logger.CallbackLog.Debugf("Presence report of an unknown UE ignored")
+		return
+	}
+	for _, area := range report.AreaList {
+		if area.PresenceInfo == nil {
+			continue
+		}
+		referenceId, err := strconv.ParseInt(area.PresenceInfo.PraId, 10, 64)
+		if err != nil {
+			continue
+		}
+		presence := etaf_context.UePresenceUnknown
+		switch area.PresenceInfo.PresenceState {
+		case models.PresenceState_IN_AREA:
+			presence = etaf_context.UePresenceIn
+		case models.PresenceState_OUT_OF_AREA:
+			presence = etaf_context.UePresenceOut
+		}
+		if ue.LocationReporting.SetPresence(referenceId, presence) {
+			logger.WithSupi(logger.CallbackLog, ue.UeId()).Infof("Presence in area of interest[%d]: %s",
+				referenceId, presence)
+		}
+	}
+}
+
+// removeUe removes a UE from the ETAF context, ending first its emergency tracking
+// session and the AMF subscriptions of its location reporting
+func removeUe(ctx context.Context, ue *etaf_context.EtafUe) {
+	ue.EmergencyMutex.Lock()
+	if ue.EmergencySessionId != "" {
+		endEmergencySession(ue, "UE removed", logger.CorrelationIDFromContext(ctx))
+	}
+	ue.EmergencyMutex.Unlock()
+
+	unsubscribeAmfEvents(ctx, ue.LocationReporting.Cancel())
+	etafSelf := etaf_context.ETAF_Self()
+	etafSelf.ForgetNotifyCorrelation(locationReportingCorrelationId(ue))
+	etafSelf.ForgetNotifyCorrelation(refreshCorrelationId(ue))
+	ue.Remove()
+}
+
+// reportingUe returns a UE known to the ETAF
+func reportingUe(caller, supi string) (*etaf_context.EtafUe, *models.ProblemDetails) {
+	ue, ok := etaf_context.ETAF_Self().EtafUeFindBySupi(supi)
+	if !ok {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: fmt.Sprintf("UE[%s] not found", privacy.ProtectIdentifier(caller, supi)),
+		}
+	}
+	return ue, nil
+}
+
+func amfFailure(err error) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Status: http.StatusInternalServerError,
+		Cause:  "SYSTEM_FAILURE",
+		Detail: err.Error(),
+	}
+}
+
+func validateAreaOfInterest(area etaf_context.AreaOfInterest) error {
+	if len(area.TaiList) == 0 && len(area.NcgiList) == 0 && len(area.EcgiList) == 0 {
+		return fmt.Errorf("areaOfInterest or geofenceId with TAIs or cells is required")
+	}
+	if len(area.TaiList) > etaf_context.MaxNumOfTAI {
+		return fmt.Errorf("an area of interest may not have more than %d TAIs", etaf_context.MaxNumOfTAI)
+	}
+	for _, tai := range area.TaiList {
+		if tai.PlmnId == nil {
+			return fmt.Errorf("TAI[%s] has no plmnId", tai.Tac)
+		}
+		if tac, err := hex.DecodeString(tai.Tac); err != nil || len(tac) != 3 {
+			return fmt.Errorf("TAC[%s] must be 6 hexadecimal digits", tai.Tac)
+		}
+	}
+	for _, ncgi := range area.NcgiList {
+		if ncgi.PlmnId == nil || len(ncgi.NrCellId) != 9 {
+			return fmt.Errorf("NCGI[%s] needs a plmnId and a 9 digit nrCellId", ncgi.NrCellId)
+		}
+	}
+	for _, ecgi := range area.EcgiList {
+		if ecgi.PlmnId == nil || len(ecgi.EutraCellId) != 7 {
+			return fmt.Errorf("ECGI[%s] needs a plmnId and a 7 digit eutraCellId", ecgi.EutraCellId)
+		}
+	}
+	return nil
+}
+
+func buildLocationReportingView(caller string, ue *etaf_context.EtafUe) *LocationReportingView {
+	return &LocationReportingView{
+		UeId:              privacy.ProtectIdentifier(caller, ue.UeId()),
+		ChangeOfServeCell: ue.LocationReporting.ChangeOfServeCell(),
+		AreasOfInterest:   ue.LocationReporting.AreasOfInterest(),
+		Emergency:         isEmergency(ue),
+	}
+}
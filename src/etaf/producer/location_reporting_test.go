@@ -0,0 +1,170 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"free5gc/lib/openapi/models"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/stream"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// fakeAmf serves the Namf_EventExposure subscriptions over h2c, as the clients
+// expect, returning an immediate location report with every location report
+// subscription
+type fakeAmf struct {
+	mutex         sync.Mutex
+	subscriptions map[string]models.AmfEventSubscription
+	location      models.UserLocation
+}
+
+func (amf *fakeAmf) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	amf.mutex.Lock()
+	defer amf.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/subscriptions"):
+		var create models.AmfCreateEventSubscription
+		if err := json.NewDecoder(r.Body).Decode(&create); err != nil || create.Subscription == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		subscriptionId := string(rune('1' + len(amf.subscriptions)))
+		amf.subscriptions[subscriptionId] = *create.Subscription
+		created := models.AmfCreatedEventSubscription{
+			Subscription:   create.Subscription,
+			SubscriptionId: subscriptionId,
+		}
+		if (*create.Subscription.EventList)[0].Type == models.AmfEventType_LOCATION_REPORT {
+			created.ReportList = []models.AmfEventReport{{
+				Type:     models.AmfEventType_LOCATION_REPORT,
+				Supi:     create.Subscription.Supi,
+				Location: &amf.location,
+			}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(created)
+	case r.Method == http.MethodDelete:
+		delete(amf.subscriptions, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (amf *fakeAmf) count() int {
+	amf.mutex.Lock()
+	defer amf.mutex.Unlock()
+	return len(amf.subscriptions)
+}
+
+func TestLocationReporting(t *testing.T) {
+	if factory.EtafConfig.Configuration == nil {
+		factory.EtafConfig.Configuration = &factory.Configuration{}
+		defer func() { factory.EtafConfig.Configuration = nil }()
+	}
+
+	plmnId := models.PlmnId{Mcc: "208", Mnc: "93"}
+	tai := models.Tai{PlmnId: &plmnId, Tac: "000001"}
+	location := models.UserLocation{NrLocation: &models.NrLocation{
+		Tai:  &tai,
+		Ncgi: &models.Ncgi{PlmnId: &plmnId, NrCellId: "000000010"},
+	}}
+	amf := &fakeAmf{subscriptions: make(map[string]models.AmfEventSubscription), location: location}
+	server := httptest.NewServer(h2c.NewHandler(amf, &http2.Server{}))
+	defer server.Close()
+
+	supi := "imsi-208930000000042"
+	ue := etaf_context.ETAF_Self().NewEtafUe(supi)
+	ue.AmfUri = server.URL
+	defer func() {
+		ue.Remove()
+		stream.Forget(supi)
+	}()
+
+	ctx := context.Background()
+	testCases := []struct {
+		name          string
+		request       LocationReportingRequest
+		subscriptions int
+		check         func(view *LocationReportingView) bool
+	}{
+		{
+			name:          "direct",
+			request:       LocationReportingRequest{Type: etaf_context.LocationReportingDirect},
+			subscriptions: 1, // one-time, removed by the AMF once reported
+			check:         func(view *LocationReportingView) bool { return !view.ChangeOfServeCell },
+		},
+		{
+			name:          "change of serving cell",
+			request:       LocationReportingRequest{Type: etaf_context.LocationReportingChangeOfServeCell},
+			subscriptions: 2,
+			check:         func(view *LocationReportingView) bool { return view.ChangeOfServeCell },
+		},
+		{
+			name: "area of interest",
+			request: LocationReportingRequest{
+				Type:           etaf_context.LocationReportingAreaOfInterest,
+				AreaOfInterest: &etaf_context.AreaOfInterest{TaiList: []models.Tai{tai}},
+			},
+			subscriptions: 3,
+			check: func(view *LocationReportingView) bool {
+				return view.ReferenceId == 1 && len(view.AreasOfInterest) == 1
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stream.Forget(supi)
+			view, problemDetails := RequestLocationReportingProcedure(ctx, "tester", supi, testCase.request)
+			if problemDetails != nil {
+				t.Fatalf("location reporting failed: %+v", problemDetails)
+			}
+			if !testCase.check(view) {
+				t.Errorf("unexpected view %+v", view)
+			}
+			if count := amf.count(); count != testCase.subscriptions {
+				t.Errorf("%d AMF subscriptions, expected %d", count, testCase.subscriptions)
+			}
+			// the immediate location report reaches PublishLocation
+			if testCase.request.Type == etaf_context.LocationReportingAreaOfInterest {
+				return
+			}
+			update, ok := stream.LastLocation(supi)
+			if !ok || update.Location.NrLocation == nil || update.Location.NrLocation.Ncgi.NrCellId != "000000010" {
+				t.Errorf("location report not published: %+v", update)
+			}
+		})
+	}
+
+	LocationInfoNotifyProcedure(models.AmfEventNotification{
+		NotifyCorrelationId: locationReportingCorrelationId(ue),
+		ReportList: []models.AmfEventReport{{
+			Type: models.AmfEventType_PRESENCE_IN_AOI_REPORT,
+			Supi: supi,
+			AreaList: []models.AmfEventArea{{PresenceInfo: &models.PresenceInfo{
+				PraId:         "1",
+				PresenceState: models.PresenceState_IN_AREA,
+			}}},
+		}},
+	}, "")
+	areas := ue.LocationReporting.AreasOfInterest()
+	if len(areas) != 1 || areas[0].Presence != etaf_context.UePresenceIn {
+		t.Errorf("presence report not applied: %+v", areas)
+	}
+
+	// the one-time subscription is not kept, the continuous ones are to be removed
+	if subscriptions := ue.LocationReporting.Cancel(); len(subscriptions) != 2 {
+		t.Errorf("%d AMF subscriptions to be removed, expected 2", len(subscriptions))
+	}
+}
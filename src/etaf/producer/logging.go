@@ -0,0 +1,190 @@
+package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/logger"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LoggingConfig struct {
+	// Level to apply; empty keeps the current level
+	Level string `json:"level,omitempty"`
+	// Category to change (e.g. "NGAP" or "NgapLog"); empty applies to all categories
+	Category string `json:"category,omitempty"`
+	// Seconds after which the previous level is restored; 0 keeps the new level
+	Duration int `json:"duration,omitempty"`
+	// Toggles SetReportCaller for all categories
+	ReportCaller *bool `json:"reportCaller,omitempty"`
+}
+
+type CategoryLogging struct {
+	Level       string     `json:"level"`
+	RevertLevel string     `json:"revertLevel,omitempty"`
+	RevertAt    *time.Time `json:"revertAt,omitempty"`
+}
+
+type LoggingStatus struct {
+	ReportCaller bool                       `json:"reportCaller"`
+	Categories   map[string]CategoryLogging `json:"categories"`
+}
+
+// logLevelRevert restores the level a category had before a temporary change
+type logLevelRevert struct {
+	level logrus.Level
+	at    time.Time
+	timer *time.Timer
+}
+
+var logLevelReverts = make(map[string]*logLevelRevert) // category as key
+var logLevelMutex sync.Mutex
+
+func HandleOAMGetLogging(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("[OAM] Handle Get Logging")
+
+	return http_wrapper.NewResponse(http.StatusOK, nil, OAMGetLoggingProcedure())
+}
+
+func HandleOAMSetLogging(request *http_wrapper.Request) *http_wrapper.Response {
+	log := logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader))
+	log.Infof("[OAM] Handle Set Logging")
+
+	loggingConfig := request.Body.(LoggingConfig)
+
+	loggingStatus, problemDetails := OAMSetLoggingProcedure(loggingConfig)
+	if problemDetails != nil {
+		log.Warnf("[OAM] Set Logging failed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else {
+		return http_wrapper.NewResponse(http.StatusOK, nil, loggingStatus)
+	}
+}
+
+func OAMGetLoggingProcedure() *LoggingStatus {
+	logLevelMutex.Lock()
+	defer logLevelMutex.Unlock()
+
+	loggingStatus := &LoggingStatus{
+		ReportCaller: logger.ReportCaller(),
+		Categories:   make(map[string]CategoryLogging),
+	}
+	for name, level := range logger.CategoryLogLevels() {
+		categoryLogging := CategoryLogging{
+			Level: level.String(),
+		}
+		if revert, ok := logLevelReverts[name]; ok {
+			revertAt := revert.at
+			categoryLogging.RevertLevel = revert.level.String()
+			categoryLogging.RevertAt = &revertAt
+		}
+		loggingStatus.Categories[name] = categoryLogging
+	}
+	return loggingStatus
+}
+
+func OAMSetLoggingProcedure(loggingConfig LoggingConfig) (*LoggingStatus, *models.ProblemDetails) {
+	if loggingConfig.Level == "" && loggingConfig.ReportCaller == nil {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "level or reportCaller is required",
+		}
+	}
+	if loggingConfig.Duration < 0 {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "duration must not be negative",
+		}
+	}
+
+	if loggingConfig.Level != "" {
+		level, err := logrus.ParseLevel(loggingConfig.Level)
+		if err != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusBadRequest,
+				Cause:  "MANDATORY_IE_INCORRECT",
+				Detail: err.Error(),
+			}
+		}
+		duration := time.Duration(loggingConfig.Duration) * time.Second
+
+		if loggingConfig.Category == "" {
+			logLevelMutex.Lock()
+			for name := range logger.CategoryLogLevels() {
+				setCategoryLogLevel(name, level, duration)
+			}
+			logLevelMutex.Unlock()
+		} else {
+			name, _, err := logger.CategoryLogLevel(loggingConfig.Category)
+			if err != nil {
+				return nil, &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Cause:  "RESOURCE_NOT_FOUND",
+					Detail: err.Error(),
+				}
+			}
+			logLevelMutex.Lock()
+			setCategoryLogLevel(name, level, duration)
+			logLevelMutex.Unlock()
+		}
+	}
+
+	if loggingConfig.ReportCaller != nil {
+		logger.SetReportCaller(*loggingConfig.ReportCaller)
+	}
+
+	return OAMGetLoggingProcedure(), nil
+}
+
+// setCategoryLogLevel must be called with logLevelMutex held. A pending revert of the
+// category is replaced, but keeps the level the category had before the first change.
+func setCategoryLogLevel(name string, level logrus.Level, duration time.Duration) {
+	_, previousLevel, err := logger.CategoryLogLevel(name)
+	if err != nil {
+		logger.ProducerLog.Errorln(err)
+		return
+	}
+	if revert, ok := logLevelReverts[name]; ok {
+		revert.timer.Stop()
+		previousLevel = revert.level
+		delete(logLevelReverts, name)
+	}
+
+	if duration > 0 {
+		revert := &logLevelRevert{
+			level: previousLevel,
+			at:    time.Now().Add(duration),
+		}
+		revert.timer = time.AfterFunc(duration, func() {
+			revertCategoryLogLevel(name, revert)
+		})
+		logLevelReverts[name] = revert
+	}
+
+	if _, err := logger.SetCategoryLogLevel(name, level); err != nil {
+		logger.ProducerLog.Errorln(err)
+	}
+}
+
+func revertCategoryLogLevel(name string, revert *logLevelRevert) {
+	logLevelMutex.Lock()
+	defer logLevelMutex.Unlock()
+
+	// superseded by a later change
+	if logLevelReverts[name] != revert {
+		return
+	}
+	delete(logLevelReverts, name)
+
+	if _, err := logger.SetCategoryLogLevel(name, revert.level); err != nil {
+		logger.ProducerLog.Errorln(err)
+		return
+	}
+	logger.ProducerLog.Infof("[OAM] Log level of category[%s] reverted to [%s]", name, revert.level)
+}
@@ -3,8 +3,10 @@ package producer
 import (
 	"free5gc/lib/http_wrapper"
 	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
 	"free5gc/src/etaf/context"
 	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
 	"net/http"
 	"strconv"
 )
@@ -15,84 +17,168 @@ type PduSession struct {
 	Sst          string
 	Sd           string
 	Dnn          string
+	Emergency    bool
 }
 
 type UEContext struct {
 	AccessType models.AccessType
-	Supi       string
+	Supi       string // PEI of a UE without SUPI
+	Gpsi       string
+	Pei        string
 	Guti       string
 	/* Tai */
 	Mcc string
 	Mnc string
 	Tac string
+	/* Registration area, where the UE is paged in CM-IDLE */
+	RegistrationArea []models.Tai
 	/* PDU sessions */
 	PduSessions []PduSession
 	/*Connection state */
 	CmState models.CmState
+	/* Emergency registration or emergency PDU session */
+	Emergency bool
+
+	ueId string // Supi before pseudonymisation, for the audit trail
 }
 
 type UEContexts []UEContext
 
 func HandleOAMRegisteredUEContext(request *http_wrapper.Request) *http_wrapper.Response {
-	logger.ProducerLog.Infof("[OAM] Handle Registered UE Context")
+	caller := request.Params["caller"]
+	supi, problemDetails := resolveUeId(caller, request.Params["supi"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi)
+	log.Infof("[OAM] Handle Registered UE Context")
 
-	supi := request.Params["supi"]
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          supi,
+		Purpose:       request.Query.Get("purpose"),
+		CorrelationId: correlationID,
+	}
+
+	filter, paging, problemDetails := ParseUEContextQuery(request.Query)
+	if problemDetails != nil {
+		log.Warnf("[OAM] Registered UE Context failed: %s", problemDetails.Detail)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
 
-	ueContexts, problemDetails := OAMRegisteredUEContextProcedure(supi)
+	ueContexts, nextCursor, total, problemDetails := OAMRegisteredUEContextProcedure(caller, supi, filter, paging)
 	if problemDetails != nil {
+		log.Warnf("[OAM] Registered UE Context failed: %s", problemDetails.Cause)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else if problemDetails = auditUeDisclosures(auditEntry, ueContexts.disclosures()); problemDetails != nil {
+		log.Errorf("[OAM] Registered UE Context not disclosed: %s", problemDetails.Detail)
 		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
 	} else {
-		return http_wrapper.NewResponse(http.StatusOK, nil, ueContexts)
+		header := http.Header{}
+		header.Set(TotalCountHeader, strconv.Itoa(total))
+		if nextCursor != "" {
+			header.Set(NextCursorHeader, nextCursor)
+		}
+		return http_wrapper.NewResponse(http.StatusOK, header, ueContexts)
 	}
 }
 
-func OAMRegisteredUEContextProcedure(supi string) (UEContexts, *models.ProblemDetails) {
-	var ueContexts UEContexts
+// OAMRegisteredUEContextProcedure returns one page of the registered UE contexts
+// matching filter, the cursor of the next page and the number of matching
+// contexts. Identifiers are pseudonymised as configured for caller.
+func OAMRegisteredUEContextProcedure(caller, supi string, filter *UEContextFilter,
+	paging *UEContextPaging) (UEContexts, string, int, *models.ProblemDetails) {
+	ueContexts := UEContexts{}
 	etafSelf := context.ETAF_Self()
 
 	if supi != "" {
 		if ue, ok := etafSelf.EtafUeFindBySupi(supi); ok {
-			ueContext := buildUEContext(ue, models.AccessType__3_GPP_ACCESS)
-			if ueContext != nil {
-				ueContexts = append(ueContexts, *ueContext)
-			}
-			ueContext = buildUEContext(ue, models.AccessType_NON_3_GPP_ACCESS)
-			if ueContext != nil {
-				ueContexts = append(ueContexts, *ueContext)
-			}
+			ueContexts = appendUEContexts(ueContexts, ue, filter)
 		} else {
 			problemDetails := &models.ProblemDetails{
 				Status: http.StatusNotFound,
 				Cause:  "CONTEXT_NOT_FOUND",
 			}
-			return nil, problemDetails
+			return nil, "", 0, problemDetails
 		}
 	} else {
 		etafSelf.UePool.Range(func(key, value interface{}) bool {
 			ue := value.(*context.EtafUe)
-			ueContext := buildUEContext(ue, models.AccessType__3_GPP_ACCESS)
-			if ueContext != nil {
-				ueContexts = append(ueContexts, *ueContext)
-			}
-			ueContext = buildUEContext(ue, models.AccessType_NON_3_GPP_ACCESS)
-			if ueContext != nil {
-				ueContexts = append(ueContexts, *ueContext)
-			}
+			ueContexts = appendUEContexts(ueContexts, ue, filter)
 			return true
 		})
 	}
 
-	return ueContexts, nil
+	if privacy.Pseudonymise(caller) {
+		for i := range ueContexts {
+			ueContexts[i].Supi = privacy.Pseudonym(caller, ueContexts[i].Supi)
+			if ueContexts[i].Gpsi != "" {
+				ueContexts[i].Gpsi = privacy.Pseudonym(caller, ueContexts[i].Gpsi)
+			}
+			// the GUTI and the PEI would let the caller link the pseudonym back to the UE
+			ueContexts[i].Guti = ""
+			ueContexts[i].Pei = ""
+		}
+	}
+
+	// paged after pseudonymisation, so cursors never carry a raw SUPI
+	if paging == nil {
+		paging = &UEContextPaging{Sort: UEContextSortSupi}
+	}
+	page, nextCursor, problemDetails := paging.page(ueContexts)
+	if problemDetails != nil {
+		return nil, "", 0, problemDetails
+	}
+	return page, nextCursor, len(ueContexts), nil
+}
+
+// disclosures groups the UE contexts by UE, one entry per access type
+func (ueContexts UEContexts) disclosures() map[string]interface{} {
+	byUe := make(map[string]UEContexts)
+	for _, ueContext := range ueContexts {
+		byUe[ueContext.ueId] = append(byUe[ueContext.ueId], ueContext)
+	}
+	disclosures := make(map[string]interface{}, len(byUe))
+	for ueId, ueContexts := range byUe {
+		disclosures[ueId] = ueContexts
+	}
+	return disclosures
+}
+
+func appendUEContexts(ueContexts UEContexts, ue *context.EtafUe, filter *UEContextFilter) UEContexts {
+	for _, accessType := range []models.AccessType{models.AccessType__3_GPP_ACCESS,
+		models.AccessType_NON_3_GPP_ACCESS} {
+		if ueContext := buildUEContext(ue, accessType); ueContext != nil && filter.match(ue, ueContext) {
+			ueContexts = append(ueContexts, *ueContext)
+		}
+	}
+	return ueContexts
 }
+
 func buildUEContext(ue *context.EtafUe, accessType models.AccessType) *UEContext {
 	if ue.State[accessType].Is(context.Registered) {
 		ueContext := &UEContext{
-			AccessType: models.AccessType__3_GPP_ACCESS,
-			Supi:       ue.Supi,
+			AccessType: accessType,
+			Supi:       ue.UeId(),
+			ueId:       ue.UeId(),
+			Gpsi:       ue.Gpsi,
+			Pei:        ue.Pei,
 			Guti:       ue.Guti,
 			Mcc:        ue.Tai.PlmnId.Mcc,
 			Mnc:        ue.Tai.PlmnId.Mnc,
 			Tac:        ue.Tai.Tac,
+			Emergency:  isEmergency(ue),
+		}
+		if registrationArea := ue.RegistrationArea[accessType]; len(registrationArea) > 0 {
+			ueContext.RegistrationArea = append([]models.Tai(nil), registrationArea...)
 		}
 
 		for _, smContext := range ue.SmContextList {
@@ -105,6 +191,7 @@ func buildUEContext(ue *context.EtafUe, accessType models.AccessType) *UEContext
 						Sst:          strconv.Itoa(int(pduSessionContext.SNssai.Sst)),
 						Sd:           pduSessionContext.SNssai.Sd,
 						Dnn:          pduSessionContext.Dnn,
+						Emergency:    isEmergencyPduSession(pduSessionContext),
 					}
 					ueContext.PduSessions = append(ueContext.PduSessions, pduSession)
 				}
@@ -0,0 +1,67 @@
+package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"free5gc/src/etaf/stream"
+	"net/http"
+)
+
+type LocationDataPurge struct {
+	Supi         string `json:"supi"`
+	PurgedPoints int    `json:"purgedPoints"`
+}
+
+func HandleOAMPurgeLocationData(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	supi, problemDetails := resolveUeId(caller, request.Params["supi"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi)
+	log.Infof("[OAM] Handle Purge Location Data")
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationPurge,
+		UeId:          supi,
+		Purpose:       request.Query.Get("purpose"),
+		CorrelationId: correlationID,
+	}
+
+	locationDataPurge, problemDetails := OAMPurgeLocationDataProcedure(caller, supi)
+	if problemDetails != nil {
+		log.Warnf("[OAM] Purge Location Data failed: %s", problemDetails.Cause)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else {
+		auditEntry.Outcome = audit.OutcomeSuccess
+		audit.Record(auditEntry, locationDataPurge)
+		return http_wrapper.NewResponse(http.StatusOK, nil, locationDataPurge)
+	}
+}
+
+// OAMPurgeLocationDataProcedure drops the location history of a subscriber. The
+// current location is kept, it is still needed to serve the UE.
+func OAMPurgeLocationDataProcedure(caller, supi string) (*LocationDataPurge, *models.ProblemDetails) {
+	ue, ok := context.ETAF_Self().EtafUeFindBySupi(supi)
+	if !ok {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+	}
+
+	stream.Forget(ue.UeId())
+	return &LocationDataPurge{
+		Supi:         privacy.ProtectIdentifier(caller, ue.UeId()),
+		PurgedPoints: ue.LocationHistory.Purge(),
+	}, nil
+}
@@ -0,0 +1,131 @@
+package producer
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type RanSupportedTai struct {
+	Tai        models.Tai
+	SNssaiList []models.Snssai
+}
+
+type RanServedUe struct {
+	Supi         string
+	RanUeNgapId  int64
+	EtafUeNgapId int64
+	Tai          models.Tai
+}
+
+type RanContext struct {
+	GlobalRanNodeId string
+	RanId           *models.GlobalRanNodeId
+	Name            string
+	AnType          models.AccessType
+	Address         string
+	/* Supported TA List */
+	SupportedTAList []RanSupportedTai
+	/* UEs currently served */
+	ServedUes []RanServedUe
+}
+
+type RanContexts []RanContext
+
+func HandleOAMRanContext(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	globalRanNodeId := request.Params["globalRanNodeId"]
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithCorrelationID(logger.ProducerLog, correlationID)
+	log.Infof("[OAM] Handle RAN Context")
+
+	// the served UE lists locate each UE at its RAN node
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		Purpose:       request.Query.Get("purpose"),
+		CorrelationId: correlationID,
+	}
+
+	ranContexts, problemDetails := OAMRanContextProcedure(caller, globalRanNodeId)
+	if problemDetails != nil {
+		log.Warnf("[OAM] RAN Context failed: %s", problemDetails.Cause)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else if problemDetails = auditDisclosure(auditEntry, ranContexts); problemDetails != nil {
+		log.Errorf("[OAM] RAN Context not disclosed: %s", problemDetails.Detail)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	} else {
+		return http_wrapper.NewResponse(http.StatusOK, nil, ranContexts)
+	}
+}
+
+// OAMRanContextProcedure returns the connected RAN nodes, or only the one with the
+// given global RAN node ID, sorted by ID
+func OAMRanContextProcedure(caller, globalRanNodeId string) (RanContexts, *models.ProblemDetails) {
+	ranContexts := RanContexts{}
+
+	context.ETAF_Self().EtafRanPool.Range(func(key, value interface{}) bool {
+		ran := value.(*context.EtafRan)
+		if globalRanNodeId != "" && !strings.EqualFold(ran.RanNodeId(), globalRanNodeId) {
+			return true
+		}
+		ranContexts = append(ranContexts, buildRanContext(caller, ran))
+		return globalRanNodeId == ""
+	})
+
+	if globalRanNodeId != "" && len(ranContexts) == 0 {
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		}
+		return nil, problemDetails
+	}
+
+	sort.Slice(ranContexts, func(i, j int) bool {
+		return ranContexts[i].GlobalRanNodeId < ranContexts[j].GlobalRanNodeId
+	})
+	return ranContexts, nil
+}
+
+func buildRanContext(caller string, ran *context.EtafRan) RanContext {
+	ranContext := RanContext{
+		GlobalRanNodeId: ran.RanNodeId(),
+		RanId:           ran.RanId,
+		Name:            ran.Name,
+		AnType:          ran.AnType,
+		SupportedTAList: []RanSupportedTai{},
+		ServedUes:       []RanServedUe{},
+	}
+	if ran.Conn != nil {
+		ranContext.Address = ran.Conn.RemoteAddr().String()
+	}
+
+	for _, supportedTai := range ran.SupportedTais() {
+		ranContext.SupportedTAList = append(ranContext.SupportedTAList, RanSupportedTai{
+			Tai:        supportedTai.Tai,
+			SNssaiList: supportedTai.SNssaiList,
+		})
+	}
+
+	for _, ranUe := range ran.RanUes() {
+		servedUe := RanServedUe{
+			RanUeNgapId:  ranUe.RanUeNgapId,
+			EtafUeNgapId: ranUe.EtafUeNgapId,
+			Tai:          ranUe.Tai,
+		}
+		if ranUe.EtafUe != nil {
+			servedUe.Supi = privacy.ProtectIdentifier(caller, ranUe.EtafUe.UeId())
+		}
+		ranContext.ServedUes = append(ranContext.ServedUes, servedUe)
+	}
+	return ranContext
+}
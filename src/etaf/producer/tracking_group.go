@@ -0,0 +1,269 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/consumer"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxGroupMembers bounds the UEs a group tracking session follows
+const maxGroupMembers = 1000
+
+// TrackingSessionLocations is the latest known location of every UE followed by
+// a tracking session
+type TrackingSessionLocations struct {
+	SessionId string           `json:"sessionId"`
+	GroupId   string           `json:"groupId,omitempty"`
+	Members   []MemberLocation `json:"members"`
+}
+
+// MemberLocation carries identifiers only while the location of the UE is unknown
+type MemberLocation struct {
+	UeId     string                       `json:"ueId,omitempty"`
+	Gpsi     string                       `json:"gpsi,omitempty"`
+	Time     *time.Time                   `json:"time,omitempty"`
+	Tai      *models.Tai                  `json:"tai,omitempty"`
+	Location *models.UserLocation         `json:"location,omitempty"`
+	Estimate *etaf_context.GeographicArea `json:"estimate,omitempty"`
+	// lmf, or cellDatabase for the approximation by the serving cell
+	EstimateSource string `json:"estimateSource,omitempty"`
+}
+
+func HandleGetTrackingSessionLocations(request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	logger.WithCorrelationID(logger.ProducerLog, correlationID).Infof("Handle Get Tracking Session Locations")
+
+	session, problemDetails := findTrackingSession(caller, request.Params["sessionId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	locations := TrackingSessionLocationsProcedure(session)
+	located := 0
+	for _, member := range locations.Members {
+		if member.Location != nil {
+			located++
+		}
+	}
+	problemDetails = auditDisclosure(audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          session.Supi,
+		Purpose:       session.Purpose,
+		CorrelationId: correlationID,
+	}, map[string]interface{}{"session": "locations", "sessionId": session.Id, "groupId": session.GroupId,
+		"located": located})
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, locations)
+}
+
+// TrackingSessionLocationsProcedure aggregates the last known locations of the UEs
+// followed by a session
+func TrackingSessionLocationsProcedure(session *etaf_context.TrackingSession) *TrackingSessionLocations {
+	members := session.Members
+	if !session.IsGroup() {
+		members = []etaf_context.GroupMember{{Supi: session.Supi}}
+	}
+
+	etafSelf := etaf_context.ETAF_Self()
+	locations := &TrackingSessionLocations{
+		SessionId: session.Id,
+		GroupId:   session.GroupId,
+		Members:   make([]MemberLocation, 0, len(members)),
+	}
+	for _, member := range members {
+		if member.Supi == "" && member.Gpsi != "" {
+			if ue, ok := etafSelf.EtafUeFindByGpsi(member.Gpsi); ok {
+				member.Supi = ue.UeId()
+			}
+		}
+		memberLocation := MemberLocation{
+			UeId: privacy.ProtectIdentifier(session.Caller, member.Supi),
+			Gpsi: privacy.ProtectIdentifier(session.Caller, member.Gpsi),
+		}
+		if known, ok := lastKnownLocation(member.Supi); ok {
+			memberLocation.Time = known.Time
+			memberLocation.Tai = &known.Tai
+			memberLocation.Location = known.Location
+			memberLocation.Estimate = known.Estimate
+			memberLocation.EstimateSource = known.EstimateSource
+		}
+		locations.Members = append(locations.Members, memberLocation)
+	}
+	return locations
+}
+
+// resolveGroupMembers lists the UEs of the group selected by createData: the
+// members of an internal group are the registered UEs whose subscription data in
+// the UDM lists it, and the members of an ad hoc group given by GPSI are matched
+// against the registered UEs
+func resolveGroupMembers(ctx context.Context, caller string, createData TrackingSessionCreateData) (
+	[]etaf_context.GroupMember, *models.ProblemDetails) {
+	etafSelf := etaf_context.ETAF_Self()
+	var members []etaf_context.GroupMember
+	seen := make(map[string]bool)
+	add := func(member etaf_context.GroupMember) {
+		key := member.Supi
+		if key == "" {
+			key = member.Gpsi
+		}
+		if !seen[key] {
+			seen[key] = true
+			members = append(members, member)
+		}
+	}
+
+	if createData.GroupId != "" {
+		sdmUri, err := consumer.SearchUdmSdmUri(ctx, etafSelf.NrfUri)
+		if err != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Cause:  "SYSTEM_FAILURE",
+				Detail: err.Error(),
+			}
+		}
+		var ues []*etaf_context.EtafUe
+		etafSelf.UePool.Range(func(key, value interface{}) bool {
+			if ue := value.(*etaf_context.EtafUe); ue.Supi != "" {
+				ues = append(ues, ue)
+			}
+			return true
+		})
+		groupUes, problemDetails, err := consumer.SDMGetGroupMembers(ctx, sdmUri, createData.GroupId, ues)
+		if problemDetails != nil {
+			return nil, problemDetails
+		} else if err != nil {
+			return nil, &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Cause:  "SYSTEM_FAILURE",
+				Detail: fmt.Sprintf("resolve group[%s] error: %+v", createData.GroupId, err),
+			}
+		}
+		for _, ue := range groupUes {
+			ue.GroupID = createData.GroupId
+			add(etaf_context.GroupMember{Supi: ue.Supi, Gpsi: ue.Gpsi})
+		}
+	} else {
+		for _, id := range createData.UeIds {
+			id, problemDetails := resolveUeId(caller, id)
+			if problemDetails != nil {
+				return nil, problemDetails
+			}
+			if strings.HasPrefix(id, "msisdn-") || strings.HasPrefix(id, "extid-") {
+				member := etaf_context.GroupMember{Gpsi: id}
+				if ue, ok := etafSelf.EtafUeFindByGpsi(id); ok {
+					member.Supi = ue.UeId()
+				}
+				add(member)
+			} else if id != "" {
+				add(etaf_context.GroupMember{Supi: id})
+			}
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: "the group has no members",
+		}
+	}
+	if len(members) > maxGroupMembers {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: fmt.Sprintf("a group may not have more than %d members", maxGroupMembers),
+		}
+	}
+	return members, nil
+}
+
+// subscribeLocationReports subscribes to the location reports of the UE of a
+// session, or of the members of a group session, at every AMF: once per AMF for
+// a single UE or an internal group, else once per member. The session goes on
+// with the AMFs that accepted the subscription.
+func subscribeLocationReports(ctx context.Context, session *etaf_context.TrackingSession) {
+	log := logger.WithCorrelationID(logger.ProducerLog, logger.CorrelationIDFromContext(ctx)).
+		WithField("sessionId", session.Id)
+
+	var targets []models.AmfEventSubscription
+	switch {
+	case !session.IsGroup():
+		targets = append(targets, ueEventSubscription(etaf_context.GroupMember{Supi: session.Supi}))
+	case session.GroupId != "":
+		targets = append(targets, models.AmfEventSubscription{GroupId: session.GroupId})
+	default:
+		for _, member := range session.Members {
+			targets = append(targets, ueEventSubscription(member))
+		}
+	}
+
+	var subscriptions []etaf_context.AmfSubscription
+	amfInfos := consumer.SearchAvailableAMFs(ctx, etaf_context.ETAF_Self().NrfUri, models.ServiceName_NAMF_EVTS)
+	for _, amfInfo := range amfInfos {
+		for _, target := range targets {
+			subscriptionId, reports, problemDetails, err :=
+				consumer.AmfLocationReportSubscribe(ctx, amfInfo.AmfUri, session.Id, target)
+			if problemDetails != nil {
+				log.Warnf("AMF[%s] location report subscription failed: %s", amfInfo.AmfUri, problemDetails.Cause)
+				continue
+			} else if err != nil {
+				log.Warnf("AMF[%s] location report subscription error: %+v", amfInfo.AmfUri, err)
+				continue
+			}
+			subscriptions = append(subscriptions, etaf_context.AmfSubscription{
+				AmfUri:         amfInfo.AmfUri,
+				SubscriptionId: subscriptionId,
+			})
+			if len(reports) > 0 {
+				LocationInfoNotifyProcedure(models.AmfEventNotification{
+					NotifyCorrelationId: session.Id,
+					ReportList:          reports,
+				}, logger.CorrelationIDFromContext(ctx))
+			}
+		}
+	}
+	if len(subscriptions) == 0 {
+		log.Warnf("No AMF accepted the location report subscriptions of the session")
+	}
+
+	if !session.SetAmfSubscriptions(subscriptions) {
+		unsubscribeAmfEvents(ctx, subscriptions)
+	}
+}
+
+// ueEventSubscription targets the AMF event subscription at a UE by its SUPI, PEI
+// or GPSI
+func ueEventSubscription(member etaf_context.GroupMember) models.AmfEventSubscription {
+	if etaf_context.IsImeiPei(member.Supi) {
+		return models.AmfEventSubscription{Pei: member.Supi}
+	} else if member.Supi != "" {
+		return models.AmfEventSubscription{Supi: member.Supi}
+	}
+	return models.AmfEventSubscription{Gpsi: member.Gpsi}
+}
+
+func unsubscribeAmfEvents(ctx context.Context, subscriptions []etaf_context.AmfSubscription) {
+	for _, subscription := range subscriptions {
+		problemDetails, err := consumer.AmfEventUnsubscribe(ctx, subscription.AmfUri, subscription.SubscriptionId)
+		if problemDetails != nil {
+			logger.ProducerLog.Warnf("Remove AMF[%s] subscription[%s] failed: %s", subscription.AmfUri,
+				subscription.SubscriptionId, problemDetails.Cause)
+		} else if err != nil {
+			logger.ProducerLog.Warnf("Remove AMF[%s] subscription[%s] error: %+v", subscription.AmfUri,
+				subscription.SubscriptionId, err)
+		}
+	}
+}
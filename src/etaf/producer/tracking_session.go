@@ -0,0 +1,328 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/notifier"
+	"free5gc/src/etaf/privacy"
+	"free5gc/src/etaf/stream"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrackingSessionCreateData selects the UEs to follow with exactly one of UeId,
+// GroupId and UeIds
+type TrackingSessionCreateData struct {
+	UeId string `json:"ueId,omitempty"`
+	// Internal group identifier, whose members are resolved through the UDM
+	GroupId string `json:"groupId,omitempty"`
+	// SUPIs or GPSIs of an ad hoc group
+	UeIds           []string `json:"ueIds,omitempty"`
+	NotificationUri string   `json:"notificationUri"`
+	// all (default), tai, cell or registrationArea
+	Filter string `json:"filter,omitempty"`
+	// Seconds after which the session ends; 0 keeps it until deleted
+	Duration int    `json:"duration,omitempty"`
+	Purpose  string `json:"purpose,omitempty"`
+}
+
+type TrackingSessionView struct {
+	SessionId        string                      `json:"sessionId"`
+	UeId             string                      `json:"ueId,omitempty"`
+	GroupId          string                      `json:"groupId,omitempty"`
+	Members          []GroupMemberView           `json:"members,omitempty"`
+	NotificationUri  string                      `json:"notificationUri"`
+	Filter           string                      `json:"filter"`
+	Purpose          string                      `json:"purpose,omitempty"`
+	CreatedAt        time.Time                   `json:"createdAt"`
+	Expiry           *time.Time                  `json:"expiry,omitempty"`
+	AmfSubscriptions int                         `json:"amfSubscriptions,omitempty"`
+	Delivery         etaf_context.DeliveryStatus `json:"delivery"`
+	Priority         bool                        `json:"priority,omitempty"`
+	ReportInterval   int                         `json:"reportInterval,omitempty"` // seconds between periodic reports
+	// The UE followed is in emergency services
+	Emergency bool `json:"emergency"`
+}
+
+type GroupMemberView struct {
+	UeId      string `json:"ueId,omitempty"`
+	Gpsi      string `json:"gpsi,omitempty"`
+	Emergency bool   `json:"emergency"`
+}
+
+func HandleCreateTrackingSession(ctx context.Context, request *http_wrapper.Request) *http_wrapper.Response {
+	caller := request.Params["caller"]
+	createData := request.Body.(TrackingSessionCreateData)
+	supi, problemDetails := resolveUeId(caller, createData.UeId)
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	correlationID := request.Header.Get(logger.CorrelationIDHeader)
+	log := logger.WithSupi(logger.WithCorrelationID(logger.ProducerLog, correlationID), supi)
+	log.Infof("Handle Create Tracking Session")
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          supi,
+		Purpose:       createData.Purpose,
+		CorrelationId: correlationID,
+	}
+
+	view, problemDetails := CreateTrackingSessionProcedure(ctx, caller, supi, createData)
+	if problemDetails != nil {
+		log.Warnf("Create Tracking Session failed: %s", problemDetails.Detail)
+		auditEntry.Outcome = audit.OutcomeFailure
+		auditEntry.Cause = problemDetails.Cause
+		audit.Record(auditEntry, nil)
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	problemDetails = auditDisclosure(auditEntry, map[string]interface{}{"session": "created",
+		"sessionId": view.SessionId, "notificationUri": view.NotificationUri, "groupId": view.GroupId,
+		"members": len(view.Members)})
+	if problemDetails != nil {
+		log.Errorf("Tracking Session[%s] ended: %s", view.SessionId, problemDetails.Detail)
+		if session, ok := etaf_context.ETAF_Self().TrackingSessionFindById(view.SessionId); ok {
+			endTrackingSession(session, correlationID)
+		}
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	header := http.Header{
+		"Location": {etaf_context.ETAF_Self().GetIPv4Uri() + "/netaf-track/v1/sessions/" + view.SessionId},
+	}
+	return http_wrapper.NewResponse(http.StatusCreated, header, view)
+}
+
+func HandleGetTrackingSession(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("Handle Get Tracking Session")
+
+	session, problemDetails := findTrackingSession(request.Params["caller"], request.Params["sessionId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, buildTrackingSessionView(session))
+}
+
+func HandleDeleteTrackingSession(request *http_wrapper.Request) *http_wrapper.Response {
+	logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader)).
+		Infof("Handle Delete Tracking Session")
+
+	session, problemDetails := findTrackingSession(request.Params["caller"], request.Params["sessionId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+	endTrackingSession(session, request.Header.Get(logger.CorrelationIDHeader))
+	return http_wrapper.NewResponse(http.StatusNoContent, nil, nil)
+}
+
+func HandleGetTrackingSessionDeadLetters(request *http_wrapper.Request) *http_wrapper.Response {
+	log := logger.WithCorrelationID(logger.ProducerLog, request.Header.Get(logger.CorrelationIDHeader))
+	log.Infof("Handle Get Tracking Session Dead Letters")
+
+	session, problemDetails := findTrackingSession(request.Params["caller"], request.Params["sessionId"])
+	if problemDetails != nil {
+		return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+	}
+
+	var limit int64
+	if value := request.Query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.ParseInt(value, 10, 64); err != nil || limit <= 0 {
+			problemDetails := invalidQueryParameter("limit must be a positive integer")
+			return http_wrapper.NewResponse(int(problemDetails.Status), nil, problemDetails)
+		}
+	}
+	deadLetters, err := notifier.DeadLetters(session.Id, limit)
+	if err != nil {
+		log.Errorln(err)
+		problemDetails := &models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		return http_wrapper.NewResponse(http.StatusInternalServerError, nil, problemDetails)
+	}
+	return http_wrapper.NewResponse(http.StatusOK, nil, deadLetters)
+}
+
+// CreateTrackingSessionProcedure creates a session following the UE supi, or the
+// group selected by createData if supi is empty
+func CreateTrackingSessionProcedure(ctx context.Context, caller, supi string,
+	createData TrackingSessionCreateData) (*TrackingSessionView, *models.ProblemDetails) {
+	selectors := 0
+	for _, selected := range []bool{supi != "", createData.GroupId != "", len(createData.UeIds) > 0} {
+		if selected {
+			selectors++
+		}
+	}
+	if selectors == 0 || createData.NotificationUri == "" {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_MISSING",
+			Detail: "one of ueId, groupId and ueIds, and notificationUri are required",
+		}
+	}
+	if selectors > 1 {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "only one of ueId, groupId and ueIds may be given",
+		}
+	}
+	if notificationUri, err := url.Parse(createData.NotificationUri); err != nil ||
+		(notificationUri.Scheme != "http" && notificationUri.Scheme != "https") || notificationUri.Host == "" {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "notificationUri must be an absolute http or https URI",
+		}
+	} else if !allowedNotificationHost(notificationUri) {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusForbidden,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: fmt.Sprintf("notificationUri host[%s] is not allowed", notificationUri.Host),
+		}
+	}
+	filter := stream.Filter(createData.Filter)
+	switch filter {
+	case "":
+		filter = stream.FilterAll
+	case stream.FilterAll, stream.FilterTaiChange, stream.FilterCellChange, stream.FilterRegistrationAreaChange:
+	default:
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "filter must be all, tai, cell or registrationArea",
+		}
+	}
+	if createData.Duration < 0 {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "duration must not be negative",
+		}
+	}
+
+	var members []etaf_context.GroupMember
+	if supi == "" {
+		var problemDetails *models.ProblemDetails
+		if members, problemDetails = resolveGroupMembers(ctx, caller, createData); problemDetails != nil {
+			return nil, problemDetails
+		}
+	}
+
+	etafSelf := etaf_context.ETAF_Self()
+	session := etafSelf.NewTrackingSession(supi)
+	session.GroupId = createData.GroupId
+	session.Members = members
+	session.Caller = caller
+	session.Purpose = createData.Purpose
+	session.NotificationUri = createData.NotificationUri
+	session.Filter = string(filter)
+	// the expiry is set before the session is published, which makes it read only
+	if createData.Duration > 0 {
+		duration := time.Duration(createData.Duration) * time.Second
+		expiry := session.CreatedAt.Add(duration)
+		session.Expiry = &expiry
+		session.ExpiryTimer = time.AfterFunc(duration, func() {
+			logger.ProducerLog.Infof("Tracking session[%s] expired", session.Id)
+			endTrackingSession(session, "")
+		})
+	}
+	etafSelf.AddTrackingSession(session)
+	subscribeLocationReports(ctx, session)
+
+	return buildTrackingSessionView(session), nil
+}
+
+// allowedNotificationHost reports whether the host of a notification URI is one of
+// the configured notification hosts, so the sessions cannot make the ETAF send
+// requests to arbitrary hosts of the core network
+func allowedNotificationHost(notificationUri *url.URL) bool {
+	host := strings.ToLower(notificationUri.Hostname())
+	hostPort := strings.ToLower(notificationUri.Host)
+	for _, allowed := range factory.EtafConfig.Configuration.NotificationHosts {
+		allowed = strings.ToLower(allowed)
+		switch {
+		case strings.HasPrefix(allowed, "*."):
+			if strings.HasSuffix(host, allowed[1:]) {
+				return true
+			}
+		case allowed == host || allowed == hostPort:
+			return true
+		}
+	}
+	return false
+}
+
+// findTrackingSession only finds the sessions of caller; the sessions of other
+// clients are reported as missing rather than forbidden
+func findTrackingSession(caller, sessionId string) (*etaf_context.TrackingSession, *models.ProblemDetails) {
+	session, ok := etaf_context.ETAF_Self().TrackingSessionFindById(sessionId)
+	if !ok || session.Caller != caller {
+		return nil, &models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+			Detail: fmt.Sprintf("tracking session[%s] not found", sessionId),
+		}
+	}
+	return session, nil
+}
+
+// endTrackingSession deletes a session, removes its AMF event subscriptions and
+// closes its audit record with a summary of the notifications sent
+func endTrackingSession(session *etaf_context.TrackingSession, correlationID string) {
+	if !etaf_context.ETAF_Self().DeleteTrackingSession(session) {
+		return
+	}
+	unsubscribeAmfEvents(logger.ContextWithCorrelationID(context.Background(), correlationID),
+		session.AmfSubscriptions())
+	etaf_context.ETAF_Self().ForgetNotifyCorrelation(session.Id)
+	delivery := session.Delivery()
+	audit.Record(audit.Entry{
+		Caller:        session.Caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          session.Supi,
+		Purpose:       session.Purpose,
+		Outcome:       audit.OutcomeSuccess,
+		CorrelationId: correlationID,
+	}, map[string]interface{}{"session": "ended", "sessionId": session.Id, "groupId": session.GroupId,
+		"delivered": delivery.Delivered, "deadLettered": delivery.DeadLettered})
+}
+
+func buildTrackingSessionView(session *etaf_context.TrackingSession) *TrackingSessionView {
+	view := &TrackingSessionView{
+		SessionId:        session.Id,
+		UeId:             privacy.ProtectIdentifier(session.Caller, session.Supi),
+		GroupId:          session.GroupId,
+		NotificationUri:  session.NotificationUri,
+		Filter:           session.Filter,
+		Purpose:          session.Purpose,
+		CreatedAt:        session.CreatedAt,
+		Expiry:           session.Expiry,
+		AmfSubscriptions: len(session.AmfSubscriptions()),
+		Delivery:         session.Delivery(),
+		Priority:         session.Priority,
+		ReportInterval:   int(session.ReportInterval / time.Second),
+		Emergency:        !session.IsGroup() && isEmergencySupi(session.Supi),
+	}
+	for _, member := range session.Members {
+		view.Members = append(view.Members, GroupMemberView{
+			UeId:      privacy.ProtectIdentifier(session.Caller, member.Supi),
+			Gpsi:      privacy.ProtectIdentifier(session.Caller, member.Gpsi),
+			Emergency: member.Supi != "" && isEmergencySupi(member.Supi),
+		})
+	}
+	return view
+}
@@ -0,0 +1,280 @@
+package producer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/context"
+	"free5gc/src/etaf/privacy"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Headers of a paged registered UE context listing
+const (
+	NextCursorHeader = "X-Next-Cursor"
+	TotalCountHeader = "X-Total-Count"
+)
+
+const (
+	UEContextSortSupi       = "supi"
+	UEContextSortTac        = "tac"
+	UEContextSortAccessType = "accessType"
+	UEContextSortCmState    = "cmState"
+)
+
+// UEContextFilter selects registered UE contexts; empty fields match anything
+type UEContextFilter struct {
+	PlmnId     *models.PlmnId
+	Tac        string
+	AccessType models.AccessType
+	CmState    models.CmState
+	Snssai     *models.Snssai
+	Dnn        string
+	Emergency  *bool // UEs in emergency services, or only the others
+}
+
+// UEContextPaging orders the listing and selects one page of it. Limit 0 returns
+// every entry after Cursor.
+type UEContextPaging struct {
+	Sort       string
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// ueContextCursor is the position after the last entry of a page
+type ueContextCursor struct {
+	Sort       string            `json:"s"`
+	Key        string            `json:"k"`
+	Supi       string            `json:"i"`
+	AccessType models.AccessType `json:"a"`
+}
+
+// ParseUEContextQuery reads the filter and paging parameters of a listing
+func ParseUEContextQuery(query url.Values) (*UEContextFilter, *UEContextPaging, *models.ProblemDetails) {
+	filter := &UEContextFilter{
+		Tac:        query.Get("tac"),
+		AccessType: models.AccessType(query.Get("accessType")),
+		CmState:    models.CmState(query.Get("cmState")),
+		Dnn:        query.Get("dnn"),
+	}
+
+	if plmnId := query.Get("plmnId"); plmnId != "" {
+		if len(plmnId) != 5 && len(plmnId) != 6 {
+			return nil, nil, invalidQueryParameter("plmnId must be MCC followed by MNC")
+		}
+		filter.PlmnId = &models.PlmnId{Mcc: plmnId[:3], Mnc: plmnId[3:]}
+	}
+	switch filter.AccessType {
+	case "", models.AccessType__3_GPP_ACCESS, models.AccessType_NON_3_GPP_ACCESS:
+	default:
+		return nil, nil, invalidQueryParameter("accessType must be 3GPP_ACCESS or NON_3GPP_ACCESS")
+	}
+	switch filter.CmState {
+	case "", models.CmState_CONNECTED, models.CmState_IDLE:
+	default:
+		return nil, nil, invalidQueryParameter("cmState must be CONNECTED or IDLE")
+	}
+	if emergency := query.Get("emergency"); emergency != "" {
+		value, err := strconv.ParseBool(emergency)
+		if err != nil {
+			return nil, nil, invalidQueryParameter("emergency must be true or false")
+		}
+		filter.Emergency = &value
+	}
+	if snssai := query.Get("snssai"); snssai != "" {
+		// sst, optionally followed by "-" and sd
+		parts := strings.SplitN(snssai, "-", 2)
+		sst, err := strconv.Atoi(parts[0])
+		if err != nil || sst < 0 || sst > 255 {
+			return nil, nil, invalidQueryParameter("snssai must be <sst> or <sst>-<sd>")
+		}
+		filter.Snssai = &models.Snssai{Sst: int32(sst)}
+		if len(parts) == 2 {
+			filter.Snssai.Sd = strings.ToLower(parts[1])
+		}
+	}
+
+	paging := &UEContextPaging{
+		Sort:   UEContextSortSupi,
+		Cursor: query.Get("cursor"),
+	}
+	if sortBy := query.Get("sort"); sortBy != "" {
+		paging.Descending = strings.HasPrefix(sortBy, "-")
+		paging.Sort = strings.TrimPrefix(sortBy, "-")
+		switch paging.Sort {
+		case UEContextSortSupi, UEContextSortTac, UEContextSortAccessType, UEContextSortCmState:
+		default:
+			return nil, nil, invalidQueryParameter("sort must be one of supi, tac, accessType, cmState, optionally prefixed by -")
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return nil, nil, invalidQueryParameter("limit must be a positive integer")
+		}
+		paging.Limit = value
+	}
+
+	return filter, paging, nil
+}
+
+// resolveUeId maps a UE identifier given by caller to the SUPI of the UE, or to
+// the canonical PEI of a UE without SUPI
+func resolveUeId(caller, id string) (string, *models.ProblemDetails) {
+	ueId, err := privacy.ResolveUeId(caller, id)
+	if err != nil {
+		return "", &models.ProblemDetails{
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: err.Error(),
+		}
+	}
+	return ueId, nil
+}
+
+func invalidQueryParameter(detail string) *models.ProblemDetails {
+	return &models.ProblemDetails{
+		Status: http.StatusBadRequest,
+		Cause:  "MANDATORY_IE_INCORRECT",
+		Detail: detail,
+	}
+}
+
+func (filter *UEContextFilter) match(ue *context.EtafUe, ueContext *UEContext) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.PlmnId != nil && (ueContext.Mcc != filter.PlmnId.Mcc || ueContext.Mnc != filter.PlmnId.Mnc) {
+		return false
+	}
+	if filter.Tac != "" && !strings.EqualFold(ueContext.Tac, filter.Tac) {
+		return false
+	}
+	if filter.AccessType != "" && ueContext.AccessType != filter.AccessType {
+		return false
+	}
+	if filter.CmState != "" && ueContext.CmState != filter.CmState {
+		return false
+	}
+	if filter.Emergency != nil && ueContext.Emergency != *filter.Emergency {
+		return false
+	}
+	if filter.Snssai != nil && !filter.matchSnssai(ue, ueContext) {
+		return false
+	}
+	if filter.Dnn != "" {
+		found := false
+		for _, pduSession := range ueContext.PduSessions {
+			if pduSession.Dnn == filter.Dnn {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSnssai matches the allowed NSSAI of the access or the slice of a PDU session
+func (filter *UEContextFilter) matchSnssai(ue *context.EtafUe, ueContext *UEContext) bool {
+	sst := strconv.Itoa(int(filter.Snssai.Sst))
+	for _, allowedSnssai := range ue.AllowedNssai[ueContext.AccessType] {
+		if snssai := allowedSnssai.AllowedSnssai; snssai != nil && snssai.Sst == filter.Snssai.Sst &&
+			(filter.Snssai.Sd == "" || strings.EqualFold(snssai.Sd, filter.Snssai.Sd)) {
+			return true
+		}
+	}
+	for _, pduSession := range ueContext.PduSessions {
+		if pduSession.Sst == sst && (filter.Snssai.Sd == "" || strings.EqualFold(pduSession.Sd, filter.Snssai.Sd)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (paging *UEContextPaging) key(ueContext *UEContext) string {
+	switch paging.Sort {
+	case UEContextSortTac:
+		return ueContext.Mcc + ueContext.Mnc + strings.ToLower(ueContext.Tac)
+	case UEContextSortAccessType:
+		return string(ueContext.AccessType)
+	case UEContextSortCmState:
+		return string(ueContext.CmState)
+	default:
+		return ueContext.Supi
+	}
+}
+
+// less orders entries by the sort key, then by SUPI and access type so the order
+// is total and a cursor identifies a single position
+func (paging *UEContextPaging) less(a, b ueContextCursor) bool {
+	if paging.Descending {
+		a, b = b, a
+	}
+	if a.Key != b.Key {
+		return a.Key < b.Key
+	}
+	if a.Supi != b.Supi {
+		return a.Supi < b.Supi
+	}
+	return a.AccessType < b.AccessType
+}
+
+func (paging *UEContextPaging) position(ueContext *UEContext) ueContextCursor {
+	return ueContextCursor{
+		Sort:       paging.Sort,
+		Key:        paging.key(ueContext),
+		Supi:       ueContext.Supi,
+		AccessType: ueContext.AccessType,
+	}
+}
+
+// page sorts the entries and returns the page after the cursor with the cursor of
+// the next page, empty on the last page
+func (paging *UEContextPaging) page(ueContexts UEContexts) (UEContexts, string, *models.ProblemDetails) {
+	sort.Slice(ueContexts, func(i, j int) bool {
+		return paging.less(paging.position(&ueContexts[i]), paging.position(&ueContexts[j]))
+	})
+
+	start := 0
+	if paging.Cursor != "" {
+		var cursor ueContextCursor
+		raw, err := base64.RawURLEncoding.DecodeString(paging.Cursor)
+		if err == nil {
+			err = json.Unmarshal(raw, &cursor)
+		}
+		if err != nil || cursor.Sort != paging.Sort {
+			return nil, "", invalidQueryParameter("cursor is invalid or was issued for another sort order")
+		}
+		start = sort.Search(len(ueContexts), func(i int) bool {
+			return paging.less(cursor, paging.position(&ueContexts[i]))
+		})
+	}
+
+	end := len(ueContexts)
+	if paging.Limit > 0 && start+paging.Limit < end {
+		end = start + paging.Limit
+	}
+	page := ueContexts[start:end]
+
+	nextCursor := ""
+	if end < len(ueContexts) {
+		raw, err := json.Marshal(paging.position(&ueContexts[end-1]))
+		if err != nil {
+			return nil, "", &models.ProblemDetails{
+				Status: http.StatusInternalServerError,
+				Cause:  "SYSTEM_FAILURE",
+				Detail: err.Error(),
+			}
+		}
+		nextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nextCursor, nil
+}
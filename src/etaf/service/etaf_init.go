@@ -2,6 +2,7 @@ package service
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,15 +20,19 @@ import (
 	"free5gc/lib/openapi/models"
 	"free5gc/lib/path_util"
 	"free5gc/src/app"
+	"free5gc/src/etaf/audit"
+	"free5gc/src/etaf/celldb"
 	"free5gc/src/etaf/consumer"
-	"free5gc/src/etaf/context"
+	etaf_context "free5gc/src/etaf/context"
 	"free5gc/src/etaf/factory"
 	"free5gc/src/etaf/httpcallback"
+	"free5gc/src/etaf/lmf"
 	"free5gc/src/etaf/logger"
-
-	// ngap_message "free5gc/src/etaf/ngap/message"
-	// ngap_service "free5gc/src/etaf/ngap/service"
+	"free5gc/src/etaf/ngap"
+	ngap_service "free5gc/src/etaf/ngap/service"
 	"free5gc/src/etaf/oam"
+	"free5gc/src/etaf/privacy"
+	"free5gc/src/etaf/producer"
 	"free5gc/src/etaf/tracking"
 	"free5gc/src/etaf/util"
 )
@@ -93,6 +98,10 @@ func (*ETAF) Initialize(c *cli.Context) {
 
 	logger.SetReportCaller(app.ContextSelf().Logger.ETAF.ReportCaller)
 
+	if factory.EtafConfig.Configuration.LogFormat == "json" {
+		logger.SetJSONFormatter()
+	}
+
 }
 
 func (etaf *ETAF) FilterCli(c *cli.Context) (args []string) {
@@ -114,19 +123,46 @@ func (etaf *ETAF) Start() {
 
 	initLog.Infoln("Server started")
 
+	if err := util.InitTracing(); err != nil {
+		initLog.Warnf("Initialize tracing failed: %+v", err)
+	}
+
+	if err := util.InitCallerAuthentication(); err != nil {
+		initLog.Errorf("Initialize caller authentication failed: %+v", err)
+		return
+	}
+	// location data must not be disclosed without an audit trail
+	if err := audit.Init(); err != nil {
+		initLog.Errorf("Initialize audit log failed: %+v", err)
+		return
+	}
+	if err := privacy.Init(); err != nil {
+		initLog.Errorf("Initialize privacy controls failed: %+v", err)
+		return
+	}
+	// locations are still reported by cell without coordinates
+	if err := celldb.Init(); err != nil {
+		initLog.Warnf("Load cell database failed: %+v", err)
+	}
+
 	router := logger_util.NewGinWithLogrus(logger.GinLog)
 	router.Use(cors.New(cors.Config{
 		AllowMethods: []string{"GET", "POST", "OPTIONS", "PUT", "PATCH", "DELETE"},
 		AllowHeaders: []string{"Origin", "Content-Length", "Content-Type", "User-Agent", "Referrer", "Host",
-			"Token", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		AllowAllOrigins:  true,
-		MaxAge:           86400,
+			"Token", "X-Requested-With", logger.CorrelationIDHeader},
+		ExposeHeaders:   []string{"Content-Length", logger.CorrelationIDHeader},
+		AllowAllOrigins: true,
+		MaxAge:          86400,
 	}))
+	router.Use(util.CorrelationID())
 
 	httpcallback.AddService(router)
 	oam.AddService(router)
+	if lmfConfig := factory.EtafConfig.Configuration.Lmf; lmfConfig != nil && lmfConfig.StandIn != nil &&
+		lmfConfig.StandIn.Enable {
+		initLog.Warnln("Stand-in LMF enabled: UEs are positioned at their cell sites")
+		lmf.AddService(router)
+	}
 	for _, serviceName := range factory.EtafConfig.Configuration.ServiceNameList {
 		switch models.ServiceName(serviceName) {
 		case models.ServiceName_NETAF_TRACK:
@@ -134,12 +170,14 @@ func (etaf *ETAF) Start() {
 		}
 	}
 
-	self := context.ETAF_Self()
+	self := etaf_context.ETAF_Self()
 	util.InitEtafContext(self)
 
 	addr := fmt.Sprintf("%s:%d", self.BindingIPv4, self.SBIPort)
 
-	// ngap_service.Run(self.NgapIpList, 38412, ngap.Dispatch)
+	ngap_service.Run(self.NgapIpList, self.NgapPort, ngap.Dispatch)
+
+	ctx := logger.ContextWithCorrelationID(context.Background(), logger.NewCorrelationID())
 
 	// Register to NRF
 	var profile models.NfProfile
@@ -151,7 +189,7 @@ func (etaf *ETAF) Start() {
 
 	logger.CommLog.Info("Register ETAF to NRF start")
 
-	if _, nfId, err := consumer.SendRegisterNFInstance(self.NrfUri, self.NfId, profile); err != nil {
+	if _, nfId, err := consumer.SendRegisterNFInstance(ctx, self.NrfUri, self.NfId, profile); err != nil {
 		initLog.Warnf("Send Register NF Instance failed: %+v", err)
 
 	} else {
@@ -162,7 +200,7 @@ func (etaf *ETAF) Start() {
 	server, err := http2_util.NewServer(addr, util.EtafLogPath, router)
 
 	logger.CommLog.Info("Send ETAF Location Info Subscribe towards AMF start")
-	amfInfos := consumer.SearchAvailableAMFs(self.NrfUri, models.ServiceName_NAMF_COMM)
+	amfInfos := consumer.SearchAvailableAMFs(ctx, self.NrfUri, models.ServiceName_NAMF_COMM)
 	for _, amfInfo := range amfInfos {
 		guamiList := util.GetNotSubscribedGuamis(amfInfo.GuamiList)
 		if len(guamiList) == 0 {
@@ -170,7 +208,7 @@ func (etaf *ETAF) Start() {
 		}
 
 		var problemDetails *models.ProblemDetails
-		problemDetails, err = consumer.AmfStatusChangeSubscribe(amfInfo)
+		problemDetails, err = consumer.AmfStatusChangeSubscribe(ctx, amfInfo)
 		if problemDetails != nil {
 			logger.InitLog.Warnf("AMF status subscribe Failed[%+v]", problemDetails)
 		} else if err != nil {
@@ -179,6 +217,8 @@ func (etaf *ETAF) Start() {
 	}
 	logger.CommLog.Info("ETAF Location Info Subscribe success")
 
+	producer.SubscribeEmergencyRegistrations(ctx)
+
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 	go func() {
@@ -196,6 +236,11 @@ func (etaf *ETAF) Start() {
 		initLog.Warnf("Initialize HTTP server: %+v", err)
 	}
 
+	if err := util.ConfigureClientAuthentication(server); err != nil {
+		initLog.Errorf("Initialize client authentication failed: %+v", err)
+		return
+	}
+
 	serverScheme := factory.EtafConfig.Configuration.Sbi.Scheme
 	if serverScheme == "http" {
 		err = server.ListenAndServe()
@@ -259,12 +304,11 @@ func (etaf *ETAF) Exec(c *cli.Context) error {
 // Used in ETAF planned removal procedure
 func (etaf *ETAF) Terminate() {
 	logger.InitLog.Infof("Terminating ETAF...")
-	// etafSelf := context.ETAF_Self()
 
 	// TODO: forward registered UE contexts to target ETAF in the same ETAF set if there is one
 
 	// deregister with NRF
-	problemDetails, err := consumer.SendDeregisterNFInstance()
+	problemDetails, err := consumer.SendDeregisterNFInstance(context.Background())
 	if problemDetails != nil {
 		logger.InitLog.Errorf("Deregister NF instance Failed Problem[%+v]", problemDetails)
 	} else if err != nil {
@@ -273,16 +317,15 @@ func (etaf *ETAF) Terminate() {
 		logger.InitLog.Infof("[ETAF] Deregister from NRF successfully")
 	}
 
-	// send ETAF status indication to ran to notify ran that this ETAF will be unavailable
-	logger.InitLog.Infof("Send ETAF Status Indication to Notify RANs due to ETAF terminating")
-	// unavailableGuamiList := ngap_message.BuildUnavailableGUAMIList(etafSelf.ServedGuamiList)
-	// etafSelf.EtafRanPool.Range(func(key, value interface{}) bool {
-	// 	ran := value.(*context.EtafRan)
-	// 	ngap_message.SendETAFStatusIndication(ran, unavailableGuamiList)
-	// 	return true
-	// })
+	producer.UnsubscribeEmergencyRegistrations(context.Background())
+
+	// no AMF Status Indication is sent: the ETAF serves no UE, so the RANs have
+	// nothing to move off it
+	ngap_service.Stop()
 
-	// ngap_service.Stop()
+	util.ShutdownTracing()
+	privacy.Stop()
+	audit.Close()
 
 	logger.InitLog.Infof("ETAF terminated")
 }
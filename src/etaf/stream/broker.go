@@ -0,0 +1,249 @@
+package stream
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"time"
+
+	"free5gc/lib/openapi/models"
+)
+
+// Filter selects which location updates a subscriber receives
+type Filter string
+
+const (
+	FilterAll        Filter = "all"
+	FilterTaiChange  Filter = "tai"  // only updates moving the UE to another TAI
+	FilterCellChange Filter = "cell" // only updates moving the UE to another cell
+	// only updates moving the UE out of its registration area
+	FilterRegistrationAreaChange Filter = "registrationArea"
+)
+
+const DefaultBufferSize = 64
+
+// MaxLastLocations bounds the number of UEs whose last location is kept; the
+// least recently updated ones are forgotten first
+const MaxLastLocations = 100000
+
+type LocationUpdate struct {
+	Supi        string              `json:"supi"`
+	Time        time.Time           `json:"time"`
+	Tai         models.Tai          `json:"tai"`
+	Location    models.UserLocation `json:"location"`
+	TaiChanged  bool                `json:"taiChanged"`
+	CellChanged bool                `json:"cellChanged"`
+	Emergency   bool                `json:"emergency"` // the UE is in emergency services
+	// Registration area of the UE, where it is paged while in CM-IDLE
+	RegistrationArea        []models.Tai `json:"registrationArea,omitempty"`
+	RegistrationAreaChanged bool         `json:"registrationAreaChanged"`
+}
+
+// Subscriber receives the location updates of one UE on C. Publishing never waits
+// for a subscriber: when its buffer is full the oldest update is dropped, so a
+// slow consumer only loses stale positions.
+type Subscriber struct {
+	C       chan LocationUpdate
+	supi    string
+	filter  Filter
+	mutex   sync.Mutex
+	dropped uint64
+}
+
+// Dropped returns the number of updates dropped since the last call
+func (subscriber *Subscriber) Dropped() uint64 {
+	subscriber.mutex.Lock()
+	defer subscriber.mutex.Unlock()
+
+	dropped := subscriber.dropped
+	subscriber.dropped = 0
+	return dropped
+}
+
+func (subscriber *Subscriber) accepts(update LocationUpdate) bool {
+	switch subscriber.filter {
+	case FilterTaiChange:
+		return update.TaiChanged
+	case FilterCellChange:
+		return update.CellChanged
+	case FilterRegistrationAreaChange:
+		return update.RegistrationAreaChanged
+	default:
+		return true
+	}
+}
+
+func (subscriber *Subscriber) deliver(update LocationUpdate) {
+	subscriber.mutex.Lock()
+	defer subscriber.mutex.Unlock()
+
+	for {
+		select {
+		case subscriber.C <- update:
+			return
+		default:
+		}
+		select {
+		case <-subscriber.C:
+			subscriber.dropped++
+		default:
+		}
+	}
+}
+
+var subscribers = make(map[string]map[*Subscriber]struct{}) // SUPI as key
+var lastLocations = make(map[string]*list.Element)          // SUPI as key
+var lastLocationOrder list.List                             // of LocationUpdate, least recently updated first
+var brokerMutex sync.RWMutex
+
+// Subscribe registers a subscriber for the location updates of a UE
+func Subscribe(supi string, filter Filter, bufferSize int) *Subscriber {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	subscriber := &Subscriber{
+		C:      make(chan LocationUpdate, bufferSize),
+		supi:   supi,
+		filter: filter,
+	}
+
+	brokerMutex.Lock()
+	defer brokerMutex.Unlock()
+	if subscribers[supi] == nil {
+		subscribers[supi] = make(map[*Subscriber]struct{})
+	}
+	subscribers[supi][subscriber] = struct{}{}
+	return subscriber
+}
+
+func Unsubscribe(subscriber *Subscriber) {
+	brokerMutex.Lock()
+	defer brokerMutex.Unlock()
+
+	delete(subscribers[subscriber.supi], subscriber)
+	if len(subscribers[subscriber.supi]) == 0 {
+		delete(subscribers, subscriber.supi)
+	}
+}
+
+// Publish fills in the TAI of a location update and its TAI, cell and registration
+// area change flags, relative to the previous update of the UE, and hands it to the
+// subscribers of the UE. The broker stays locked until the update is delivered,
+// so the subscribers get the updates in the order their flags were computed.
+func Publish(update LocationUpdate) LocationUpdate {
+	tai, cell := taiAndCell(update.Location)
+	if tai != nil {
+		update.Tai = *tai
+	}
+
+	brokerMutex.Lock()
+	defer brokerMutex.Unlock()
+	var previous LocationUpdate
+	element, known := lastLocations[update.Supi]
+	if known {
+		previous = element.Value.(LocationUpdate)
+	}
+	previousTai, previousCell := taiAndCell(previous.Location)
+	update.TaiChanged = !known || previousTai == nil || !reflect.DeepEqual(*previousTai, update.Tai)
+	update.CellChanged = !known || previousCell != cell || update.TaiChanged
+	update.RegistrationAreaChanged = !known || !reflect.DeepEqual(previous.RegistrationArea, update.RegistrationArea)
+	if known {
+		element.Value = update
+		lastLocationOrder.MoveToBack(element)
+	} else {
+		lastLocations[update.Supi] = lastLocationOrder.PushBack(update)
+		for len(lastLocations) > MaxLastLocations {
+			oldest := lastLocationOrder.Front()
+			delete(lastLocations, oldest.Value.(LocationUpdate).Supi)
+			lastLocationOrder.Remove(oldest)
+		}
+	}
+
+	for subscriber := range subscribers[update.Supi] {
+		if subscriber.accepts(update) {
+			subscriber.deliver(update)
+		}
+	}
+	return update
+}
+
+// LastLocation returns the last location update published for a UE
+func LastLocation(supi string) (LocationUpdate, bool) {
+	brokerMutex.RLock()
+	defer brokerMutex.RUnlock()
+
+	if element, ok := lastLocations[supi]; ok {
+		return element.Value.(LocationUpdate), true
+	}
+	return LocationUpdate{}, false
+}
+
+// LastLocations returns the last location update published for every UE
+func LastLocations() []LocationUpdate {
+	brokerMutex.RLock()
+	defer brokerMutex.RUnlock()
+
+	updates := make([]LocationUpdate, 0, len(lastLocations))
+	for element := lastLocationOrder.Front(); element != nil; element = element.Next() {
+		updates = append(updates, element.Value.(LocationUpdate))
+	}
+	return updates
+}
+
+// Forget drops the last location of a UE, e.g. when its location data is purged
+func Forget(supi string) {
+	brokerMutex.Lock()
+	defer brokerMutex.Unlock()
+
+	if element, ok := lastLocations[supi]; ok {
+		delete(lastLocations, supi)
+		lastLocationOrder.Remove(element)
+	}
+}
+
+// PurgeLastLocations drops the last locations published before purgeBefore and
+// returns how many were dropped
+func PurgeLastLocations(purgeBefore time.Time) int {
+	brokerMutex.Lock()
+	defer brokerMutex.Unlock()
+
+	purged := 0
+	for element := lastLocationOrder.Front(); element != nil; {
+		next := element.Next()
+		if update := element.Value.(LocationUpdate); update.Time.Before(purgeBefore) {
+			delete(lastLocations, update.Supi)
+			lastLocationOrder.Remove(element)
+			purged++
+		}
+		element = next
+	}
+	return purged
+}
+
+// LocationTai returns the TAI of a location
+func LocationTai(location models.UserLocation) (models.Tai, bool) {
+	if tai, _ := taiAndCell(location); tai != nil {
+		return *tai, true
+	}
+	return models.Tai{}, false
+}
+
+func taiAndCell(location models.UserLocation) (*models.Tai, string) {
+	switch {
+	case location.NrLocation != nil:
+		cell := ""
+		if location.NrLocation.Ncgi != nil {
+			cell = location.NrLocation.Ncgi.NrCellId
+		}
+		return location.NrLocation.Tai, cell
+	case location.EutraLocation != nil:
+		cell := ""
+		if location.EutraLocation.Ecgi != nil {
+			cell = location.EutraLocation.Ecgi.EutraCellId
+		}
+		return location.EutraLocation.Tai, cell
+	case location.N3gaLocation != nil:
+		return location.N3gaLocation.N3gppTai, location.N3gaLocation.UeIpv4Addr + location.N3gaLocation.UeIpv6Addr
+	}
+	return nil, ""
+}
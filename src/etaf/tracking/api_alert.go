@@ -0,0 +1,79 @@
+package tracking
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/producer"
+	"free5gc/src/etaf/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HTTPIssueAlert(c *gin.Context) {
+	var createData producer.AlertCreateData
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		logger.HttpLog.Errorf("Get Request Body error: %+v", err)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&createData, requestBody, "application/json")
+	if err != nil {
+		problemDetail := "[Request Body] " + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.HttpLog.Errorln(problemDetail)
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	req := http_wrapper.NewRequest(c.Request, createData)
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleIssueAlert(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPGetAlerts(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleGetAlerts(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPGetAlert(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["alertId"] = c.Params.ByName("alertId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleGetAlert(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPCancelAlert(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["alertId"] = c.Params.ByName("alertId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleCancelAlert(req)
+
+	sendResponse(c, rsp)
+}
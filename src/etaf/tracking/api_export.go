@@ -0,0 +1,39 @@
+package tracking
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/src/etaf/producer"
+	"free5gc/src/etaf/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HTTPExportUeTrack(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleExportUeTrack(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPExportTrackingSessionTrack(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["sessionId"] = c.Params.ByName("sessionId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleExportTrackingSessionTrack(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPExportAlertArea(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["alertId"] = c.Params.ByName("alertId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleExportAlertArea(req)
+
+	sendResponse(c, rsp)
+}
@@ -0,0 +1,71 @@
+package tracking
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/producer"
+	"free5gc/src/etaf/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HTTPRequestLocationReporting(c *gin.Context) {
+	var reportingRequest producer.LocationReportingRequest
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		logger.HttpLog.Errorf("Get Request Body error: %+v", err)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&reportingRequest, requestBody, "application/json")
+	if err != nil {
+		problemDetail := "[Request Body] " + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.HttpLog.Errorln(problemDetail)
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	req := http_wrapper.NewRequest(c.Request, reportingRequest)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleRequestLocationReporting(c.Request.Context(), req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPGetLocationReporting(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleGetLocationReporting(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPStopLocationReporting(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["ueId"] = c.Params.ByName("ueId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleStopLocationReporting(c.Request.Context(), req)
+
+	sendResponse(c, rsp)
+}
@@ -0,0 +1,245 @@
+package tracking
+
+import (
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/audit"
+	etaf_context "free5gc/src/etaf/context"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/privacy"
+	"free5gc/src/etaf/stream"
+	"free5gc/src/etaf/util"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultHeartbeatInterval = 15 // seconds
+	webSocketWriteTimeout    = 10 * time.Second
+)
+
+// Event types of the SSE events and of the "type" of WebSocket frames
+const (
+	streamEventLocation  = "location"
+	streamEventHeartbeat = "heartbeat"
+	streamEventDropped   = "dropped" // updates dropped because the client reads too slowly
+)
+
+type streamFrame struct {
+	Type    string                 `json:"type"`
+	Time    time.Time              `json:"time"`
+	Update  *stream.LocationUpdate `json:"update,omitempty"`
+	Dropped uint64                 `json:"dropped,omitempty"`
+}
+
+// locationStream is one client following the location of a UE
+type locationStream struct {
+	caller     string
+	supi       string
+	subscriber *stream.Subscriber
+	heartbeat  *time.Ticker
+	auditEntry audit.Entry
+	sent       int
+	dropped    uint64
+}
+
+// HTTPLocationStream pushes the location updates of a UE as Server-Sent Events,
+// or over a WebSocket when the request asks for an upgrade
+func HTTPLocationStream(c *gin.Context) {
+	caller := util.CallerIdentity(c.Request)
+	supi, err := privacy.ResolveUeId(caller, c.Params.ByName("ueId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ProblemDetails{
+			Title:  "Invalid UE identifier",
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: err.Error(),
+		})
+		return
+	}
+
+	// streams are only opened for the UEs the ETAF knows, so they cannot be used to
+	// wait for arbitrary subscribers to show up
+	if !knownUe(supi) {
+		c.JSON(http.StatusNotFound, models.ProblemDetails{
+			Title:  "UE not found",
+			Status: http.StatusNotFound,
+			Cause:  "CONTEXT_NOT_FOUND",
+		})
+		return
+	}
+
+	filter := stream.Filter(c.Query("filter"))
+	switch filter {
+	case "":
+		filter = stream.FilterAll
+	case stream.FilterAll, stream.FilterTaiChange, stream.FilterCellChange, stream.FilterRegistrationAreaChange:
+	default:
+		c.JSON(http.StatusBadRequest, models.ProblemDetails{
+			Title:  "Invalid query parameter",
+			Status: http.StatusBadRequest,
+			Cause:  "MANDATORY_IE_INCORRECT",
+			Detail: "filter must be all, tai, cell or registrationArea",
+		})
+		return
+	}
+	heartbeatInterval := defaultHeartbeatInterval
+	if value := c.Query("heartbeat"); value != "" {
+		interval, err := strconv.Atoi(value)
+		if err != nil || interval <= 0 {
+			c.JSON(http.StatusBadRequest, models.ProblemDetails{
+				Title:  "Invalid query parameter",
+				Status: http.StatusBadRequest,
+				Cause:  "MANDATORY_IE_INCORRECT",
+				Detail: "heartbeat must be a positive number of seconds",
+			})
+			return
+		}
+		heartbeatInterval = interval
+	}
+
+	auditEntry := audit.Entry{
+		Caller:        caller,
+		Action:        audit.ActionLocationDisclosure,
+		UeId:          supi,
+		Purpose:       c.Query("purpose"),
+		CorrelationId: c.GetHeader(logger.CorrelationIDHeader),
+		Outcome:       audit.OutcomeSuccess,
+	}
+	// location data must not be disclosed without an audit trail
+	if err := audit.Record(auditEntry, gin.H{"stream": "opened", "filter": filter}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ProblemDetails{
+			Title:  "Audit trail unavailable",
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: "the disclosure could not be audited",
+		})
+		return
+	}
+
+	locStream := &locationStream{
+		caller:     caller,
+		supi:       supi,
+		subscriber: stream.Subscribe(supi, filter, stream.DefaultBufferSize),
+		heartbeat:  time.NewTicker(time.Duration(heartbeatInterval) * time.Second),
+		auditEntry: auditEntry,
+	}
+	defer locStream.close()
+
+	log := logger.WithSupi(logger.HttpLog.WithContext(c.Request.Context()), supi)
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		log.Infof("Location stream opened over WebSocket for caller[%s]", caller)
+		server := websocket.Server{Handler: locStream.serveWebSocket}
+		server.ServeHTTP(c.Writer, c.Request)
+	} else {
+		log.Infof("Location stream opened over SSE for caller[%s]", caller)
+		locStream.serveSSE(c)
+	}
+	log.Infof("Location stream closed after %d updates, %d dropped", locStream.sent, locStream.dropped)
+}
+
+// knownUe reports whether a UE is registered or was located by a location report
+func knownUe(ueId string) bool {
+	etafSelf := etaf_context.ETAF_Self()
+	if _, ok := etafSelf.EtafUeFindBySupi(ueId); ok {
+		return true
+	}
+	if etaf_context.IsImeiPei(ueId) {
+		if _, ok := etafSelf.EtafUeFindByPei(ueId); ok {
+			return true
+		}
+	}
+	_, ok := stream.LastLocation(ueId)
+	return ok
+}
+
+func (locStream *locationStream) close() {
+	stream.Unsubscribe(locStream.subscriber)
+	locStream.heartbeat.Stop()
+	audit.Record(locStream.auditEntry, gin.H{"stream": "closed", "updates": locStream.sent,
+		"dropped": locStream.dropped})
+}
+
+// protect pseudonymises the SUPI of an update as configured for the caller
+func (locStream *locationStream) protect(update stream.LocationUpdate) *stream.LocationUpdate {
+	update.Supi = privacy.ProtectIdentifier(locStream.caller, update.Supi)
+	return &update
+}
+
+func (locStream *locationStream) serveSSE(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	done := c.Request.Context().Done()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update := <-locStream.subscriber.C:
+			if dropped := locStream.subscriber.Dropped(); dropped != 0 {
+				locStream.dropped += dropped
+				c.SSEvent(streamEventDropped, dropped)
+			}
+			c.SSEvent(streamEventLocation, locStream.protect(update))
+			locStream.sent++
+		case now := <-locStream.heartbeat.C:
+			c.SSEvent(streamEventHeartbeat, now.UTC())
+		case <-done:
+			return false
+		}
+		return true
+	})
+}
+
+func (locStream *locationStream) serveWebSocket(ws *websocket.Conn) {
+	// the client sends nothing; a failed read means it has gone
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		var frame streamFrame
+		select {
+		case update := <-locStream.subscriber.C:
+			if dropped := locStream.subscriber.Dropped(); dropped != 0 {
+				locStream.dropped += dropped
+				if !locStream.sendFrame(ws, streamFrame{Type: streamEventDropped, Time: time.Now().UTC(),
+					Dropped: dropped}) {
+					return
+				}
+			}
+			frame = streamFrame{Type: streamEventLocation, Time: update.Time, Update: locStream.protect(update)}
+			locStream.sent++
+		case now := <-locStream.heartbeat.C:
+			frame = streamFrame{Type: streamEventHeartbeat, Time: now.UTC()}
+		case <-done:
+			return
+		}
+		if !locStream.sendFrame(ws, frame) {
+			return
+		}
+	}
+}
+
+// sendFrame gives up on clients that stop reading instead of letting them hold
+// the stream open forever
+func (locStream *locationStream) sendFrame(ws *websocket.Conn, frame streamFrame) bool {
+	if err := ws.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout)); err != nil {
+		return false
+	}
+	if err := websocket.JSON.Send(ws, frame); err != nil {
+		logger.HttpLog.Warnf("Send location stream frame error: %+v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package tracking
+
+import (
+	"free5gc/lib/http_wrapper"
+	"free5gc/lib/openapi"
+	"free5gc/lib/openapi/models"
+	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/producer"
+	"free5gc/src/etaf/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HTTPCreateTrackingSession(c *gin.Context) {
+	var createData producer.TrackingSessionCreateData
+
+	requestBody, err := c.GetRawData()
+	if err != nil {
+		problemDetail := models.ProblemDetails{
+			Title:  "System failure",
+			Status: http.StatusInternalServerError,
+			Detail: err.Error(),
+			Cause:  "SYSTEM_FAILURE",
+		}
+		logger.HttpLog.Errorf("Get Request Body error: %+v", err)
+		c.JSON(http.StatusInternalServerError, problemDetail)
+		return
+	}
+
+	err = openapi.Deserialize(&createData, requestBody, "application/json")
+	if err != nil {
+		problemDetail := "[Request Body] " + err.Error()
+		rsp := models.ProblemDetails{
+			Title:  "Malformed request syntax",
+			Status: http.StatusBadRequest,
+			Detail: problemDetail,
+		}
+		logger.HttpLog.Errorln(problemDetail)
+		c.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	req := http_wrapper.NewRequest(c.Request, createData)
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleCreateTrackingSession(c.Request.Context(), req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPGetTrackingSession(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["sessionId"] = c.Params.ByName("sessionId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleGetTrackingSession(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPDeleteTrackingSession(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["sessionId"] = c.Params.ByName("sessionId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleDeleteTrackingSession(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPGetTrackingSessionDeadLetters(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["sessionId"] = c.Params.ByName("sessionId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleGetTrackingSessionDeadLetters(req)
+
+	sendResponse(c, rsp)
+}
+
+func HTTPGetTrackingSessionLocations(c *gin.Context) {
+	req := http_wrapper.NewRequest(c.Request, nil)
+	req.Params["sessionId"] = c.Params.ByName("sessionId")
+	req.Params["caller"] = util.CallerIdentity(c.Request)
+
+	rsp := producer.HandleGetTrackingSessionLocations(req)
+
+	sendResponse(c, rsp)
+}
+
+func sendResponse(c *gin.Context, rsp *http_wrapper.Response) {
+	for key, val := range rsp.Header {
+		c.Header(key, val[0])
+	}
+	if rsp.Body == nil {
+		c.Status(rsp.Status)
+		return
+	}
+	// exports are rendered by the producer
+	if body, ok := rsp.Body.([]byte); ok {
+		c.Data(rsp.Status, rsp.Header.Get("Content-Type"), body)
+		return
+	}
+	responseBody, err := openapi.Serialize(rsp.Body, "application/json")
+	if err != nil {
+		logger.HttpLog.Errorln(err)
+		problemDetails := models.ProblemDetails{
+			Status: http.StatusInternalServerError,
+			Cause:  "SYSTEM_FAILURE",
+			Detail: err.Error(),
+		}
+		c.JSON(http.StatusInternalServerError, problemDetails)
+	} else {
+		c.Data(rsp.Status, "application/json", responseBody)
+	}
+}
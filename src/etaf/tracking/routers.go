@@ -12,6 +12,7 @@ package tracking
 import (
 	"free5gc/lib/logger_util"
 	"free5gc/src/etaf/logger"
+	"free5gc/src/etaf/util"
 	"net/http"
 	"github.com/sirupsen/logrus"
 
@@ -50,15 +51,16 @@ func AddService(engine *gin.Engine) *gin.RouterGroup {
 	group := engine.Group("/netaf-track/v1")
 
 	for _, route := range routes {
+		handlerFunc := util.TracingHandler("netaf-track", group.BasePath()+route.Pattern, route.HandlerFunc)
 		switch route.Method {
 		case "GET":
-			group.GET(route.Pattern, route.HandlerFunc)
+			group.GET(route.Pattern, handlerFunc)
 		case "POST":
-			group.POST(route.Pattern, route.HandlerFunc)
+			group.POST(route.Pattern, handlerFunc)
 		case "PUT":
-			group.PUT(route.Pattern, route.HandlerFunc)
+			group.PUT(route.Pattern, handlerFunc)
 		case "DELETE":
-			group.DELETE(route.Pattern, route.HandlerFunc)
+			group.DELETE(route.Pattern, handlerFunc)
 		}
 	}
 	return group
@@ -77,6 +79,132 @@ var routes = Routes{
 		Index,
 	},
 
+	{
+		"LocationStream",
+		"GET",
+		"/ue/:ueId/locations/stream",
+		HTTPLocationStream,
+	},
+
+	{
+		"LocateUe",
+		"POST",
+		"/ue/:ueId/locate",
+		HTTPLocateUe,
+	},
+
+	{
+		"ExportUeTrack",
+		"GET",
+		"/ue/:ueId/locations/export",
+		HTTPExportUeTrack,
+	},
+
+	{
+		"RequestLocationReporting",
+		"POST",
+		"/ue/:ueId/location-reporting",
+		HTTPRequestLocationReporting,
+	},
+
+	{
+		"GetLocationReporting",
+		"GET",
+		"/ue/:ueId/location-reporting",
+		HTTPGetLocationReporting,
+	},
+
+	{
+		"StopLocationReporting",
+		"DELETE",
+		"/ue/:ueId/location-reporting",
+		HTTPStopLocationReporting,
+	},
+
+	{
+		"CreateTrackingSession",
+		"POST",
+		"/sessions",
+		HTTPCreateTrackingSession,
+	},
+
+	{
+		"GetTrackingSession",
+		"GET",
+		"/sessions/:sessionId",
+		HTTPGetTrackingSession,
+	},
+
+	{
+		"DeleteTrackingSession",
+		"DELETE",
+		"/sessions/:sessionId",
+		HTTPDeleteTrackingSession,
+	},
+
+	{
+		"GetTrackingSessionDeadLetters",
+		"GET",
+		"/sessions/:sessionId/dead-letters",
+		HTTPGetTrackingSessionDeadLetters,
+	},
+
+	{
+		"GetTrackingSessionLocations",
+		"GET",
+		"/sessions/:sessionId/locations",
+		HTTPGetTrackingSessionLocations,
+	},
+
+	{
+		"ExportTrackingSessionTrack",
+		"GET",
+		"/sessions/:sessionId/locations/export",
+		HTTPExportTrackingSessionTrack,
+	},
+
+	{
+		"AreaQuery",
+		"POST",
+		"/area-query",
+		HTTPAreaQuery,
+	},
+
+	{
+		"IssueAlert",
+		"POST",
+		"/alerts",
+		HTTPIssueAlert,
+	},
+
+	{
+		"GetAlerts",
+		"GET",
+		"/alerts",
+		HTTPGetAlerts,
+	},
+
+	{
+		"GetAlert",
+		"GET",
+		"/alerts/:alertId",
+		HTTPGetAlert,
+	},
+
+	{
+		"CancelAlert",
+		"DELETE",
+		"/alerts/:alertId",
+		HTTPCancelAlert,
+	},
+
+	{
+		"ExportAlertArea",
+		"GET",
+		"/alerts/:alertId/area/export",
+		HTTPExportAlertArea,
+	},
+
 	// {
 	// 	"N1N2MessageTransfer",
 	// 	strings.ToUpper("Post"),
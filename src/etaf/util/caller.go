@@ -0,0 +1,168 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+)
+
+// CallerAddressPrefix prefixes the identity of the callers that did not
+// authenticate, which are named by their address
+const CallerAddressPrefix = "address:"
+
+// tokenVerificationKey verifies the signature of the access tokens, which are
+// ignored when it is nil
+var tokenVerificationKey interface{}
+
+// InitCallerAuthentication loads the public key of the access token issuer
+func InitCallerAuthentication() error {
+	tokenVerificationKey = nil
+	authentication := factory.EtafConfig.Configuration.Authentication
+	if authentication == nil || authentication.TokenVerificationKeyPath == "" {
+		return nil
+	}
+
+	if authentication.TokenIssuer == "" || authentication.TokenAudience == "" {
+		return fmt.Errorf("Token issuer and audience must be set along with the token verification key")
+	}
+
+	raw, err := ioutil.ReadFile(authentication.TokenVerificationKeyPath)
+	if err != nil {
+		return fmt.Errorf("Read token verification key error: %+v", err)
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(raw); err == nil {
+		tokenVerificationKey = key
+		return nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(raw); err == nil {
+		tokenVerificationKey = key
+		return nil
+	}
+	return fmt.Errorf("Token verification key[%s] is neither an RSA nor an EC public key",
+		authentication.TokenVerificationKeyPath)
+}
+
+// ConfigureClientAuthentication makes server verify the TLS client certificates
+// against the configured CAs; the certificate is optional, callers without one
+// are named by their token or address
+func ConfigureClientAuthentication(server *http.Server) error {
+	authentication := factory.EtafConfig.Configuration.Authentication
+	if authentication == nil || authentication.ClientCaPath == "" {
+		return nil
+	}
+
+	raw, err := ioutil.ReadFile(authentication.ClientCaPath)
+	if err != nil {
+		return fmt.Errorf("Read client CA error: %+v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(raw) {
+		return fmt.Errorf("No certificate in client CA[%s]", authentication.ClientCaPath)
+	}
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{}
+	}
+	server.TLSConfig.ClientCAs = clientCAs
+	server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return nil
+}
+
+// CallerIdentity names the client of a request for auditing and privacy controls:
+// the subject of its verified bearer token, else the subject of its verified TLS
+// client certificate, else its address prefixed by CallerAddressPrefix
+func CallerIdentity(req *http.Request) string {
+	if subject, ok := tokenSubject(req); ok {
+		return subject
+	}
+
+	if req.TLS != nil && len(req.TLS.VerifiedChains) != 0 && len(req.TLS.VerifiedChains[0]) != 0 {
+		subject := req.TLS.VerifiedChains[0][0].Subject
+		if subject.CommonName != "" {
+			return subject.CommonName
+		}
+		return subject.String()
+	}
+
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return CallerAddressPrefix + host
+	}
+	return CallerAddressPrefix + req.RemoteAddr
+}
+
+// IsOperator tells whether caller is an authenticated caller configured as operator
+func IsOperator(caller string) bool {
+	authentication := factory.EtafConfig.Configuration.Authentication
+	if authentication == nil || strings.HasPrefix(caller, CallerAddressPrefix) {
+		return false
+	}
+	for _, operator := range authentication.Operators {
+		if operator == caller {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenSubject returns the subject of the bearer token of a request once its
+// signature, validity period, issuer and audience are verified
+func tokenSubject(req *http.Request) (string, bool) {
+	authorization := req.Header.Get("Authorization")
+	if tokenVerificationKey == nil || !strings.HasPrefix(authorization, "Bearer ") {
+		return "", false
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimPrefix(authorization, "Bearer "), claims,
+		func(token *jwt.Token) (interface{}, error) {
+			switch tokenVerificationKey.(type) {
+			case *rsa.PublicKey:
+				if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+					return tokenVerificationKey, nil
+				}
+			case *ecdsa.PublicKey:
+				if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+					return tokenVerificationKey, nil
+				}
+			}
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		})
+	if err != nil || !token.Valid {
+		logger.UtilLog.Warnf("Access token of %s rejected: %+v", req.RemoteAddr, err)
+		return "", false
+	}
+	authentication := factory.EtafConfig.Configuration.Authentication
+	if !claims.VerifyIssuer(authentication.TokenIssuer, true) ||
+		!audienceIncludes(claims["aud"], authentication.TokenAudience) {
+		logger.UtilLog.Warnf("Access token of %s rejected: issued by %v for %v", req.RemoteAddr,
+			claims["iss"], claims["aud"])
+		return "", false
+	}
+	sub, ok := claims["sub"].(string)
+	return sub, ok && sub != ""
+}
+
+// audienceIncludes tells whether the aud claim, a string or an array of strings, names audience
+func audienceIncludes(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, item := range aud {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"free5gc/src/etaf/logger"
+)
+
+// CorrelationID takes the correlation ID of an inbound request from its header, or
+// generates one, and makes it available to handlers through the request context
+// and header and to the caller through the response header
+func CorrelationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetHeader(logger.CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = logger.NewCorrelationID()
+			c.Request.Header.Set(logger.CorrelationIDHeader, correlationID)
+		}
+		c.Request = c.Request.WithContext(logger.ContextWithCorrelationID(c.Request.Context(), correlationID))
+		c.Header(logger.CorrelationIDHeader, correlationID)
+		c.Next()
+	}
+}
@@ -21,11 +21,15 @@ func InitEtafContext(context *context.ETAFContext) {
 	if configuration.EtafName != "" {
 		context.Name = configuration.EtafName
 	}
-	// if configuration.NgapIpList != nil {
-	// 	context.NgapIpList = configuration.NgapIpList
-	// } else {
-	// 	context.NgapIpList = []string{"127.0.0.1"} // default localhost
-	// }
+	if configuration.NgapIpList != nil {
+		context.NgapIpList = configuration.NgapIpList
+	} else {
+		context.NgapIpList = []string{"127.0.0.1"} // default localhost
+	}
+	context.NgapPort = 38413 // default port, 38412 being the one of the AMF
+	if configuration.NgapPort != 0 {
+		context.NgapPort = configuration.NgapPort
+	}
 	sbi := configuration.Sbi
 	if sbi.Scheme != "" {
 		context.UriScheme = models.UriScheme(sbi.Scheme)
@@ -77,6 +81,56 @@ func InitEtafContext(context *context.ETAFContext) {
 	context.T3502Value = configuration.T3502
 	context.T3512Value = configuration.T3512
 	context.Non3gppDeregistrationTimerValue = configuration.Non3gppDeregistrationTimer
+	if configuration.TmsiGuardPeriod != nil {
+		context.TmsiGuardPeriod = *configuration.TmsiGuardPeriod
+	}
+	if registrationArea := configuration.RegistrationArea; registrationArea != nil {
+		context.RegistrationAreaConfig = registrationAreaConfig(registrationArea)
+	}
+	if non3gppTai := configuration.Non3gppTai; non3gppTai != nil {
+		context.Non3gppTai = non3gppTaiConfig(non3gppTai)
+	}
+}
+
+func registrationAreaConfig(registrationArea *factory.RegistrationArea) context.RegistrationAreaConfig {
+	config := context.RegistrationAreaConfig{
+		Strategy:       context.RegistrationAreaStrategy(registrationArea.Strategy),
+		MobilityWindow: registrationArea.MobilityWindow,
+		MaxListSize:    registrationArea.MaxListSize,
+	}
+	for _, taiNeighbours := range registrationArea.Neighbours {
+		neighbours := context.TaiNeighbours{Tai: taiConfigToModels(taiNeighbours.Tai)}
+		for _, neighbour := range taiNeighbours.Neighbours {
+			neighbours.Neighbours = append(neighbours.Neighbours, taiConfigToModels(neighbour))
+		}
+		config.Neighbours = append(config.Neighbours, neighbours)
+	}
+	return config
+}
+
+func non3gppTaiConfig(non3gppTai *factory.Non3gppTai) context.Non3gppTaiConfig {
+	var config context.Non3gppTaiConfig
+	if non3gppTai.Default != nil {
+		defaultTai := taiConfigToModels(*non3gppTai.Default)
+		config.Default = &defaultTai
+	}
+	for _, n3iwfTai := range non3gppTai.N3iwfs {
+		config.N3iwfs = append(config.N3iwfs, context.N3iwfTai{
+			N3IwfId: n3iwfTai.N3IwfId,
+			Tai:     taiConfigToModels(n3iwfTai.Tai),
+		})
+	}
+	return config
+}
+
+// taiConfigToModels converts the TAC of a configured TAI, and copies its PLMN ID
+func taiConfigToModels(tai models.Tai) models.Tai {
+	converted := models.Tai{Tac: TACConfigToModels(tai.Tac)}
+	if tai.PlmnId != nil {
+		plmnId := *tai.PlmnId
+		converted.PlmnId = &plmnId
+	}
+	return converted
 }
 
 func getIntAlgOrder(integrityOrder []string) (intOrder []uint8) {
@@ -0,0 +1,136 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/propagators"
+	export "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+
+	"free5gc/src/etaf/factory"
+	"free5gc/src/etaf/logger"
+)
+
+const (
+	TracingExporterOtlp = "otlp"
+	TracingExporterFile = "file"
+
+	tracerName                = "free5gc/src/etaf"
+	defaultOtlpAddress        = "localhost:55680"
+	defaultTracingFilePath    = "etaf-traces.json"
+	correlationIDAttributeKey = "etaf.correlation_id"
+)
+
+var spanProcessor *sdktrace.BatchSpanProcessor
+var traceFile *os.File
+
+// InitTracing installs the tracer provider and the W3C trace context propagator
+// described by the tracing configuration. When tracing is disabled the global
+// no-op provider stays in place and spans cost next to nothing.
+func InitTracing() error {
+	global.SetTextMapPropagator(propagators.TraceContext{})
+
+	tracing := factory.EtafConfig.Configuration.Tracing
+	if tracing == nil || !tracing.Enable {
+		return nil
+	}
+
+	var exporter export.SpanExporter
+	switch tracing.Exporter {
+	case TracingExporterOtlp, "":
+		address := tracing.OtlpAddress
+		if address == "" {
+			address = defaultOtlpAddress
+		}
+		otlpExporter, err := otlp.NewExporter(otlp.WithInsecure(), otlp.WithAddress(address))
+		if err != nil {
+			return fmt.Errorf("Create OTLP exporter error: %+v", err)
+		}
+		exporter = otlpExporter
+	case TracingExporterFile:
+		filePath := tracing.FilePath
+		if filePath == "" {
+			filePath = defaultTracingFilePath
+		}
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+		if err != nil {
+			return fmt.Errorf("Open trace file error: %+v", err)
+		}
+		fileExporter, err := stdout.NewExporter(stdout.WithWriter(file), stdout.WithoutMetricExport())
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("Create file exporter error: %+v", err)
+		}
+		exporter = fileExporter
+		traceFile = file
+	default:
+		return fmt.Errorf("Unsupported tracing exporter[%s]", tracing.Exporter)
+	}
+
+	spanProcessor = sdktrace.NewBatchSpanProcessor(exporter)
+	global.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+		sdktrace.WithResource(resource.New(semconv.ServiceNameKey.String("etaf"))),
+		sdktrace.WithSpanProcessor(spanProcessor),
+	))
+	return nil
+}
+
+// ShutdownTracing flushes the pending spans
+func ShutdownTracing() {
+	if spanProcessor == nil {
+		return
+	}
+	spanProcessor.Shutdown()
+	if traceFile != nil {
+		if err := traceFile.Close(); err != nil {
+			logger.InitLog.Warnf("Close trace file error: %+v", err)
+		}
+	}
+}
+
+func Tracer() trace.Tracer {
+	return global.Tracer(tracerName)
+}
+
+// TracingHandler wraps a route handler in a server span that continues the trace
+// context of the inbound request
+func TracingHandler(serviceName, route string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := global.TextMapPropagator().Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(serviceName, route, c.Request)...))
+		defer span.End()
+		if correlationID := logger.CorrelationIDFromContext(ctx); correlationID != "" {
+			span.SetAttributes(label.String(correlationIDAttributeKey, correlationID))
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		handler(c)
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+	}
+}
+
+// SetSpanError marks the span of an operation as failed
+func SetSpanError(ctx context.Context, span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(ctx, err)
+	span.SetStatus(codes.Error, err.Error())
+}